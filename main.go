@@ -2,51 +2,206 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/muktihari/quiz_master/pkg/textdiff"
 	"github.com/muktihari/quiz_master/pkg/textinput"
 	"github.com/muktihari/quiz_master/questionnaire"
+	"github.com/muktihari/quiz_master/questionnaire/convert"
+	"github.com/muktihari/quiz_master/questionnaire/printout"
+	_ "modernc.org/sqlite"
 )
 
 // Command is command inside the CLI.
 type Command string
 
 const (
-	Exit           Command = "exit"
-	Help           Command = "help"
-	Question       Command = "question"
-	Questions      Command = "questions"
-	CreateQuestion Command = "create_question"
-	UpdateQuestion Command = "update_question"
-	DeleteQuestion Command = "delete_question"
-	AnswerQuestion Command = "answer_question"
+	Exit            Command = "exit"
+	Help            Command = "help"
+	Question        Command = "question"
+	Questions       Command = "questions"
+	CreateQuestion  Command = "create_question"
+	UpdateQuestion  Command = "update_question"
+	DeleteQuestion  Command = "delete_question"
+	AnswerQuestion  Command = "answer_question"
+	Play            Command = "play"
+	Compact         Command = "compact"
+	Trash           Command = "trash"
+	RestoreQuestion Command = "restore_question"
+	Purge           Command = "purge"
+	History         Command = "history"
+	RevertQuestion  Command = "revert_question"
+	DiffQuestion    Command = "diff_question"
+	Undo            Command = "undo"
+	Redo            Command = "redo"
+	Begin           Command = "begin"
+	Commit          Command = "commit"
+	Rollback        Command = "rollback"
+	Banks           Command = "banks"
+	CreateBank      Command = "create_bank"
+	Use             Command = "use"
+	CopyQuestion    Command = "copy_question"
+	Tag             Command = "tag"
+	Untag           Command = "untag"
+	Search          Command = "search"
+	ImportCSV       Command = "import_csv"
+	ExportCSV       Command = "export_csv"
+	Load            Command = "load"
+	Save            Command = "save"
+	ImportMoodle    Command = "import_moodle"
+	ExportMoodle    Command = "export_moodle"
+	ExportQTI       Command = "export_qti"
+	ImportAnki      Command = "import_anki"
+	ExportAnki      Command = "export_anki"
+	Print           Command = "print"
 
 	HelpText = "Command | Description\n" +
 		"help | Shows list of available command\n" +
-		"create_question <no> <question> <answer> | Create a question\n" +
-		"update_question <no> <question> <answer> | Update a question\n" +
-		"delete_question <no> | Update a question\n" +
+		"create_question [--tags <tag>,...] [--category <path>] [--matching <option>,...] [<no>] <question> <answer> | Create a question, a free number is picked when none is given\n" +
+		"update_question [--if-version <version>] [--tags <tag>,...] [--category <path>] [--matching <option>,...] <no> <question> <answer> | Update a question\n" +
+		"tag <no> <tag>... | Add tags to a question\n" +
+		"untag <no> <tag>... | Remove tags from a question\n" +
+		"delete_question <no> | Move a question to trash\n" +
 		"question <no> | Shows a question\n" +
-		"questions | Shows list of question\n" +
+		"questions [--page <n>] [--limit <n>] [--sort <order>] [--from <no>] [--to <no>] [--contains <text>] [--tags <expr>] [--category <path>] [--cursor <cursor>] | Shows list of question\n" +
+		"search <terms> | Shows the questions whose question or answer contains the terms, the most relevant first\n" +
+		"play [<questions flags>] | Ask the questions selected by the questions flags one by one and shows the score\n" +
+		"trash | Shows list of question in trash\n" +
+		"restore_question <no> | Restore a question from trash\n" +
+		"purge | Delete all questions in trash for good\n" +
+		"history <no> | Shows list of revision of a question\n" +
+		"revert_question <no> <rev> | Revert a question to how it was right after a revision\n" +
+		"diff_question <no> <rev> <rev> | Shows the difference of a question between two revisions\n" +
+		"banks | Shows list of bank, the active one is marked with *\n" +
+		"create_bank <name> | Create an empty bank\n" +
+		"use <name> | Make the following commands work on the questions of a bank\n" +
+		"copy_question <no> <name> | Copy a question of the active bank into another bank\n" +
+		"import_csv [--dry-run] [--on-conflict skip|overwrite|fail] [--columns <header>=<field>,...] <file> | Import questions from a CSV file\n" +
+		"export_csv [--columns <header>=<field>,...] <file> | Export the questions of the active bank to a CSV file\n" +
+		"load [--dry-run] [--on-conflict skip|overwrite|fail] <file> | Load a bank from a JSON or YAML bank file\n" +
+		"save <file> | Save the active bank to a JSON or YAML bank file\n" +
+		"import_moodle [--dry-run] [--on-conflict skip|overwrite|fail] <file> | Import questions from a Moodle GIFT (.gift, .txt) or XML (.xml) file\n" +
+		"export_moodle <file> | Export the questions of the active bank to a Moodle GIFT (.gift, .txt) or XML (.xml) file\n" +
+		"export_qti <file> | Export the questions of the active bank to a zipped IMS QTI 2.1 content package\n" +
+		"import_anki [--dry-run] [--on-conflict skip|overwrite|fail] <file> | Import questions from an Anki text file\n" +
+		"export_anki <file> | Export the questions of the active bank to an Anki text file\n" +
+		"print [--title <title>] [--shuffle <seed>] [--page-break <n>] [--layout <file>] [<questions flags>] <file> | Print a question sheet and its answer key in Markdown (.md) or HTML (.html)\n" +
+		"undo | Undo the last change made in this session\n" +
+		"redo | Redo the last undone change\n" +
+		"compact | Compact the store, only supported by \"wal\" and \"sqlite\" store\n" +
+		"begin | Start a batch, the following changes are applied all together or not at all\n" +
+		"commit | Apply the changes of the batch, nothing is applied if any of them failed\n" +
+		"rollback | Discard the changes of the batch\n" +
 		"exit | Exit CLI\n"
 
-	PrintFormat = "Q: \"%s\"\nA: %s\n"
+	PrintFormat    = "Q: \"%s\"\nA: %s\n"
+	VersionFormat  = "Version: %d\n"
+	TagsFormat     = "Tags: %s\n"
+	CategoryFormat = "Category: %s\n"
+	MatchingFormat = "Matching: %s\n"
 )
 
 func main() {
-	var qs questionnaire.Service
-	{
-		r := questionnaire.NewRepository()
-		qs = questionnaire.NewService(r)
+	store := flag.String("store", "memory", "Question store: \"memory\", \"json:<path>\", \"wal:<path>\" or \"sqlite:<path>\"")
+	author := flag.String("author", os.Getenv("USER"), "Author recorded in the revisions of the changes")
+	undoDepth := flag.Int("undo-depth", 100, "Number of changes that can be undone, 0 disables undo")
+	idAllocation := flag.String("id-allocation", "monotonic", "Number picked for the questions created without one: \"monotonic\" or \"fill-gaps\"")
+	trashRetentionDays := flag.Int("trash-retention-days", 0, "Purge questions kept in trash longer than given days on start, 0 keeps them until \"purge\"")
+	flag.Parse()
+
+	allocate, err := idAllocator(*idAllocation)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	r, err := openRepository(*store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not open store %q: %v\n", *store, err)
+		os.Exit(1)
+	}
+	qs := questionnaire.NewUndoService(questionnaire.NewService(r,
+		questionnaire.WithTrashRetention(time.Duration(*trashRetentionDays)*24*time.Hour),
+		questionnaire.WithIDAllocator(allocate),
+	), *undoDepth)
+
+	if n, err := qs.PurgeExpired(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not purge trash: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("Purged %d question(s) kept in trash longer than %d days\n", n, *trashRetentionDays)
 	}
 
 	fmt.Println("Welcome to Quiz Master!")
-	os.Exit(run(context.Background(), qs, os.Stdin, os.Stdout))
+	code := run(questionnaire.WithAuthor(context.Background(), *author), qs, os.Stdin, os.Stdout)
+
+	if closer, ok := r.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not close store %q: %v\n", *store, err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// idAllocator returns the IDAllocator named by allocation.
+func idAllocator(allocation string) (questionnaire.IDAllocator, error) {
+	switch allocation {
+	case "monotonic":
+		return questionnaire.NextID, nil
+	case "fill-gaps":
+		return questionnaire.FirstFreeID, nil
+	}
+	return nil, fmt.Errorf("unknown id allocation %q, should be \"monotonic\" or \"fill-gaps\"", allocation)
+}
+
+// openRepository opens the Repository described by store, formatted as "<kind>[:<path>]".
+func openRepository(store string) (questionnaire.Repository, error) {
+	kind, path, _ := strings.Cut(store, ":")
+
+	switch kind {
+	case "memory":
+		return questionnaire.NewRepository(), nil
+	case "json":
+		if path == "" {
+			return nil, errors.New("missing file path, e.g. \"json:questions.json\"")
+		}
+		return questionnaire.NewFileRepository(path)
+	case "wal":
+		if path == "" {
+			return nil, errors.New("missing file path, e.g. \"wal:questions.wal\"")
+		}
+		return questionnaire.NewWALRepository(path)
+	case "sqlite":
+		if path == "" {
+			return nil, errors.New("missing file path, e.g. \"sqlite:questions.db\"")
+		}
+		// Wait for the lock instead of failing right away when another process is writing, transactions take
+		// the write lock when they begin so a transaction reading before writing never fails halfway.
+		db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+		if err != nil {
+			return nil, err
+		}
+		r, err := questionnaire.NewSQLRepository(context.Background(), db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown store kind %q", kind)
+	}
 }
 
 func run(ctx context.Context, qs questionnaire.Service, in io.Reader, out io.Writer) int {
@@ -64,24 +219,244 @@ func run(ctx context.Context, qs questionnaire.Service, in io.Reader, out io.Wri
 		switch cmd {
 		case Exit:
 			return 0
-		case Help:
-			fmt.Fprint(out, HelpText)
-		case Question:
-			question(ctx, qs, args, out)
-		case Questions:
-			questions(ctx, qs, args, out)
-		case CreateQuestion:
-			createQuestion(ctx, qs, args, out)
-		case UpdateQuestion:
-			updateQuestion(ctx, qs, args, out)
-		case DeleteQuestion:
-			deleteQuestion(ctx, qs, args, out)
-		case AnswerQuestion:
-			answerQuestion(ctx, qs, args, out)
+		case Begin:
+			if exit, code := batch(ctx, qs, scanner, args, out); exit {
+				return code
+			}
+		case Commit, Rollback:
+			fmt.Fprintln(out, "No batch in progress, start one with \"begin\"")
+		case Undo, Redo:
+			undoRedo(ctx, qs, cmd, args, out)
+		case Use:
+			ctx = use(ctx, qs, args, out)
+		case Play:
+			play(ctx, qs, scanner, args, out)
 		default:
-			fmt.Fprintf(out, "Command \"%s\" is not found. See \"help\"\n", cmd)
+			execute(ctx, qs, cmd, args, out)
+		}
+	}
+}
+
+// execute runs a command that works the same inside and outside of a batch.
+func execute(ctx context.Context, qs questionnaire.Service, cmd Command, args []string, out io.Writer) {
+	switch cmd {
+	case Help:
+		fmt.Fprint(out, HelpText)
+	case Question:
+		question(ctx, qs, args, out)
+	case Questions:
+		questions(ctx, qs, args, out)
+	case Search:
+		search(ctx, qs, args, out)
+	case CreateQuestion:
+		createQuestion(ctx, qs, args, out)
+	case UpdateQuestion:
+		updateQuestion(ctx, qs, args, out)
+	case Tag, Untag:
+		tag(ctx, qs, cmd, args, out)
+	case DeleteQuestion:
+		deleteQuestion(ctx, qs, args, out)
+	case AnswerQuestion:
+		answerQuestion(ctx, qs, args, out)
+	case Trash:
+		trash(ctx, qs, args, out)
+	case RestoreQuestion:
+		restoreQuestion(ctx, qs, args, out)
+	case Purge:
+		purge(ctx, qs, args, out)
+	case History:
+		history(ctx, qs, args, out)
+	case RevertQuestion:
+		revertQuestion(ctx, qs, args, out)
+	case DiffQuestion:
+		diffQuestion(ctx, qs, args, out)
+	case Compact:
+		compact(ctx, qs, args, out)
+	case Banks:
+		banks(ctx, qs, args, out)
+	case CreateBank:
+		createBank(ctx, qs, args, out)
+	case CopyQuestion:
+		copyQuestion(ctx, qs, args, out)
+	case ImportCSV:
+		importCSV(ctx, qs, args, out)
+	case ExportCSV:
+		exportCSV(ctx, qs, args, out)
+	case Load:
+		load(ctx, qs, args, out)
+	case Save:
+		save(ctx, qs, args, out)
+	case ImportMoodle:
+		importMoodle(ctx, qs, args, out)
+	case ExportMoodle:
+		exportMoodle(ctx, qs, args, out)
+	case ExportQTI:
+		exportQTI(ctx, qs, args, out)
+	case ImportAnki:
+		importAnki(ctx, qs, args, out)
+	case ExportAnki:
+		exportAnki(ctx, qs, args, out)
+	case Print:
+		printSheet(ctx, qs, args, out)
+	default:
+		fmt.Fprintf(out, "Command \"%s\" is not found. See \"help\"\n", cmd)
+	}
+}
+
+// errRollback discards the changes of a batch.
+var errRollback = errors.New("rolled back")
+
+// batch runs the commands following "begin" in a single transaction until "commit" or "rollback".
+// exit reports whether the CLI was exited in the middle of the batch, the batch is then rolled back,
+// code is the exit status: 1 when the input could not be read.
+func batch(ctx context.Context, qs questionnaire.Service, scanner *bufio.Scanner, args []string, out io.Writer) (exit bool, code int) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return false, 0
+	}
+
+	fmt.Fprintln(out, "Batch started, \"commit\" to apply the changes or \"rollback\" to discard them")
+
+	var failed int
+	err := qs.WithTx(ctx, func(tx questionnaire.Service) error {
+		tx = &batchService{Service: tx, failed: &failed}
+
+		for {
+			fmt.Fprintf(out, "batch$ ")
+			if !scanner.Scan() {
+				exit = true
+				if err := scanner.Err(); err != nil {
+					failed++
+					code = 1
+					return fmt.Errorf("could not read input: %w", err)
+				}
+				return errRollback
+			}
+
+			var (
+				args = textinput.Split(scanner.Text(), ' ')
+				cmd  = Command(strings.ToLower(args[0]))
+			)
+
+			switch cmd {
+			case Exit:
+				exit = true
+				return errRollback
+			case Begin:
+				fmt.Fprintln(out, "A batch is already in progress, \"commit\" or \"rollback\" it first")
+			case Commit:
+				if failed > 0 {
+					return fmt.Errorf("%d change(s) failed", failed)
+				}
+				return nil
+			case Rollback:
+				return errRollback
+			case Compact, Undo, Redo, Use:
+				fmt.Fprintf(out, "Could not %s in the middle of a batch\n", cmd)
+			case Play:
+				play(ctx, tx, scanner, args, out)
+			default:
+				execute(ctx, tx, cmd, args, out)
+			}
 		}
+	})
+
+	switch {
+	case err == nil:
+		fmt.Fprintln(out, "Batch committed")
+	case errors.Is(err, errRollback):
+		fmt.Fprintln(out, "Batch rolled back")
+	default:
+		fmt.Fprintf(out, "Could not commit batch, rolled back: %v\n", err)
+	}
+
+	return exit, code
+}
+
+// batchService counts the changes that failed inside a batch, a batch with a failed change is never committed.
+// nested is set on the transactions started inside the batch, e.g. by imports.
+type batchService struct {
+	questionnaire.Service
+	failed *int
+	nested bool
+}
+
+func (s *batchService) Create(ctx context.Context, question *questionnaire.Question) error {
+	err := s.Service.Create(ctx, question)
+	if s.nested && errors.Is(err, questionnaire.ErrQuestionIsAlreadyExist) {
+		// Imports find their conflicts by creating, their conflict policy tells whether it failed.
+		return err
+	}
+	return s.check(err)
+}
+
+func (s *batchService) Update(ctx context.Context, question *questionnaire.Question) error {
+	return s.check(s.Service.Update(ctx, question))
+}
+
+func (s *batchService) UpdateIfVersion(ctx context.Context, question *questionnaire.Question, version int) error {
+	return s.check(s.Service.UpdateIfVersion(ctx, question, version))
+}
+
+func (s *batchService) Tag(ctx context.Context, id int, tags []string) (*questionnaire.Question, error) {
+	question, err := s.Service.Tag(ctx, id, tags)
+	return question, s.check(err)
+}
+
+func (s *batchService) Untag(ctx context.Context, id int, tags []string) (*questionnaire.Question, error) {
+	question, err := s.Service.Untag(ctx, id, tags)
+	return question, s.check(err)
+}
+
+func (s *batchService) Delete(ctx context.Context, id int) error {
+	return s.check(s.Service.Delete(ctx, id))
+}
+
+func (s *batchService) Restore(ctx context.Context, id int) error {
+	return s.check(s.Service.Restore(ctx, id))
+}
+
+func (s *batchService) Purge(ctx context.Context) (int, error) {
+	n, err := s.Service.Purge(ctx)
+	return n, s.check(err)
+}
+
+func (s *batchService) Revert(ctx context.Context, id, number int) (*questionnaire.Question, error) {
+	question, err := s.Service.Revert(ctx, id, number)
+	return question, s.check(err)
+}
+
+func (s *batchService) CreateBank(ctx context.Context, bank *questionnaire.Bank) error {
+	return s.check(s.Service.CreateBank(ctx, bank))
+}
+
+func (s *batchService) CopyQuestion(ctx context.Context, id int, bank string) (*questionnaire.Question, error) {
+	question, err := s.Service.CopyQuestion(ctx, id, bank)
+	return question, s.check(err)
+}
+
+// WithTx runs fn in a transaction whose changes are counted as well. Its failed changes count once it's committed,
+// a transaction rolled back by an error other than a dry run counts as one failed change.
+func (s *batchService) WithTx(ctx context.Context, fn func(tx questionnaire.Service) error) error {
+	var failed int
+	err := s.Service.WithTx(ctx, func(tx questionnaire.Service) error {
+		return fn(&batchService{Service: tx, failed: &failed, nested: true})
+	})
+	switch {
+	case err == nil:
+		*s.failed += failed
+	case !errors.Is(err, questionnaire.ErrDryRun):
+		*s.failed++
+	}
+
+	return err
+}
+
+func (s *batchService) check(err error) error {
+	if err != nil {
+		*s.failed++
 	}
+	return err
 }
 
 func question(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
@@ -103,14 +478,56 @@ func question(ctx context.Context, qs questionnaire.Service, args []string, out
 	}
 
 	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+	fmt.Fprintf(out, VersionFormat, question.Version)
+	printClassification(out, question)
 }
 
-func questions(ctx context.Context, qs questionnaire.Service, _ []string, out io.Writer) {
-	questions, err := qs.GetAll(ctx)
+// printClassification prints the tags, the category and the matching options of question, if any.
+func printClassification(out io.Writer, question *questionnaire.Question) {
+	if len(question.Tags) > 0 {
+		fmt.Fprintf(out, TagsFormat, strings.Join(question.Tags, ", "))
+	}
+	if question.Category != "" {
+		fmt.Fprintf(out, CategoryFormat, question.Category)
+	}
+	if len(question.Matching) > 0 {
+		fmt.Fprintf(out, MatchingFormat, strings.Join(question.Matching, ", "))
+	}
+}
+
+func questions(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) == 1 {
+		questions, err := qs.GetAll(ctx)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return
+		}
+		printQuestions(out, questions)
+		return
+	}
+
+	query, err := parseQuery(args)
+	if err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	page, err := qs.Find(ctx, query)
 	if err != nil {
-		fmt.Fprintln(out, err)
+		fmt.Fprintf(out, "Could not get questions: %v\n", err)
 		return
 	}
+	printQuestions(out, page.Questions)
+
+	if query.Limit > 0 {
+		fmt.Fprintf(out, "Showing %d of %d questions\n", len(page.Questions), page.Total)
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(out, "Next cursor: %s\n", page.NextCursor)
+	}
+}
+
+func printQuestions(out io.Writer, questions []questionnaire.Question) {
 	fmt.Fprintln(out, "No | Question | Answer")
 
 	for _, question := range questions {
@@ -118,23 +535,193 @@ func questions(ctx context.Context, qs questionnaire.Service, _ []string, out io
 	}
 }
 
-func createQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
-	if len(args) != 4 {
+// search prints the questions matching the terms by relevance with their answer, the matched terms are enclosed in
+// brackets.
+func search(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	terms := make([]string, 0, len(args))
+	for _, arg := range args[1:] {
+		terms = append(terms, strings.Trim(arg, "\""))
+	}
+	if len(questionnaire.Tokenize(strings.Join(terms, " "))) == 0 {
 		fmt.Fprintln(out, "Invalid input format. See \"help\"")
 		return
 	}
 
-	id, err := strconv.ParseInt(args[1], 10, 64)
+	results, err := qs.Search(ctx, strings.Join(terms, " "), 0)
 	if err != nil {
-		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		fmt.Fprintf(out, "Could not search questions: %v\n", err)
 		return
 	}
 
-	question := &questionnaire.Question{
-		ID:       int(id),
-		Question: args[2],
-		Answer:   args[3],
+	fmt.Fprintln(out, "No | Score | Question | Answer")
+	for _, result := range results {
+		fmt.Fprintf(out, "%d %.2f \"%s\" %s\n", result.Question.ID, result.Score,
+			questionnaire.Highlight(result.Question.Question, result.Terms, "[", "]"),
+			questionnaire.Highlight(result.Question.Answer, result.Terms, "[", "]"))
+	}
+}
+
+// defaultPageLimit is the number of questions per page when only the page number is given.
+const defaultPageLimit = 20
+
+// parseQuery parses the filtering, sorting and paging flags following a command, args[0] is the command.
+func parseQuery(args []string) (questionnaire.Query, error) {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	query := queryFlags(fs)
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return questionnaire.Query{}, err
+	}
+	if fs.NArg() != 0 {
+		return questionnaire.Query{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	return query()
+}
+
+// queryFlags defines the filtering, sorting and paging flags in fs, query returns the query they tell once fs is
+// parsed.
+func queryFlags(fs *flag.FlagSet) (query func() (questionnaire.Query, error)) {
+	var (
+		q                        questionnaire.Query
+		page, from, to           int
+		sortOrder, contains, cur string
+		tags, category           string
+	)
+
+	fs.IntVar(&page, "page", 0, "")
+	fs.IntVar(&q.Limit, "limit", 0, "")
+	fs.StringVar(&sortOrder, "sort", "id", "")
+	fs.IntVar(&from, "from", 0, "")
+	fs.IntVar(&to, "to", 0, "")
+	fs.StringVar(&contains, "contains", "", "")
+	fs.StringVar(&cur, "cursor", "", "")
+	fs.StringVar(&tags, "tags", "", "")
+	fs.StringVar(&category, "category", "", "")
+
+	return func() (questionnaire.Query, error) {
+		query := q
+
+		var err error
+		if query.Sort, err = questionnaire.ParseSortOrder(sortOrder); err != nil {
+			return query, err
+		}
+
+		if isFlagSet(fs, "from") {
+			query.MinID = &from
+		}
+		if isFlagSet(fs, "to") {
+			query.MaxID = &to
+		}
+		query.Contains = strings.Trim(contains, "\"")
+		query.Cursor = cur
+
+		if tags = strings.Trim(tags, "\""); tags != "" {
+			if query.Tags, err = questionnaire.ParseTagExpr(tags); err != nil {
+				return query, err
+			}
+		}
+		if query.Category, err = questionnaire.NormalizeCategory(strings.Trim(category, "\"")); err != nil {
+			return query, err
+		}
+
+		if query.Limit < 0 {
+			return query, errors.New("limit should not be negative")
+		}
+		if page < 0 || (page == 0 && isFlagSet(fs, "page")) {
+			return query, errors.New("page should start from 1")
+		}
+		if page > 0 {
+			if query.Limit == 0 {
+				query.Limit = defaultPageLimit
+			}
+			query.Offset = (page - 1) * query.Limit
+		}
+
+		return query, nil
+	}
+}
+
+// cutFlag removes flag name and its value from args, wherever they are after the command.
+func cutFlag(args []string, name string) (value string, rest []string, found bool) {
+	for i := 1; i < len(args)-1; i++ {
+		if args[i] == name {
+			rest = append(append(rest, args[:i]...), args[i+2:]...)
+			return args[i+1], rest, true
+		}
+	}
+	return "", args, false
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) (set bool) {
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// cutClassification removes the --tags and --category flags from args and sets them to question.
+// set reports whether any of them was given.
+func cutClassification(args []string, question *questionnaire.Question) (rest []string, set bool) {
+	tags, args, tagsSet := cutFlag(args, "--tags")
+	if tagsSet {
+		question.Tags = splitTags(tags)
+	}
+	category, args, categorySet := cutFlag(args, "--category")
+	if categorySet {
+		question.Category = strings.Trim(category, "\"")
+	}
+
+	return args, tagsSet || categorySet
+}
+
+// cutMatching removes the --matching flag from args and sets it to question, set reports whether it was given.
+func cutMatching(args []string, question *questionnaire.Question) (rest []string, set bool) {
+	matching, args, set := cutFlag(args, "--matching")
+	if set {
+		question.Matching = splitTags(matching)
+	}
+
+	return args, set
+}
+
+// splitTags splits tags separated by commas.
+func splitTags(tags string) []string {
+	return strings.FieldsFunc(strings.Trim(tags, "\""), func(r rune) bool { return r == ',' })
+}
+
+func createQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	question := &questionnaire.Question{}
+	args, _ = cutClassification(args, question)
+	args, _ = cutMatching(args, question)
+	switch len(args) {
+	case 3:
+		// The number is picked by the service. A number followed by a single argument is rather a question
+		// missing its answer.
+		if _, err := strconv.ParseInt(args[1], 10, 64); err == nil {
+			fmt.Fprintln(out, "Invalid input format. See \"help\"")
+			return
+		}
+		question.Question, question.Answer = strings.Trim(args[1], "\""), strings.Trim(args[2], "\"")
+	case 4:
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			fmt.Fprintln(out, "Invalid question ID, should be integer")
+			return
+		}
+		if id == 0 {
+			fmt.Fprintln(out, "Invalid question ID, should not be 0, leave it out to get the next free one")
+			return
+		}
+		question.ID, question.Question, question.Answer = int(id), strings.Trim(args[2], "\""), strings.Trim(args[3], "\"")
+	default:
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
 	}
+
 	if err := qs.Create(ctx, question); err != nil {
 		fmt.Fprintf(out, "Could not create question: %v\n", err)
 		return
@@ -142,9 +729,14 @@ func createQuestion(ctx context.Context, qs questionnaire.Service, args []string
 
 	fmt.Fprintf(out, "Question no %d created:\n", question.ID)
 	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+	printClassification(out, question)
 }
 
 func updateQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	question := &questionnaire.Question{}
+	ifVersion, args, conditional := cutFlag(args, "--if-version")
+	args, classified := cutClassification(args, question)
+	args, matched := cutMatching(args, question)
 	if len(args) != 4 {
 		fmt.Fprintf(out, "Invalid input format. See \"help\"\n")
 		return
@@ -156,18 +748,86 @@ func updateQuestion(ctx context.Context, qs questionnaire.Service, args []string
 		return
 	}
 
-	question := &questionnaire.Question{
-		ID:       int(id),
-		Question: args[2],
-		Answer:   args[3],
+	var version int
+	if conditional {
+		if version, err = strconv.Atoi(ifVersion); err != nil {
+			fmt.Fprintln(out, "Invalid question version, should be integer")
+			return
+		}
+	}
+
+	question.ID, question.Question, question.Answer = int(id), strings.Trim(args[2], "\""), strings.Trim(args[3], "\"")
+	if !classified || !matched {
+		// Keep what isn't given, the update is made on the version it's read from so that a change made in between
+		// isn't overwritten. A missing question is reported by the update.
+		if current, err := qs.GetByID(ctx, question.ID); err == nil {
+			if !classified {
+				question.Tags, question.Category = current.Tags, current.Category
+			}
+			if !matched {
+				question.Matching = current.Matching
+			}
+			if !conditional {
+				version, conditional = current.Version, true
+			}
+		}
+	}
+
+	if conditional {
+		err = qs.UpdateIfVersion(ctx, question, version)
+		var conflict *questionnaire.VersionConflictError
+		if errors.As(err, &conflict) {
+			fmt.Fprintf(out, "Could not update question [%d]: %v\n", id, err)
+			fmt.Fprintf(out, "Review it with \"question %d\" and retry with --if-version %d\n", id, conflict.Current)
+			return
+		}
+	} else {
+		err = qs.Update(ctx, question)
 	}
-	if err := qs.Update(ctx, question); err != nil {
+	if err != nil {
 		fmt.Fprintf(out, "Could not update question [%d]: %v\n", id, err)
 		return
 	}
 
 	fmt.Fprintf(out, "Question no %d updated:\n", question.ID)
 	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+	fmt.Fprintf(out, VersionFormat, question.Version)
+	printClassification(out, question)
+}
+
+func tag(ctx context.Context, qs questionnaire.Service, cmd Command, args []string, out io.Writer) {
+	if len(args) < 3 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+
+	var tags []string
+	for _, arg := range args[2:] {
+		tags = append(tags, splitTags(arg)...)
+	}
+
+	var question *questionnaire.Question
+	if cmd == Tag {
+		question, err = qs.Tag(ctx, int(id), tags)
+	} else {
+		question, err = qs.Untag(ctx, int(id), tags)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Could not %s question [%d]: %v\n", cmd, id, err)
+		return
+	}
+
+	if len(question.Tags) == 0 {
+		fmt.Fprintf(out, "Question no %d has no tag\n", question.ID)
+		return
+	}
+	fmt.Fprintf(out, "Question no %d tagged: %s\n", question.ID, strings.Join(question.Tags, ", "))
 }
 
 func deleteQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
@@ -187,7 +847,72 @@ func deleteQuestion(ctx context.Context, qs questionnaire.Service, args []string
 		return
 	}
 
-	fmt.Fprintf(out, "Question no %d deleted:\n", id)
+	fmt.Fprintf(out, "Question no %d moved to trash, restore it with \"restore_question %d\"\n", id, id)
+}
+
+// TimeFormat is the layout of the times shown by "trash", "history" and "banks".
+const TimeFormat = "2006-01-02 15:04"
+
+func trash(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	questions, err := qs.Trash(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get trash: %v\n", err)
+		return
+	}
+
+	fmt.Fprintln(out, "No | Question | Answer | Deleted at")
+
+	for _, question := range questions {
+		fmt.Fprintf(out, "%d \"%s\" %s %s\n", question.ID, question.Question, question.Answer,
+			question.DeletedAt.Local().Format(TimeFormat))
+	}
+}
+
+func restoreQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+
+	if err := qs.Restore(ctx, int(id)); err != nil {
+		fmt.Fprintf(out, "Could not restore question [%d]: %v\n", id, err)
+		return
+	}
+
+	question, err := qs.GetByID(ctx, int(id))
+	if err != nil {
+		fmt.Fprintf(out, "Could not get question [%d]: %v\n", id, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Question no %d restored:\n", question.ID)
+	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+}
+
+func purge(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	n, err := qs.Purge(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not purge trash: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(out, "Purged %d question(s) from trash\n", n)
 }
 
 func answerQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
@@ -202,7 +927,12 @@ func answerQuestion(ctx context.Context, qs questionnaire.Service, args []string
 		return
 	}
 
-	correct, err := qs.Answer(ctx, int(id), args[2])
+	correct, err := qs.Answer(ctx, int(id), strings.Trim(args[2], "\""))
+	var wrong *questionnaire.WrongDimensionError
+	if errors.As(err, &wrong) {
+		fmt.Fprintf(out, "Incorrect! Answer measures %s, expected %s\n", wrong.Given, wrong.Expected)
+		return
+	}
 	if err != nil {
 		fmt.Fprintf(out, "Could not answer question [%d]: %v\n", id, err)
 		return
@@ -214,3 +944,732 @@ func answerQuestion(ctx context.Context, qs questionnaire.Service, args []string
 		fmt.Fprintln(out, "Incorrect!")
 	}
 }
+
+// quitPlay is the answer ending a game before its last question.
+const quitPlay = "quit"
+
+// play asks the questions selected by the questions flags one by one, reading each answer from scanner, and prints
+// the score once they are all asked or the game is quit. An empty answer skips a question.
+func play(ctx context.Context, qs questionnaire.Service, scanner *bufio.Scanner, args []string, out io.Writer) {
+	query, err := parseQuery(args)
+	if err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	page, err := qs.Find(ctx, query)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get questions: %v\n", err)
+		return
+	}
+	if len(page.Questions) == 0 {
+		fmt.Fprintln(out, "No question to play")
+		return
+	}
+
+	fmt.Fprintf(out, "Playing %d question(s), an empty answer skips a question and \"%s\" stops\n",
+		len(page.Questions), quitPlay)
+
+	var asked, correct int
+	for _, question := range page.Questions {
+		fmt.Fprintf(out, "[%d] \"%s\"\nanswer$ ", question.ID, question.Question)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		answer := strings.Trim(strings.TrimSpace(scanner.Text()), "\"")
+		if strings.EqualFold(answer, quitPlay) {
+			break
+		}
+		asked++
+		if answer == "" {
+			fmt.Fprintf(out, "Skipped, the answer is %s\n", question.Answer)
+			continue
+		}
+
+		ok, err := qs.Answer(ctx, question.ID, answer)
+		var wrong *questionnaire.WrongDimensionError
+		switch {
+		case errors.As(err, &wrong):
+			fmt.Fprintf(out, "Incorrect! Answer measures %s, the answer is %s\n", wrong.Given, question.Answer)
+		case err != nil:
+			fmt.Fprintf(out, "Could not answer question [%d]: %v\n", question.ID, err)
+		case ok:
+			correct++
+			fmt.Fprintln(out, "Correct!")
+		default:
+			fmt.Fprintf(out, "Incorrect! The answer is %s\n", question.Answer)
+		}
+	}
+
+	fmt.Fprintf(out, "Score: %d/%d\n", correct, asked)
+}
+
+func history(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+
+	revisions, err := qs.History(ctx, int(id))
+	if err != nil {
+		fmt.Fprintf(out, "Could not get history of question [%d]: %v\n", id, err)
+		return
+	}
+
+	fmt.Fprintln(out, "Rev | Time | Author | Change | Question | Answer")
+
+	for _, revision := range revisions {
+		author := revision.Author
+		if author == "" {
+			author = "-"
+		}
+		fmt.Fprintf(out, "%d %s %s %s", revision.Number, revision.At.Local().Format(TimeFormat), author, revision.Change)
+		if revision.New != nil {
+			fmt.Fprintf(out, " \"%s\" %s", revision.New.Question, revision.New.Answer)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+func revertQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 3 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+	rev, err := strconv.Atoi(args[2])
+	if err != nil {
+		fmt.Fprintln(out, "Invalid revision, should be integer")
+		return
+	}
+
+	question, err := qs.Revert(ctx, int(id), rev)
+	if err != nil {
+		fmt.Fprintf(out, "Could not revert question [%d]: %v\n", id, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Question no %d reverted to revision %d:\n", question.ID, rev)
+	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+	fmt.Fprintf(out, VersionFormat, question.Version)
+}
+
+func diffQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 4 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+
+	var texts [2]string
+	for i, arg := range args[2:] {
+		rev, err := strconv.Atoi(arg)
+		if err != nil {
+			fmt.Fprintln(out, "Invalid revision, should be integer")
+			return
+		}
+		revision, err := qs.Revision(ctx, int(id), rev)
+		if err != nil {
+			fmt.Fprintf(out, "Could not get revision %d of question [%d]: %v\n", rev, id, err)
+			return
+		}
+		// A question moved to trash by the revision is an empty text.
+		if revision.New != nil {
+			texts[i] = fmt.Sprintf(PrintFormat, revision.New.Question, revision.New.Answer)
+		}
+	}
+
+	fmt.Fprint(out, textdiff.Unified("revision "+args[2], "revision "+args[3], texts[0], texts[1]))
+}
+
+func undoRedo(ctx context.Context, qs questionnaire.Service, cmd Command, args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	us, ok := qs.(questionnaire.UndoService)
+	if !ok {
+		fmt.Fprintf(out, "Could not %s: undo is disabled\n", cmd)
+		return
+	}
+
+	var (
+		ids  []int
+		err  error
+		done string
+	)
+	if cmd == Undo {
+		ids, err = us.Undo(ctx)
+		done = "Undone"
+	} else {
+		ids, err = us.Redo(ctx)
+		done = "Redone"
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Could not %s: %v\n", cmd, err)
+		return
+	}
+
+	nos := make([]string, len(ids))
+	for i, id := range ids {
+		nos[i] = strconv.Itoa(id)
+	}
+	fmt.Fprintf(out, "%s change of question no %s\n", done, strings.Join(nos, ", "))
+}
+
+func compact(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	if err := qs.Compact(ctx); err != nil {
+		fmt.Fprintf(out, "Could not compact store: %v\n", err)
+		return
+	}
+
+	fmt.Fprintln(out, "Store compacted")
+}
+
+func banks(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	banks, err := qs.Banks(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get banks: %v\n", err)
+		return
+	}
+
+	fmt.Fprintln(out, "Bank | Created at")
+
+	active := questionnaire.BankFromContext(ctx)
+	for _, bank := range banks {
+		mark := " "
+		if bank.Name == active {
+			mark = "*"
+		}
+		// The default bank has been there from the start.
+		createdAt := "-"
+		if !bank.CreatedAt.IsZero() {
+			createdAt = bank.CreatedAt.Local().Format(TimeFormat)
+		}
+		fmt.Fprintf(out, "%s %s %s\n", mark, bank.Name, createdAt)
+	}
+}
+
+func createBank(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	bank := &questionnaire.Bank{Name: strings.Trim(args[1], "\"")}
+	if err := qs.CreateBank(ctx, bank); err != nil {
+		fmt.Fprintf(out, "Could not create bank: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(out, "Bank %s created, switch to it with \"use %s\"\n", bank.Name, bank.Name)
+}
+
+// use returns ctx scoped to the bank named in args, ctx is returned as is when the bank can't be used.
+func use(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) context.Context {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return ctx
+	}
+
+	bank, err := qs.Bank(ctx, args[1])
+	if err != nil {
+		fmt.Fprintf(out, "Could not use bank %s: %v\n", args[1], err)
+		return ctx
+	}
+
+	fmt.Fprintf(out, "Using bank %s\n", bank.Name)
+
+	return questionnaire.WithBank(ctx, bank.Name)
+}
+
+func copyQuestion(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 3 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(out, "Invalid question ID, should be integer")
+		return
+	}
+
+	question, err := qs.CopyQuestion(ctx, int(id), args[2])
+	if err != nil {
+		fmt.Fprintf(out, "Could not copy question [%d]: %v\n", id, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Question no %d copied to bank %s:\n", question.ID, args[2])
+	fmt.Fprintf(out, PrintFormat, question.Question, question.Answer)
+}
+
+func importCSV(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	var (
+		opts             questionnaire.ImportOptions
+		conflict, mapped string
+	)
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "")
+	fs.StringVar(&conflict, "on-conflict", "skip", "")
+	fs.StringVar(&mapped, "columns", "", "")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	var err error
+	if opts.Conflict, err = questionnaire.ParseConflictPolicy(conflict); err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+	columns, err := csvColumns(mapped)
+	if err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	path := strings.Trim(fs.Arg(0), "\"")
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s: %v\n", path, err)
+		return
+	}
+	rows, err := questionnaire.ReadCSV(f, columns)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s: %v\n", path, err)
+		return
+	}
+
+	var (
+		questions = make([]questionnaire.Question, 0, len(rows))
+		lines     = make([]int, 0, len(rows)) // lines[i] is the line of questions[i]
+		failed    int
+	)
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+		questions = append(questions, row.Question)
+		lines = append(lines, row.Line)
+	}
+
+	report, err := questionnaire.Import(ctx, qs, questions, opts)
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s, nothing imported: %v\n", path, err)
+		return
+	}
+
+	// Report the rows in the order of the file.
+	for i, j := 0, 0; i < len(rows); i++ {
+		if rows[i].Err != nil {
+			fmt.Fprintf(out, "Line %d: %v\n", rows[i].Line, rows[i].Err)
+			failed++
+			continue
+		}
+		switch result := report.Results[j]; result.Outcome {
+		case questionnaire.ImportSkipped:
+			fmt.Fprintf(out, "Line %d: question no %d skipped: %v\n", lines[j], result.ID, questionnaire.ErrQuestionIsAlreadyExist)
+		case questionnaire.ImportFailed:
+			fmt.Fprintf(out, "Line %d: %v\n", lines[j], result.Err)
+			failed++
+		}
+		j++
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(out, "Dry run of %s, nothing imported: %s\n", path, importSummary(report, failed))
+		return
+	}
+	fmt.Fprintf(out, "Imported %s: %s\n", path, importSummary(report, failed))
+}
+
+// importSummary counts the questions of report by outcome, failed is the number of questions that failed.
+func importSummary(report *questionnaire.ImportReport, failed int) string {
+	return fmt.Sprintf("%d created, %d updated, %d skipped, %d failed", report.Count(questionnaire.ImportCreated),
+		report.Count(questionnaire.ImportUpdated), report.Count(questionnaire.ImportSkipped), failed)
+}
+
+func exportCSV(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	mapped, args, _ := cutFlag(args, "--columns")
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	columns, err := csvColumns(mapped)
+	if err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	questions, err := qs.GetAll(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get questions: %v\n", err)
+		return
+	}
+
+	path := strings.Trim(args[1], "\"")
+	if err := writeFile(path, func(w io.Writer) error { return questionnaire.WriteCSV(w, questions, columns) }); err != nil {
+		fmt.Fprintf(out, "Could not export %s: %v\n", path, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Exported %d question(s) to %s\n", len(questions), path)
+}
+
+// csvColumns parses the --columns flag, every field is mapped to the column named after it when it's empty.
+func csvColumns(mapped string) ([]questionnaire.CSVColumn, error) {
+	if mapped = strings.Trim(mapped, "\""); mapped == "" {
+		return questionnaire.DefaultCSVColumns, nil
+	}
+	return questionnaire.ParseCSVColumns(mapped)
+}
+
+// writeFile writes the file at path with write, nothing is written when write fails.
+func writeFile(path string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	return questionnaire.WriteFileAtomic(path, buf.Bytes())
+}
+
+func load(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	var (
+		opts     questionnaire.ImportOptions
+		conflict string
+	)
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "")
+	fs.StringVar(&conflict, "on-conflict", "skip", "")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	var err error
+	if opts.Conflict, err = questionnaire.ParseConflictPolicy(conflict); err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	path := strings.Trim(fs.Arg(0), "\"")
+	format, err := questionnaire.BankFileFormatOf(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not load %s: %v\n", path, err)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not load %s: %v\n", path, err)
+		return
+	}
+	file, err := questionnaire.ReadBankFile(f, format)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(out, "Could not load %s: %v\n", path, err)
+		return
+	}
+
+	bank, report, err := questionnaire.ImportBank(ctx, qs, file, opts)
+	if err != nil {
+		fmt.Fprintf(out, "Could not load %s, nothing loaded: %v\n", path, err)
+		return
+	}
+
+	for _, result := range report.Results {
+		switch result.Outcome {
+		case questionnaire.ImportSkipped:
+			fmt.Fprintf(out, "Question no %d skipped: %v\n", result.ID, questionnaire.ErrQuestionIsAlreadyExist)
+		case questionnaire.ImportFailed:
+			fmt.Fprintf(out, "Could not load question [%d]: %v\n", result.ID, result.Err)
+		}
+	}
+
+	failed := report.Count(questionnaire.ImportFailed)
+	if opts.DryRun {
+		fmt.Fprintf(out, "Dry run of %s, nothing loaded: %s\n", path, importSummary(report, failed))
+		return
+	}
+	fmt.Fprintf(out, "Loaded %s into bank %s: %s\n", path, bank, importSummary(report, failed))
+}
+
+func save(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	path := strings.Trim(args[1], "\"")
+	format, err := questionnaire.BankFileFormatOf(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not save %s: %v\n", path, err)
+		return
+	}
+	file, err := questionnaire.ExportBank(ctx, qs)
+	if err != nil {
+		fmt.Fprintf(out, "Could not save %s: %v\n", path, err)
+		return
+	}
+	if err := writeFile(path, func(w io.Writer) error { return questionnaire.WriteBankFile(w, file, format) }); err != nil {
+		fmt.Fprintf(out, "Could not save %s: %v\n", path, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Saved %d question(s) of bank %s to %s\n", len(file.Questions), file.Bank.Name, path)
+}
+
+func importMoodle(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	importConverted(ctx, qs, args, out, func(path string, r io.Reader) ([]questionnaire.Question, []convert.Issue, error) {
+		format, err := convert.MoodleFormatOf(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return convert.ReadMoodle(r, format)
+	})
+}
+
+func exportMoodle(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	exportConverted(ctx, qs, args, out, func(path string, w io.Writer, questions []questionnaire.Question) ([]convert.Issue, error) {
+		format, err := convert.MoodleFormatOf(path)
+		if err != nil {
+			return nil, err
+		}
+		// Group the questions by category, Moodle starts a category at every change.
+		sort.SliceStable(questions, func(i, j int) bool { return questions[i].Category < questions[j].Category })
+		return convert.WriteMoodle(w, questions, format)
+	})
+}
+
+func exportQTI(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	exportConverted(ctx, qs, args, out, func(_ string, w io.Writer, questions []questionnaire.Question) ([]convert.Issue, error) {
+		return convert.WriteQTI(w, questions, questionnaire.BankFromContext(ctx))
+	})
+}
+
+func importAnki(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	importConverted(ctx, qs, args, out, func(_ string, r io.Reader) ([]questionnaire.Question, []convert.Issue, error) {
+		return convert.ReadAnki(r)
+	})
+}
+
+func exportAnki(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	exportConverted(ctx, qs, args, out, func(_ string, w io.Writer, questions []questionnaire.Question) ([]convert.Issue, error) {
+		return nil, convert.WriteAnki(w, questions, questionnaire.BankFromContext(ctx))
+	})
+}
+
+// importConverted imports the questions read by read from the file named in args, the constructs read couldn't
+// map are printed as warnings.
+func importConverted(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer,
+	read func(path string, r io.Reader) ([]questionnaire.Question, []convert.Issue, error)) {
+	var (
+		opts     questionnaire.ImportOptions
+		conflict string
+	)
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "")
+	fs.StringVar(&conflict, "on-conflict", "skip", "")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	var err error
+	if opts.Conflict, err = questionnaire.ParseConflictPolicy(conflict); err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+
+	path := strings.Trim(fs.Arg(0), "\"")
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s: %v\n", path, err)
+		return
+	}
+	questions, issues, err := read(path, f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s: %v\n", path, err)
+		return
+	}
+
+	report, err := questionnaire.Import(ctx, qs, questions, opts)
+	if err != nil {
+		fmt.Fprintf(out, "Could not import %s, nothing imported: %v\n", path, err)
+		return
+	}
+
+	printIssues(out, issues)
+	for _, result := range report.Results {
+		switch result.Outcome {
+		case questionnaire.ImportSkipped:
+			fmt.Fprintf(out, "Question no %d skipped: %v\n", result.ID, questionnaire.ErrQuestionIsAlreadyExist)
+		case questionnaire.ImportFailed:
+			fmt.Fprintf(out, "Could not import question [%d]: %v\n", result.ID, result.Err)
+		}
+	}
+
+	failed := report.Count(questionnaire.ImportFailed)
+	if opts.DryRun {
+		fmt.Fprintf(out, "Dry run of %s, nothing imported: %s\n", path, importSummary(report, failed))
+		return
+	}
+	fmt.Fprintf(out, "Imported %s: %s\n", path, importSummary(report, failed))
+}
+
+// exportConverted exports the questions of the active bank with write to the file named in args, the constructs
+// write couldn't map are printed as warnings.
+func exportConverted(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer,
+	write func(path string, w io.Writer, questions []questionnaire.Question) ([]convert.Issue, error)) {
+	if len(args) != 2 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	questions, err := qs.GetAll(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get questions: %v\n", err)
+		return
+	}
+
+	var (
+		path   = strings.Trim(args[1], "\"")
+		issues []convert.Issue
+	)
+	if err := writeFile(path, func(w io.Writer) (err error) {
+		issues, err = write(path, w, questions)
+		return err
+	}); err != nil {
+		fmt.Fprintf(out, "Could not export %s: %v\n", path, err)
+		return
+	}
+
+	printIssues(out, issues)
+	fmt.Fprintf(out, "Exported %d question(s) to %s\n", len(questions), path)
+}
+
+// printSheet prints the questions selected as questions does to a question sheet and an answer key, the answer
+// key is written next to the sheet with "-key" appended to its name.
+func printSheet(ctx context.Context, qs questionnaire.Service, args []string, out io.Writer) {
+	var (
+		opts          printout.Options
+		seed          string
+		layoutPath    string
+		fs            = flag.NewFlagSet(args[0], flag.ContinueOnError)
+		selectedQuery = queryFlags(fs)
+	)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.Title, "title", "Quiz", "")
+	fs.StringVar(&seed, "shuffle", "", "")
+	fs.IntVar(&opts.PageBreak, "page-break", 0, "")
+	fs.StringVar(&layoutPath, "layout", "", "")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(out, "Invalid input format. See \"help\"")
+		return
+	}
+
+	query, err := selectedQuery()
+	if err != nil {
+		fmt.Fprintf(out, "Invalid input format: %v. See \"help\"\n", err)
+		return
+	}
+	if opts.Shuffle = isFlagSet(fs, "shuffle"); opts.Shuffle {
+		if opts.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil {
+			fmt.Fprintln(out, "Invalid input format: shuffle seed should be integer. See \"help\"")
+			return
+		}
+	}
+	if opts.PageBreak < 0 {
+		fmt.Fprintln(out, "Invalid input format: page break should not be negative. See \"help\"")
+		return
+	}
+	opts.Title = strings.Trim(opts.Title, "\"")
+
+	path := strings.Trim(fs.Arg(0), "\"")
+	format, err := printout.FormatOf(path)
+	if err != nil {
+		fmt.Fprintf(out, "Could not print %s: %v\n", path, err)
+		return
+	}
+	layout, err := printLayout(format, strings.Trim(layoutPath, "\""))
+	if err != nil {
+		fmt.Fprintf(out, "Could not print %s: %v\n", path, err)
+		return
+	}
+
+	page, err := qs.Find(ctx, query)
+	if err != nil {
+		fmt.Fprintf(out, "Could not get questions: %v\n", err)
+		return
+	}
+	sheet := printout.NewSheet(page.Questions, opts)
+
+	keyPath := strings.TrimSuffix(path, filepath.Ext(path)) + "-key" + filepath.Ext(path)
+	if err := writeFile(path, func(w io.Writer) error { return layout.RenderSheet(w, sheet) }); err != nil {
+		fmt.Fprintf(out, "Could not print %s: %v\n", path, err)
+		return
+	}
+	if err := writeFile(keyPath, func(w io.Writer) error { return layout.RenderKey(w, sheet) }); err != nil {
+		fmt.Fprintf(out, "Could not print %s: %v\n", keyPath, err)
+		return
+	}
+
+	fmt.Fprintf(out, "Printed %d question(s) to %s, answer key in %s\n", len(sheet.Items), path, keyPath)
+}
+
+// printLayout returns the layout of the file at path written in format, the default layout when path is empty.
+func printLayout(format printout.Format, path string) (*printout.Layout, error) {
+	if path == "" {
+		return printout.DefaultLayout(format)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return printout.ParseLayout(format, string(b))
+}
+
+// printIssues prints the constructs a conversion couldn't map.
+func printIssues(out io.Writer, issues []convert.Issue) {
+	for _, issue := range issues {
+		fmt.Fprintf(out, "Warning: %v\n", issue)
+	}
+}
@@ -2,15 +2,43 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/muktihari/quiz_master/questionnaire"
 )
 
 func TestIntegrationScenario1(t *testing.T) {
+	dir := t.TempDir()
+	importCSV := filepath.Join(dir, "import.csv")
+	err := os.WriteFile(importCSV, []byte("id,question,answer,tags\n"+
+		"12,\"What is \"\"2 + 2\"\"?\",4,math\n"+
+		",How many legs does a spider have?,8,\n"+
+		"x,1 + 1?,2,\n"+
+		"21,1 \"+\" 1?,2,\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportCSV := filepath.Join(dir, "export.csv")
+	scienceYAML, scienceTXT := filepath.Join(dir, "science.yaml"), filepath.Join(dir, "science.txt")
+	scienceGIFT, scienceXML := filepath.Join(dir, "science.gift"), filepath.Join(dir, "science.xml")
+	scienceQTI, scienceAnki := filepath.Join(dir, "science-qti.zip"), filepath.Join(dir, "science-anki.txt")
+	triviaMD, triviaKeyMD, triviaPDF := filepath.Join(dir, "trivia.md"), filepath.Join(dir, "trivia-key.md"), filepath.Join(dir, "trivia.pdf")
+	err = os.WriteFile(scienceGIFT, []byte("::1:: 1 + 1? {#2}\n\n"+
+		"::Color:: Name a primary color. {=red ~purple}\n\n"+
+		"What is H2O? {=water#Right!}\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	// Integration test, the order in table test is important, can't be parallelized.
 	tt := []struct {
 		Name        string
@@ -56,6 +84,16 @@ func TestIntegrationScenario1(t *testing.T) {
 			In:          "create_question 1 \"How many characters are there in \"Quipper\"?\"\nexit",
 			ExpectedOut: "$ Invalid input format. See \"help\"\n$ ",
 		},
+		{
+			Name:        "create question without answer, invalid command format",
+			In:          "create_question \"How many characters are there in \"Quipper\"?\"\nexit",
+			ExpectedOut: "$ Invalid input format. See \"help\"\n$ ",
+		},
+		{
+			Name:        "create question invalid ID 0",
+			In:          "create_question 0 \"How many characters are there in \"Quipper\"?\" 7\nexit",
+			ExpectedOut: "$ Invalid question ID, should not be 0, leave it out to get the next free one\n$ ",
+		},
 		{
 			Name:        "create question failed duplicate",
 			In:          "create_question 1 \"How many characters are there in \"Quipper\"?\" 7\nexit",
@@ -65,8 +103,31 @@ func TestIntegrationScenario1(t *testing.T) {
 		{
 			Name: "update question 2 success",
 			In:   "update_question 2 \"How many characters are there in 'Quipperx'?\" 8\nexit",
-			ExpectedOut: fmt.Sprintf("$ Question no %d updated:\n"+PrintFormat+"$ ",
-				2, "How many characters are there in 'Quipperx'?", "8"),
+			ExpectedOut: fmt.Sprintf("$ Question no %d updated:\n"+PrintFormat+VersionFormat+"$ ",
+				2, "How many characters are there in 'Quipperx'?", "8", 2),
+		},
+		{
+			Name: "update question 3 if version 1 success",
+			In:   "update_question --if-version 1 3 \"How many characters are there in \"Engineers\"?\" 9\nexit",
+			ExpectedOut: fmt.Sprintf("$ Question no %d updated:\n"+PrintFormat+VersionFormat+"$ ",
+				3, "How many characters are there in \"Engineers\"?", "9", 2),
+		},
+		{
+			Name: "update question 3 if version 1 failed conflict",
+			In:   "update_question 3 \"How many characters are there in \"Engineer\"?\" 8 --if-version 1\nexit",
+			ExpectedOut: "$ Could not update question [3]: question 3 is at version 2, expected version 1\n" +
+				"Review it with \"question 3\" and retry with --if-version 2\n$ ",
+		},
+		{
+			Name: "update question 3 if version 2 success",
+			In:   "update_question 3 \"How many characters are there in \"Engineer\"?\" 8 --if-version 2\nexit",
+			ExpectedOut: fmt.Sprintf("$ Question no %d updated:\n"+PrintFormat+VersionFormat+"$ ",
+				3, "How many characters are there in \"Engineer\"?", "8", 3),
+		},
+		{
+			Name:        "update question invalid version",
+			In:          "update_question --if-version X 3 \"How many characters are there in \"Engineer\"?\" 8\nexit",
+			ExpectedOut: "$ Invalid question version, should be integer\n$ ",
 		},
 		{
 			Name:        "update question invalid ID",
@@ -88,7 +149,7 @@ func TestIntegrationScenario1(t *testing.T) {
 		{
 			Name:        "delete question 2 success",
 			In:          "delete_question 2\nexit",
-			ExpectedOut: fmt.Sprintf("$ Question no %d deleted:\n$ ", 2),
+			ExpectedOut: "$ Question no 2 moved to trash, restore it with \"restore_question 2\"\n$ ",
 		},
 		{
 			Name:        "delete question invalid ID",
@@ -105,11 +166,46 @@ func TestIntegrationScenario1(t *testing.T) {
 			In:          "delete_question 4\nexit",
 			ExpectedOut: fmt.Sprintf("$ Could not delete question [%d]: %v\n$ ", 4, questionnaire.ErrQuestionNotFound),
 		},
+		// trash
+		{
+			Name: "trash",
+			In:   "trash\nexit",
+			ExpectedOut: "$ No | Question | Answer | Deleted at\n" +
+				"2 \"How many characters are there in 'Quipperx'?\" 8 2024-01-02 03:04\n$ ",
+		},
+		{
+			Name:        "create question 2 in trash failed",
+			In:          "create_question 2 \"How many characters are there in \"Quipper\" and \"Engineer\"?\" 15\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not create question: %v\n$ ", questionnaire.ErrQuestionInTrash),
+		},
+		{
+			Name: "restore question 2 success",
+			In:   "restore_question 2\nexit",
+			ExpectedOut: "$ Question no 2 restored:\n" +
+				"Q: \"How many characters are there in 'Quipperx'?\"\nA: 8\n$ ",
+		},
+		{
+			Name:        "restore question 2 not in trash failed",
+			In:          "restore_question 2\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not restore question [%d]: %v\n$ ", 2, questionnaire.ErrQuestionNotFound),
+		},
+		{
+			Name:        "restore question invalid ID",
+			In:          "restore_question X\nexit",
+			ExpectedOut: "$ Invalid question ID, should be integer\n$ ",
+		},
+		{
+			Name: "delete question 2 again and purge",
+			In:   "delete_question 2\npurge\ntrash\nexit",
+			ExpectedOut: "$ Question no 2 moved to trash, restore it with \"restore_question 2\"\n" +
+				"$ Purged 1 question(s) from trash\n" +
+				"$ No | Question | Answer | Deleted at\n$ ",
+		},
 		// question
 		{
 			Name:        "question 1 success",
 			In:          "question 1\nexit",
-			ExpectedOut: "$ Q: \"How many characters are there in \"Quipper\"?\"\nA: 7\n$ ",
+			ExpectedOut: "$ Q: \"How many characters are there in \"Quipper\"?\"\nA: 7\nVersion: 1\n$ ",
 		},
 		{
 			Name:        "question invalid ID",
@@ -134,6 +230,49 @@ func TestIntegrationScenario1(t *testing.T) {
 				"1 \"How many characters are there in \"Quipper\"?\" 7\n" +
 				"3 \"How many characters are there in \"Engineer\"?\" 8\n$ ",
 		},
+		{
+			Name: "questions sorted by ID descending",
+			In:   "questions --sort id-desc\nexit",
+			ExpectedOut: "$ No | Question | Answer\n" +
+				"3 \"How many characters are there in \"Engineer\"?\" 8\n" +
+				"1 \"How many characters are there in \"Quipper\"?\" 7\n$ ",
+		},
+		{
+			Name: "questions first page",
+			In:   "questions --page 1 --limit 1\nexit",
+			ExpectedOut: "$ No | Question | Answer\n" +
+				"1 \"How many characters are there in \"Quipper\"?\" 7\n" +
+				"Showing 1 of 2 questions\n" +
+				"Next cursor: eyJzIjowLCJpIjoxfQ\n$ ",
+		},
+		{
+			Name: "questions next page by cursor",
+			In:   "questions --limit 1 --cursor eyJzIjowLCJpIjoxfQ\nexit",
+			ExpectedOut: "$ No | Question | Answer\n" +
+				"3 \"How many characters are there in \"Engineer\"?\" 8\n" +
+				"Showing 1 of 2 questions\n$ ",
+		},
+		{
+			Name: "questions filtered",
+			In:   "questions --from 2 --to 5 --contains \"engineer\"\nexit",
+			ExpectedOut: "$ No | Question | Answer\n" +
+				"3 \"How many characters are there in \"Engineer\"?\" 8\n$ ",
+		},
+		{
+			Name:        "questions invalid sort order",
+			In:          "questions --sort answer\nexit",
+			ExpectedOut: "$ Invalid input format: unknown sort order \"answer\", should be one of: id, id-desc, question, question-desc. See \"help\"\n$ ",
+		},
+		{
+			Name:        "questions invalid page",
+			In:          "questions --page 0\nexit",
+			ExpectedOut: "$ Invalid input format: page should start from 1. See \"help\"\n$ ",
+		},
+		{
+			Name:        "questions invalid cursor",
+			In:          "questions --cursor x\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not get questions: %v\n$ ", questionnaire.ErrInvalidCursor),
+		},
 		// answer
 		{
 			Name:        "answer question 1 correct",
@@ -165,12 +304,341 @@ func TestIntegrationScenario1(t *testing.T) {
 			In:          "answer_question 4 seven\nexit",
 			ExpectedOut: fmt.Sprintf("$ Could not answer question [%d]: %v\n$ ", 4, questionnaire.ErrQuestionNotFound),
 		},
+		// compact
+		{
+			Name:        "compact not supported by memory store",
+			In:          "compact\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not compact store: %v\n$ ", questionnaire.ErrCompactionNotSupported),
+		},
+		// batch
+		{
+			Name: "batch rolled back",
+			In:   "begin\ndelete_question 3\nrollback\nquestion 3\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Question no 3 moved to trash, restore it with \"restore_question 3\"\n" +
+				"batch$ Batch rolled back\n" +
+				"$ Q: \"How many characters are there in \"Engineer\"?\"\nA: 8\nVersion: 3\n$ ",
+		},
+		{
+			Name: "batch with failed change, not committed",
+			In:   "begin\ndelete_question 3\ndelete_question 4\ncommit\nquestion 3\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Question no 3 moved to trash, restore it with \"restore_question 3\"\n" +
+				fmt.Sprintf("batch$ Could not delete question [%d]: %v\n", 4, questionnaire.ErrQuestionNotFound) +
+				"batch$ Could not commit batch, rolled back: 1 change(s) failed\n" +
+				"$ Q: \"How many characters are there in \"Engineer\"?\"\nA: 8\nVersion: 3\n$ ",
+		},
+		{
+			Name: "batch committed",
+			In:   "begin\nbegin\ncreate_question 4 \"How many characters are there in \"Batch\"?\" 5\ncompact\ncommit\nquestions\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ A batch is already in progress, \"commit\" or \"rollback\" it first\n" +
+				"batch$ Question no 4 created:\nQ: \"How many characters are there in \"Batch\"?\"\nA: 5\n" +
+				"batch$ Could not compact in the middle of a batch\n" +
+				"batch$ Batch committed\n" +
+				"$ No | Question | Answer\n" +
+				"1 \"How many characters are there in \"Quipper\"?\" 7\n" +
+				"3 \"How many characters are there in \"Engineer\"?\" 8\n" +
+				"4 \"How many characters are there in \"Batch\"?\" 5\n$ ",
+		},
+		{
+			Name: "batch exited, rolled back",
+			In:   "begin\ndelete_question 4\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Question no 4 moved to trash, restore it with \"restore_question 4\"\n" +
+				"batch$ Batch rolled back\n",
+		},
+		{
+			Name:        "question 4 still exists after exit in batch",
+			In:          "question 4\nexit",
+			ExpectedOut: "$ Q: \"How many characters are there in \"Batch\"?\"\nA: 5\nVersion: 1\n$ ",
+		},
+		// history
+		{
+			Name: "update question 1 and show its history",
+			In:   "update_question 1 \"How many characters are there in \"Quipper\"?\" 8\nhistory 1\nexit",
+			ExpectedOut: "$ Question no 1 updated:\nQ: \"How many characters are there in \"Quipper\"?\"\nA: 8\nVersion: 2\n" +
+				"$ Rev | Time | Author | Change | Question | Answer\n" +
+				"1 2024-01-02 03:04 tester create \"How many characters are there in \"Quipper\"?\" 7\n" +
+				"2 2024-01-02 03:04 tester update \"How many characters are there in \"Quipper\"?\" 8\n$ ",
+		},
+		{
+			Name: "diff question 1 between revision 1 and 2",
+			In:   "diff_question 1 1 2\nexit",
+			ExpectedOut: "$ --- revision 1\n+++ revision 2\n" +
+				" Q: \"How many characters are there in \"Quipper\"?\"\n" +
+				"-A: 7\n" +
+				"+A: 8\n$ ",
+		},
+		{
+			Name: "revert question 1 to revision 1",
+			In:   "revert_question 1 1\nexit",
+			ExpectedOut: "$ Question no 1 reverted to revision 1:\n" +
+				"Q: \"How many characters are there in \"Quipper\"?\"\nA: 7\nVersion: 3\n$ ",
+		},
+		{
+			Name:        "revert question 1 to unknown revision",
+			In:          "revert_question 1 9\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not revert question [%d]: %v\n$ ", 1, questionnaire.ErrRevisionNotFound),
+		},
+		{
+			Name:        "diff question 1 with unknown revision",
+			In:          "diff_question 1 1 9\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not get revision 9 of question [%d]: %v\n$ ", 1, questionnaire.ErrRevisionNotFound),
+		},
+		{
+			Name:        "history of question not found",
+			In:          "history 9\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not get history of question [%d]: %v\n$ ", 9, questionnaire.ErrQuestionNotFound),
+		},
+		{
+			Name:        "revert question invalid revision",
+			In:          "revert_question 1 X\nexit",
+			ExpectedOut: "$ Invalid revision, should be integer\n$ ",
+		},
+		// undo
+		{
+			Name: "undo revert of question 1",
+			In:   "undo\nquestion 1\nexit",
+			ExpectedOut: "$ Undone change of question no 1\n" +
+				"$ Q: \"How many characters are there in \"Quipper\"?\"\nA: 8\nVersion: 4\n$ ",
+		},
+		{
+			Name: "redo revert of question 1",
+			In:   "redo\nquestion 1\nexit",
+			ExpectedOut: "$ Redone change of question no 1\n" +
+				"$ Q: \"How many characters are there in \"Quipper\"?\"\nA: 7\nVersion: 5\n$ ",
+		},
+		{
+			Name:        "nothing to redo",
+			In:          "redo\nexit",
+			ExpectedOut: fmt.Sprintf("$ Could not redo: %v\n$ ", questionnaire.ErrNothingToRedo),
+		},
+		{
+			Name: "undo in batch",
+			In:   "begin\nundo\nrollback\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Could not undo in the middle of a batch\n" +
+				"batch$ Batch rolled back\n$ ",
+		},
+		{
+			Name:        "commit without batch",
+			In:          "commit\nexit",
+			ExpectedOut: "$ No batch in progress, start one with \"begin\"\n$ ",
+		},
+		// tags
+		{
+			Name: "create questions 10 and 11 with tags and category",
+			In:   "create_question --tags math,Easy --category Math/Arithmetic 10 \"1 + 1?\" 2\ncreate_question --tags math 11 \"2 * 3?\" 6\nexit",
+			ExpectedOut: "$ Question no 10 created:\nQ: \"1 + 1?\"\nA: 2\nTags: easy, math\nCategory: math/arithmetic\n" +
+				"$ Question no 11 created:\nQ: \"2 * 3?\"\nA: 6\nTags: math\n$ ",
+		},
+		{
+			Name: "tag and untag question 10",
+			In:   "tag 10 hard\nuntag 10 easy,hard math\ntag 10 hard math\ntag 12 hard\ntag 10 \"very hard\"\nexit",
+			ExpectedOut: "$ Question no 10 tagged: easy, hard, math\n" +
+				"$ Question no 10 has no tag\n" +
+				"$ Question no 10 tagged: hard, math\n" +
+				"$ Could not tag question [12]: question not found\n" +
+				"$ Could not tag question [10]: \"very hard\": invalid tag, should be letters, digits, \"-\" or \"_\" and not AND, OR or NOT\n$ ",
+		},
+		{
+			Name: "update question 10 keeps tags and category",
+			In:   "update_question 10 \"1 + 1 =\" 2\nquestion 10\nexit",
+			ExpectedOut: "$ Question no 10 updated:\nQ: \"1 + 1 =\"\nA: 2\nVersion: 5\nTags: hard, math\nCategory: math/arithmetic\n" +
+				"$ Q: \"1 + 1 =\"\nA: 2\nVersion: 5\nTags: hard, math\nCategory: math/arithmetic\n$ ",
+		},
+		{
+			Name: "questions filtered by tags and category",
+			In:   "questions --tags \"math AND NOT hard\"\nquestions --category math\nquestions --tags \"math AND\"\nexit",
+			ExpectedOut: "$ No | Question | Answer\n11 \"2 * 3?\" 6\n" +
+				"$ No | Question | Answer\n10 \"1 + 1 =\" 2\n" +
+				"$ Invalid input format: unexpected end of expression: invalid tag expression. See \"help\"\n$ ",
+		},
+		{
+			Name: "play questions filtered by tags",
+			In:   "play --tags \"math AND NOT hard\"\nsix\nplay --tags math\n3\n\nplay --tags math\nquit\nplay --tags history\nexit",
+			ExpectedOut: "$ Playing 1 question(s), an empty answer skips a question and \"quit\" stops\n" +
+				"[11] \"2 * 3?\"\nanswer$ Correct!\nScore: 1/1\n" +
+				"$ Playing 2 question(s), an empty answer skips a question and \"quit\" stops\n" +
+				"[10] \"1 + 1 =\"\nanswer$ Incorrect! The answer is 2\n" +
+				"[11] \"2 * 3?\"\nanswer$ Skipped, the answer is 6\nScore: 0/2\n" +
+				"$ Playing 2 question(s), an empty answer skips a question and \"quit\" stops\n" +
+				"[10] \"1 + 1 =\"\nanswer$ Score: 0/0\n" +
+				"$ No question to play\n$ ",
+		},
+		// search
+		{
+			Name: "search characters quipper",
+			In:   "search characters \"Quipper\"\nsearch 5\nsearch\nexit",
+			ExpectedOut: "$ No | Score | Question | Answer\n1 1.75 \"How many [characters] are there in \"[Quipper]\"?\" 7\n" +
+				"3 0.49 \"How many [characters] are there in \"Engineer\"?\" 8\n4 0.49 \"How many [characters] are there in \"Batch\"?\" 5\n" +
+				"$ No | Score | Question | Answer\n4 0.90 \"How many characters are there in \"Batch\"?\" [5]\n" +
+				"$ Invalid input format. See \"help\"\n$ ",
+		},
+		// id allocation
+		{
+			Name:        "create question without number",
+			In:          "create_question \"2 + 2?\" 4\ncreate_question \"2 + 2?\"\nexit",
+			ExpectedOut: "$ Question no 12 created:\nQ: \"2 + 2?\"\nA: 4\n$ Invalid input format. See \"help\"\n$ ",
+		},
+		// csv
+		{
+			Name: "import csv dry run",
+			In:   fmt.Sprintf("import_csv --dry-run --on-conflict overwrite %s\nquestion 13\nexit", importCSV),
+			ExpectedOut: "$ Line 4: invalid id \"x\", should be integer\nLine 5: bare \" in non-quoted-field\n" +
+				fmt.Sprintf("Dry run of %s, nothing imported: 1 created, 1 updated, 0 skipped, 2 failed\n", importCSV) +
+				"$ Could not get question [13]: question not found\n$ ",
+		},
+		{
+			Name: "import csv",
+			In:   fmt.Sprintf("import_csv %s\nquestion 13\nimport_csv --on-conflict fail %s\nimport_csv --on-conflict maybe %s\nexit", importCSV, importCSV, importCSV),
+			ExpectedOut: "$ Line 2: question no 12 skipped: question is already exist\n" +
+				"Line 4: invalid id \"x\", should be integer\nLine 5: bare \" in non-quoted-field\n" +
+				fmt.Sprintf("Imported %s: 1 created, 0 updated, 1 skipped, 2 failed\n", importCSV) +
+				"$ Q: \"How many legs does a spider have?\"\nA: 8\nVersion: 1\n" +
+				fmt.Sprintf("$ Could not import %s, nothing imported: question [12]: question is already exist\n", importCSV) +
+				"$ Invalid input format: unknown conflict policy \"maybe\", should be one of: skip, overwrite, fail. See \"help\"\n$ ",
+		},
+		{
+			Name: "import csv in batch",
+			In:   fmt.Sprintf("begin\nimport_csv --dry-run --on-conflict overwrite %s\nimport_csv --on-conflict fail %s\ncommit\nexit", importCSV, importCSV),
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Line 4: invalid id \"x\", should be integer\nLine 5: bare \" in non-quoted-field\n" +
+				fmt.Sprintf("Dry run of %s, nothing imported: 1 created, 1 updated, 0 skipped, 2 failed\n", importCSV) +
+				fmt.Sprintf("batch$ Could not import %s, nothing imported: question [12]: question is already exist\n", importCSV) +
+				"batch$ Could not commit batch, rolled back: 1 change(s) failed\n$ ",
+		},
+		{
+			Name: "export csv",
+			In:   fmt.Sprintf("export_csv --columns No=id,Question=question %s\nexport_csv %s\nexit", exportCSV, filepath.Join(dir, "missing", "export.csv")),
+			ExpectedOut: fmt.Sprintf("$ Exported 7 question(s) to %s\n", exportCSV) +
+				fmt.Sprintf("$ Could not export %s: could not create temporary file in %q: no such file or directory\n$ ", filepath.Join(dir, "missing", "export.csv"), filepath.Join(dir, "missing")),
+		},
+		// banks
+		{
+			Name: "create bank science",
+			In:   "create_bank science\nbanks\nexit",
+			ExpectedOut: "$ Bank science created, switch to it with \"use science\"\n" +
+				"$ Bank | Created at\n* default -\n  science 2024-01-02 03:04\n$ ",
+		},
+		{
+			Name:        "create bank invalid name",
+			In:          "create_bank \"social science\"\nexit",
+			ExpectedOut: "$ Could not create bank: \"social science\": invalid bank name, should be letters, digits, \"-\" or \"_\"\n$ ",
+		},
+		{
+			Name: "copy question 1 to science, use science and update the copy",
+			In:   "copy_question 1 science\nuse science\nbanks\nupdate_question 1 \"1 + 1?\" 2\nquestions\nexit",
+			ExpectedOut: "$ Question no 1 copied to bank science:\nQ: \"How many characters are there in \"Quipper\"?\"\nA: 7\n" +
+				"$ Using bank science\n" +
+				"$ Bank | Created at\n  default -\n* science 2024-01-02 03:04\n" +
+				"$ Question no 1 updated:\nQ: \"1 + 1?\"\nA: 2\nVersion: 2\n" +
+				"$ No | Question | Answer\n1 \"1 + 1?\" 2\n$ ",
+		},
+		{
+			Name: "question 1 of the default bank is kept, use unknown bank",
+			In:   "question 1\nuse history\nexit",
+			ExpectedOut: "$ Q: \"How many characters are there in \"Quipper\"?\"\nA: 7\nVersion: 5\n" +
+				"$ Could not use bank history: bank not found\n$ ",
+		},
+		{
+			Name: "use in batch",
+			In:   "begin\nuse science\nrollback\nexit",
+			ExpectedOut: "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+				"batch$ Could not use in the middle of a batch\n" +
+				"batch$ Batch rolled back\n$ ",
+		},
+		// bank files
+		{
+			Name: "save and load bank science",
+			In:   fmt.Sprintf("use science\nsave %s\nload --dry-run --on-conflict overwrite %s\nload %s\nsave %s\nexit", scienceYAML, scienceYAML, scienceYAML, scienceTXT),
+			ExpectedOut: fmt.Sprintf("$ Using bank science\n$ Saved 1 question(s) of bank science to %s\n", scienceYAML) +
+				fmt.Sprintf("$ Dry run of %s, nothing loaded: 0 created, 1 updated, 0 skipped, 0 failed\n", scienceYAML) +
+				"$ Question no 1 skipped: question is already exist\n" +
+				fmt.Sprintf("Loaded %s into bank science: 0 created, 0 updated, 1 skipped, 0 failed\n", scienceYAML) +
+				fmt.Sprintf("$ Could not save %s: %q: unknown bank file format, should be .json, .yaml or .yml\n$ ", scienceTXT, scienceTXT),
+		},
+		// moodle
+		{
+			Name: "import and export moodle",
+			In:   fmt.Sprintf("use science\nimport_moodle %s\nexport_moodle %s\nexport_moodle %s\nexit", scienceGIFT, scienceXML, scienceYAML),
+			ExpectedOut: "$ Using bank science\n" +
+				"$ Warning: line 3: name \"Color\" left out, only a number is kept as the question number\n" +
+				"Warning: line 3: multiple choice question left out\nWarning: line 5: feedback left out\n" +
+				"Question no 1 skipped: question is already exist\n" +
+				fmt.Sprintf("Imported %s: 1 created, 0 updated, 1 skipped, 0 failed\n", scienceGIFT) +
+				fmt.Sprintf("$ Exported 2 question(s) to %s\n", scienceXML) +
+				fmt.Sprintf("$ Could not export %s: %q: unknown Moodle format, should be .gift, .txt or .xml\n$ ", scienceYAML, scienceYAML),
+		},
+		// qti
+		{
+			Name: "export qti",
+			In:   fmt.Sprintf("use science\ntag 2 water\nexport_qti %s\nexport_qti\nexit", scienceQTI),
+			ExpectedOut: "$ Using bank science\n$ Question no 2 tagged: water\n" +
+				"$ Warning: question [1]: other forms of the value of answer 2 left out, only its spellings are accepted\n" +
+				"Warning: question [2]: tags water left out, QTI items have no tags\n" +
+				fmt.Sprintf("Exported 2 question(s) to %s\n", scienceQTI) +
+				"$ Invalid input format. See \"help\"\n$ ",
+		},
+		// anki
+		{
+			Name: "export and import anki",
+			In:   fmt.Sprintf("use science\nexport_anki %s\nimport_anki --on-conflict overwrite %s\nuse default\nimport_anki --dry-run %s\nexit", scienceAnki, scienceAnki, scienceAnki),
+			ExpectedOut: fmt.Sprintf("$ Using bank science\n$ Exported 2 question(s) to %s\n", scienceAnki) +
+				fmt.Sprintf("$ Imported %s: 0 created, 2 updated, 0 skipped, 0 failed\n", scienceAnki) +
+				"$ Using bank default\n" +
+				"$ Question no 1 skipped: question is already exist\n" +
+				fmt.Sprintf("Dry run of %s, nothing imported: 1 created, 0 updated, 1 skipped, 0 failed\n$ ", scienceAnki),
+		},
+		// print
+		{
+			Name: "print",
+			In:   fmt.Sprintf("print --title \"Trivia night\" --shuffle 7 --page-break 2 --to 4 %s\nprint --shuffle x %s\nprint %s\nexit", triviaMD, triviaMD, triviaPDF),
+			ExpectedOut: fmt.Sprintf("$ Printed 3 question(s) to %s, answer key in %s\n", triviaMD, triviaKeyMD) +
+				"$ Invalid input format: shuffle seed should be integer. See \"help\"\n" +
+				fmt.Sprintf("$ Could not print %s: %q: unknown printout format, should be .md, .markdown, .html or .htm\n$ ", triviaPDF, triviaPDF),
+		},
+		// matching
+		{
+			Name: "answer numbers by value as the matching options tell",
+			In: "create_question 20 \"Half of 1?\" 0.5\nanswer_question 20 \"one half\"\nanswer_question 20 50%\n" +
+				"update_question --matching strict-percent,Strict-Zeros 20 \"Half of 1?\" 0.5\nanswer_question 20 50%\n" +
+				"answer_question 20 1/2\nupdate_question --matching strict-case 20 \"Half of 1?\" 0.5\nexit",
+			ExpectedOut: "$ Question no 20 created:\nQ: \"Half of 1?\"\nA: 0.5\n$ Correct!\n$ Correct!\n" +
+				"$ Question no 20 updated:\nQ: \"Half of 1?\"\nA: 0.5\nVersion: 2\nMatching: strict-percent, strict-zeros\n" +
+				"$ Incorrect!\n$ Correct!\n" +
+				"$ Could not update question [20]: \"strict-case\": invalid matching option, should be strict-percent, strict-fraction, strict-zeros, ordinals or roman\n$ ",
+		},
+		{
+			Name: "answer numbers within a tolerance",
+			In: "create_question 21 \"How tall is Everest in metres?\" \"8849 ±10\"\nanswer_question 21 8850\n" +
+				"answer_question 21 \"eight thousand eight hundred and forty\"\nanswer_question 21 8860\nexit",
+			ExpectedOut: "$ Question no 21 created:\nQ: \"How tall is Everest in metres?\"\nA: 8849 ±10\n" +
+				"$ Correct!\n$ Correct!\n$ Incorrect!\n$ ",
+		},
+		{
+			Name: "answer quantities in any unit of the dimension",
+			In: "create_question 22 \"How far is a 5K run?\" \"5 km\"\nanswer_question 22 \"5000 m\"\n" +
+				"answer_question 22 \"five kilometres\"\nanswer_question 22 \"3 miles\"\nanswer_question 22 \"5 kg\"\nexit",
+			ExpectedOut: "$ Question no 22 created:\nQ: \"How far is a 5K run?\"\nA: 5 km\n" +
+				"$ Correct!\n$ Correct!\n$ Incorrect!\n$ Incorrect! Answer measures mass, expected length\n$ ",
+		},
+		{
+			Name: "answer ordinals and roman numerals",
+			In: "create_question --matching ordinals,roman 23 \"Who founded the Church of England?\" \"Henry the Eighth\"\n" +
+				"answer_question 23 \"Henry VIII\"\nanswer_question 23 \"Henry the 8th\"\nanswer_question 23 \"Henry VII\"\nexit",
+			ExpectedOut: "$ Question no 23 created:\nQ: \"Who founded the Church of England?\"\nA: Henry the Eighth\nMatching: ordinals, roman\n" +
+				"$ Correct!\n$ Correct!\n$ Incorrect!\n$ ",
+		},
 	}
 
 	var qs questionnaire.Service
 	{
 		r := questionnaire.NewRepository()
-		qs = questionnaire.NewService(r)
+		qs = questionnaire.NewUndoService(questionnaire.NewService(r, questionnaire.WithClock(func() time.Time {
+			return time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+		})), 100)
 	}
 
 	for _, tc := range tt {
@@ -180,7 +648,7 @@ func TestIntegrationScenario1(t *testing.T) {
 				in  = strings.NewReader(tc.In)
 				out = new(strings.Builder)
 			)
-			if run(context.Background(), qs, in, out) != 0 {
+			if run(questionnaire.WithAuthor(context.Background(), "tester"), qs, in, out) != 0 {
 				t.Fatalf("do not exit properly\n")
 			}
 			if diff := cmp.Diff(tc.ExpectedOut, out.String()); diff != "" {
@@ -189,3 +657,117 @@ func TestIntegrationScenario1(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenRepository(t *testing.T) {
+	tt := []struct {
+		Name      string
+		Store     string
+		ExpectErr bool
+	}{
+		{
+			Name:      "memory store",
+			Store:     "memory",
+			ExpectErr: false,
+		},
+		{
+			Name:      "json store",
+			Store:     "json:" + filepath.Join(t.TempDir(), "questions.json"),
+			ExpectErr: false,
+		},
+		{
+			Name:      "wal store",
+			Store:     "wal:" + filepath.Join(t.TempDir(), "questions.wal"),
+			ExpectErr: false,
+		},
+		{
+			Name:      "sqlite store",
+			Store:     "sqlite:" + filepath.Join(t.TempDir(), "questions.db"),
+			ExpectErr: false,
+		},
+		{
+			Name:      "json store without path",
+			Store:     "json",
+			ExpectErr: true,
+		},
+		{
+			Name:      "unknown store",
+			Store:     "mongodb:localhost",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := openRepository(tc.Store)
+			if tc.ExpectErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectErr, err)
+			}
+		})
+	}
+}
+
+// racingService tags a question right after it's read, as another session would.
+type racingService struct {
+	questionnaire.Service
+}
+
+func (s *racingService) GetByID(ctx context.Context, id int) (*questionnaire.Question, error) {
+	question, err := s.Service.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Service.Tag(ctx, id, []string{"racing"}); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+func TestUpdateQuestionKeepsConcurrentChange(t *testing.T) {
+	ctx := context.Background()
+	qs := questionnaire.NewService(questionnaire.NewRepository())
+	if err := qs.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(strings.Builder)
+	updateQuestion(ctx, &racingService{Service: qs}, []string{"update_question", "1", "1 + 2?", "3"}, out)
+
+	expectedOut := "Could not update question [1]: question 1 is at version 2, expected version 1\n" +
+		"Review it with \"question 1\" and retry with --if-version 2\n"
+	if diff := cmp.Diff(expectedOut, out.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	question, err := qs.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"racing"}, question.Tags); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestBatchReadError(t *testing.T) {
+	ctx := context.Background()
+	qs := questionnaire.NewService(questionnaire.NewRepository())
+
+	var (
+		in  = io.MultiReader(strings.NewReader("begin\ncreate_question 1 \"1 + 1?\" 2\n"), iotest.ErrReader(errors.New("broken pipe")))
+		out = new(strings.Builder)
+	)
+	if code := run(ctx, qs, in, out); code != 1 {
+		t.Fatalf("expected exit status 1, got: %d", code)
+	}
+
+	expectedOut := "$ Batch started, \"commit\" to apply the changes or \"rollback\" to discard them\n" +
+		"batch$ Question no 1 created:\nQ: \"1 + 1?\"\nA: 2\n" +
+		"batch$ Could not commit batch, rolled back: could not read input: broken pipe\n"
+	if diff := cmp.Diff(expectedOut, out.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if _, err := qs.GetByID(ctx, 1); !errors.Is(err, questionnaire.ErrQuestionNotFound) {
+		t.Fatalf("expected the batch rolled back, got: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package textdiff compares texts line by line.
+package textdiff
+
+import (
+	"strings"
+)
+
+// Op tells what happened to a line when the first text is turned into the second one.
+type Op int
+
+const (
+	Equal  Op = iota // the line is in both texts
+	Delete           // the line is only in the first text
+	Insert           // the line is only in the second text
+)
+
+// Line is a line of the diff of two texts.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the lines of a and b in the order of the shortest edit turning a into b,
+// it's found from the longest common subsequence of the lines, deleted lines come before inserted ones.
+func Lines(a, b string) []Line {
+	var (
+		as = splitLines(a)
+		bs = splitLines(b)
+	)
+
+	// lcs[i][j] is the length of the longest common subsequence of as[i:] and bs[j:].
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, len(as)+len(bs))
+	i, j := 0, 0
+	for i < len(as) && j < len(bs) {
+		switch {
+		case as[i] == bs[j]:
+			lines = append(lines, Line{Op: Equal, Text: as[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: Delete, Text: as[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: bs[j]})
+			j++
+		}
+	}
+	for ; i < len(as); i++ {
+		lines = append(lines, Line{Op: Delete, Text: as[i]})
+	}
+	for ; j < len(bs); j++ {
+		lines = append(lines, Line{Op: Insert, Text: bs[j]})
+	}
+
+	return lines
+}
+
+// Unified formats the diff of text a named nameA and text b named nameB the way "diff -u" does,
+// without hunks: every line is printed prefixed by "-" when deleted, "+" when inserted or " " otherwise.
+// Examples:
+//
+//   - Unified("revision 1", "revision 2", "Q: \"1 + 1?\"\nA: 2\n", "Q: \"1 + 1?\"\nA: 3\n")
+//     > "--- revision 1\n+++ revision 2\n Q: \"1 + 1?\"\n-A: 2\n+A: 3\n"
+func Unified(nameA, nameB, a, b string) string {
+	var sb strings.Builder
+	sb.WriteString("--- " + nameA + "\n")
+	sb.WriteString("+++ " + nameB + "\n")
+
+	for _, line := range Lines(a, b) {
+		switch line.Op {
+		case Delete:
+			sb.WriteByte('-')
+		case Insert:
+			sb.WriteByte('+')
+		default:
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(line.Text)
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// splitLines splits s into lines, the line break ending the last line is optional.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,73 @@
+package textdiff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/pkg/textdiff"
+)
+
+func TestLines(t *testing.T) {
+	tt := []struct {
+		Name     string
+		A, B     string
+		Expected []textdiff.Line
+	}{
+		{
+			Name: "same texts",
+			A:    "Q: 1 + 1?\nA: 2\n",
+			B:    "Q: 1 + 1?\nA: 2\n",
+			Expected: []textdiff.Line{
+				{Op: textdiff.Equal, Text: "Q: 1 + 1?"},
+				{Op: textdiff.Equal, Text: "A: 2"},
+			},
+		},
+		{
+			Name: "changed line",
+			A:    "Q: 1 + 1?\nA: 2\n",
+			B:    "Q: 1 + 1?\nA: 3",
+			Expected: []textdiff.Line{
+				{Op: textdiff.Equal, Text: "Q: 1 + 1?"},
+				{Op: textdiff.Delete, Text: "A: 2"},
+				{Op: textdiff.Insert, Text: "A: 3"},
+			},
+		},
+		{
+			Name: "inserted and deleted lines",
+			A:    "a\nb\nc\nd",
+			B:    "a\nc\nd\ne",
+			Expected: []textdiff.Line{
+				{Op: textdiff.Equal, Text: "a"},
+				{Op: textdiff.Delete, Text: "b"},
+				{Op: textdiff.Equal, Text: "c"},
+				{Op: textdiff.Equal, Text: "d"},
+				{Op: textdiff.Insert, Text: "e"},
+			},
+		},
+		{
+			Name: "from empty text",
+			A:    "",
+			B:    "a\n",
+			Expected: []textdiff.Line{
+				{Op: textdiff.Insert, Text: "a"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.Expected, textdiff.Lines(tc.A, tc.B)); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	res := textdiff.Unified("revision 1", "revision 2", "Q: \"1 + 1?\"\nA: 2\n", "Q: \"1 + 1?\"\nA: 3\n")
+	expected := "--- revision 1\n+++ revision 2\n Q: \"1 + 1?\"\n-A: 2\n+A: 3\n"
+	if diff := cmp.Diff(expected, res); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,76 @@
+package textinput
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Interval is the inclusive range of numbers of an answer written with a tolerance, e.g. "8849 ±10", or as a
+// range, e.g. "8800..8900".
+type Interval struct {
+	Low, High *big.Rat
+	// Target and Tolerance are the number and the absolute tolerance of an answer written with a tolerance, nil
+	// for a range. A relative tolerance is given as absolute, e.g. 5 for "100 ±5%".
+	Target, Tolerance *big.Rat
+}
+
+var (
+	toleranceSeparators = []string{"±", "+/-", "+-"}
+	rangeSeparators     = []string{"..", " to "}
+)
+
+// ParseInterval parses s as a number with a tolerance or as an inclusive range of numbers, ok reports whether s is
+// one. The numbers are read by ParseNumber, a tolerance written as a percentage is relative to the number.
+// Examples:
+//
+//   - 8849 ±10, 8849 +/- 10 -> 8839 to 8859
+//   - 100 ±5% -> 95 to 105
+//   - 8800..8900, eight thousand eight hundred to eight thousand nine hundred -> 8800 to 8900
+func ParseInterval(s string) (interval Interval, ok bool) {
+	lower := strings.ToLower(s)
+	for _, sep := range toleranceSeparators {
+		target, tolerance, found := strings.Cut(lower, sep)
+		if !found {
+			continue
+		}
+		t, ok := ParseNumber(target)
+		if !ok {
+			return interval, false
+		}
+		d, ok := ParseNumber(tolerance)
+		if !ok || d.Value.Sign() < 0 {
+			return interval, false
+		}
+		if d.Percent {
+			d.Value.Mul(d.Value, new(big.Rat).Abs(t.Value))
+		}
+		interval.Target, interval.Tolerance = t.Value, d.Value
+		interval.Low = new(big.Rat).Sub(t.Value, d.Value)
+		interval.High = new(big.Rat).Add(t.Value, d.Value)
+		return interval, true
+	}
+
+	for _, sep := range rangeSeparators {
+		low, high, found := strings.Cut(lower, sep)
+		if !found {
+			continue
+		}
+		l, ok := ParseNumber(low)
+		if !ok {
+			return interval, false
+		}
+		h, ok := ParseNumber(high)
+		if !ok || l.Value.Cmp(h.Value) > 0 {
+			return interval, false
+		}
+		interval.Low, interval.High = l.Value, h.Value
+		return interval, true
+	}
+
+	return interval, false
+}
+
+// Contains reports whether v is in the interval, its ends included.
+func (i Interval) Contains(v *big.Rat) bool {
+	return i.Low.Cmp(v) <= 0 && v.Cmp(i.High) <= 0
+}
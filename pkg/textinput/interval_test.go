@@ -0,0 +1,52 @@
+package textinput_test
+
+import (
+	"testing"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+)
+
+func TestParseInterval(t *testing.T) {
+	tt := []struct {
+		Name              string
+		Input             string
+		ExpectedOk        bool
+		ExpectedLow       string
+		ExpectedHigh      string
+		ExpectedTolerance string // empty for a range
+	}{
+		{Name: "absolute tolerance", Input: "8849 ±10", ExpectedOk: true, ExpectedLow: "8839", ExpectedHigh: "8859", ExpectedTolerance: "10"},
+		{Name: "ascii tolerance", Input: "8849 +/- 0.5", ExpectedOk: true, ExpectedLow: "17697/2", ExpectedHigh: "17699/2", ExpectedTolerance: "1/2"},
+		{Name: "relative tolerance", Input: "-200 +- 5%", ExpectedOk: true, ExpectedLow: "-210", ExpectedHigh: "-190", ExpectedTolerance: "10"},
+		{Name: "tolerance in words", Input: "one hundred ± ten", ExpectedOk: true, ExpectedLow: "90", ExpectedHigh: "110", ExpectedTolerance: "10"},
+		{Name: "range", Input: "8800..8900", ExpectedOk: true, ExpectedLow: "8800", ExpectedHigh: "8900"},
+		{Name: "range in words", Input: "a half to Three quarters", ExpectedOk: true, ExpectedLow: "1/2", ExpectedHigh: "3/4"},
+		{Name: "negative tolerance", Input: "10 ±-1", ExpectedOk: false},
+		{Name: "reversed range", Input: "5..1", ExpectedOk: false},
+		{Name: "text", Input: "back to back", ExpectedOk: false},
+		{Name: "number", Input: "8849", ExpectedOk: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			interval, ok := textinput.ParseInterval(tc.Input)
+			if ok != tc.ExpectedOk {
+				t.Fatalf("expected ok: %t, got: %t", tc.ExpectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+			if low, high := interval.Low.RatString(), interval.High.RatString(); low != tc.ExpectedLow || high != tc.ExpectedHigh {
+				t.Fatalf("expected: %s to %s, got: %s to %s", tc.ExpectedLow, tc.ExpectedHigh, low, high)
+			}
+			var tolerance string
+			if interval.Tolerance != nil {
+				tolerance = interval.Tolerance.RatString()
+			}
+			if tolerance != tc.ExpectedTolerance {
+				t.Fatalf("expected tolerance: %q, got: %q", tc.ExpectedTolerance, tolerance)
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+package textinput
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// numberSeparators are the runes surrounding the numbers RecognizedAsNumber converts.
+var numberSeparators = []rune{' ', ',', '.', '?', '!', '(', ')'}
+
+var (
+	unitNames = [...]string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tensNames = [...]string{2: "twenty", 3: "thirty", 4: "forty", 5: "fifty", 6: "sixty", 7: "seventy", 8: "eighty",
+		9: "ninety"}
+	// scaleNames are the scale words from the largest, the largest number spelled is below a thousand times the
+	// largest scale.
+	scaleNames = [...]struct {
+		name  string
+		value int64
+	}{{"quadrillion", 1e15}, {"trillion", 1e12}, {"billion", 1e9}, {"million", 1e6}, {"thousand", 1e3}}
+
+	units, tens, scales = numberValues()
+)
+
+// Words of numbers besides units, tens and scales.
+const (
+	wordHundred = "hundred"
+	wordDozen   = "dozen"
+	wordA       = "a"
+	wordAnd     = "and"
+)
+
+// negativeWords make the number following them negative, e.g. "minus three".
+var negativeWords = map[string]bool{"minus": true, "negative": true}
+
+func numberValues() (units, tens, scales map[string]int64) {
+	units, tens, scales = make(map[string]int64), make(map[string]int64), make(map[string]int64)
+	for v, name := range unitNames {
+		units[name] = int64(v)
+	}
+	for v, name := range tensNames {
+		if name != "" {
+			tens[name] = int64(v * 10)
+		}
+	}
+	for _, scale := range scaleNames {
+		scales[scale.name] = scale.value
+	}
+	return units, tens, scales
+}
+
+// RecognizedAsNumber converts all substrings in the given s that represent numbers in English words to digits,
+// the canonical form of a number. A number is made of words separated by spaces or hyphens: units, tens,
+// "hundred", scales up to "quadrillion" and "dozen", with "and" after hundreds and scales, "a" in place of "one"
+// and "minus" or "negative" in front of negative numbers.
+// Examples:
+//
+//   - one, two, three or four?! -> 1, 2, 3 or 4?!
+//   - loss (one) usd -> loss (1) usd
+//   - twenty-one -> 21
+//   - one hundred and five -> 105
+//   - one thousand two hundred, twelve hundred -> 1200, 1200
+//   - a dozen eggs -> 12 eggs
+//   - minus three -> -3
+//   - one two three -> 1 2 3
+func RecognizedAsNumber(s string) string {
+	return RecognizedAsNumberWithOptions(s, false, false)
+}
+
+// RecognizedAsNumberWithOptions converts the numbers of s to digits as RecognizedAsNumber does, with ordinals and
+// Roman numerals as well when told so. Their canonical form is the number they stand for in digits, e.g. "3rd",
+// "third" and "III" are all 3. An ordinal is written in words or in digits with its suffix, the ordinal word of a
+// fraction is left as is, e.g. "third" in "one third". A "the" between a capitalized word and an ordinal is left
+// out, e.g. "Henry the Eighth" is "Henry 8", as is "Henry VIII" with Roman numerals. A Roman numeral is a word in
+// upper or lower case only.
+// Examples with ordinals:
+//
+//   - 3rd, third, Third -> 3, 3, 3
+//   - twenty-first, one hundred and first -> 21, 101
+//   - Henry the Eighth, Henry the 8th -> Henry 8, Henry 8
+//   - one third, a second -> 1 third, a second
+//
+// Examples with Roman numerals:
+//
+//   - III, xiv -> 3, 14
+//   - Henry VIII -> Henry 8
+//   - Mix, IIII -> Mix, IIII
+func RecognizedAsNumberWithOptions(s string, ordinals, roman bool) string {
+	parts := NumberParts(s)
+	if ordinals {
+		recognizeOrdinals(parts)
+	}
+	if roman {
+		recognizeRomanNumerals(parts)
+	}
+
+	return recognizeNumbers(parts)
+}
+
+// recognizeNumbers converts the numbers written in words of parts to digits and joins the parts.
+func recognizeNumbers(parts []string) string {
+	words := splitNumberWords(parts)
+
+	for w := 0; w < len(words); {
+		if !words[w].first {
+			w++
+			continue
+		}
+
+		var (
+			limit  = len(words)
+			digits string
+			end    int
+			ok     bool
+		)
+		for {
+			p := numberParser{words: words, start: w, limit: limit}
+			if digits, end, ok = p.parse(); !ok || words[end-1].last {
+				break
+			}
+			// The number ends in the middle of a hyphenated part, it has to end before the part.
+			for limit = end - 1; !words[limit].first; limit-- {
+			}
+			if limit <= w {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			w++
+			continue
+		}
+
+		parts[words[w].part] = digits
+		for i := words[w].part + 1; i <= words[end-1].part; i++ {
+			parts[i] = ""
+		}
+		w = end
+	}
+
+	return strings.Join(parts, "")
+}
+
+// NumberParts splits s into the parts RecognizedAsNumber converts, separators included.
+// Examples:
+//
+//   - loss (one) usd -> []string{"loss", " ", "(", "one", ")", " ", "usd"}
+func NumberParts(s string) []string {
+	return SplitWithOptions(s, numberSeparators, false, true)
+}
+
+// numberWord is a word of the parts of s, the words of a hyphenated part are words of their own when they are
+// all number words.
+type numberWord struct {
+	text string // in lower case
+	part int    // index of its part
+	// first and last tell whether it's the first and the last word of its part.
+	first, last bool
+	// joined tells whether it follows the previous word with nothing but spaces or a hyphen between them.
+	joined bool
+}
+
+func splitNumberWords(parts []string) []numberWord {
+	var (
+		words  []numberWord
+		joined bool
+	)
+	for i, part := range parts {
+		if len(part) == 1 && isNumberSeparator(rune(part[0])) {
+			joined = joined && part == " "
+			continue
+		}
+		if part == "" {
+			continue
+		}
+
+		lower := strings.ToLower(part)
+		texts := strings.Split(lower, "-")
+		for _, text := range texts {
+			if !isNumberWord(text) {
+				texts = []string{lower}
+				break
+			}
+		}
+		for k, text := range texts {
+			words = append(words, numberWord{
+				text:   text,
+				part:   i,
+				first:  k == 0,
+				last:   k == len(texts)-1,
+				joined: k > 0 || joined,
+			})
+		}
+		joined = true
+	}
+
+	return words
+}
+
+func isNumberSeparator(c rune) bool {
+	for _, sep := range numberSeparators {
+		if c == sep {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumberWord reports whether word is a word of a hyphenated number, e.g. "twenty-one".
+func isNumberWord(word string) bool {
+	_, unit := units[word]
+	_, ten := tens[word]
+	_, scale := scales[word]
+	return unit || ten || scale || word == wordHundred || word == wordDozen
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// numberParser parses the number written in words[start:limit] from start.
+type numberParser struct {
+	words        []numberWord
+	start, limit int
+}
+
+// word returns the text of words[i] when it's part of the number, "" otherwise.
+func (p *numberParser) word(i int) string {
+	if i >= p.limit || (i > p.start && !p.words[i].joined) {
+		return ""
+	}
+	return p.words[i].text
+}
+
+// parse parses the longest number from start, returns its digits and the index of the word following it.
+func (p *numberParser) parse() (digits string, end int, ok bool) {
+	i := p.start
+	negative := negativeWords[p.word(i)]
+	if negative {
+		i++
+		if w := p.word(i); isDigits(w) && p.words[i].first && p.words[i].last {
+			return "-" + w, i + 1, true
+		}
+	}
+
+	var (
+		total  int64
+		last   int64 = math.MaxInt64 // the last scale
+		parsed bool
+	)
+	for {
+		k := i
+		if parsed && p.word(k) == wordAnd {
+			k++ // e.g. "one thousand and five"
+		}
+		group, j, ok := p.belowThousand(k)
+		if !ok {
+			break
+		}
+		if group == 0 {
+			// Zero is a number of its own.
+			if !parsed {
+				total, i, parsed = 0, j, true
+			}
+			break
+		}
+		scale, isScale := scales[p.word(j)]
+		if isScale && scale < last && group < 1000 {
+			total, last, i, parsed = total+group*scale, scale, j+1, true
+			continue
+		}
+		// A group followed by a larger scale starts the next number, e.g. "two" in "one thousand two thousand".
+		if !isScale && group < last {
+			total, i, parsed = total+group, j, true
+		}
+		break
+	}
+	if !parsed {
+		return "", p.start, false
+	}
+
+	digits = strconv.FormatInt(total, 10)
+	if negative && total != 0 {
+		digits = "-" + digits
+	}
+	return digits, i, true
+}
+
+// belowThousand parses a number below ten thousand from i, made of hundreds or dozens, e.g. "twelve hundred and
+// five" or "a dozen". A group "a" is 1 when followed by "hundred", "dozen" or a scale.
+func (p *numberParser) belowThousand(i int) (v int64, end int, ok bool) {
+	var n int64
+	if p.word(i) == wordA {
+		next := p.word(i + 1)
+		if _, scale := scales[next]; !scale && next != wordHundred && next != wordDozen {
+			return 0, i, false
+		}
+		n, end = 1, i+1
+	} else if n, end, ok = p.belowHundred(i); !ok || n == 0 {
+		return n, end, ok
+	}
+
+	switch p.word(end) {
+	case wordDozen:
+		return n * 12, end + 1, true
+	case wordHundred:
+		v, end = n*100, end+1
+		k := end
+		if p.word(k) == wordAnd {
+			k++
+		}
+		if rest, next, ok := p.belowHundred(k); ok && rest > 0 {
+			v, end = v+rest, next
+		}
+		return v, end, true
+	}
+
+	return n, end, true
+}
+
+// belowHundred parses a number below a hundred from i, e.g. "twenty-one" or "twenty one".
+func (p *numberParser) belowHundred(i int) (v int64, end int, ok bool) {
+	w := p.word(i)
+	if v, ok := units[w]; ok {
+		return v, i + 1, true
+	}
+	if v, ok := tens[w]; ok {
+		if unit, ok := units[p.word(i+1)]; ok && unit > 0 && unit < 10 {
+			return v + unit, i + 2, true
+		}
+		return v, i + 1, true
+	}
+	return 0, i, false
+}
+
+// NumberWords returns the usual spellings in lower case of the number written in digits that RecognizedAsNumber
+// converts to it, nil when there is none.
+// Examples:
+//
+//   - 8 -> []string{"eight"}
+//   - 105 -> []string{"one hundred and five", "one hundred five"}
+//   - 1200 -> []string{"one thousand two hundred", "twelve hundred"}
+//   - -21 -> []string{"minus twenty one", "minus twenty-one", "negative twenty one", "negative twenty-one"}
+func NumberWords(number string) []string {
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || strconv.FormatInt(n, 10) != number || n <= -1e18 || n >= 1e18 {
+		return nil
+	}
+
+	spellings := make(map[string]bool)
+	if n < 0 {
+		for _, spelling := range NumberWords(strconv.FormatInt(-n, 10)) {
+			for negative := range negativeWords {
+				spellings[negative+" "+spelling] = true
+			}
+		}
+	} else {
+		for _, hyphen := range []bool{false, true} {
+			for _, and := range []bool{false, true} {
+				spellings[spellNumber(n, hyphen, and)] = true
+				if n >= 1100 && n < 10000 && n/100%10 != 0 {
+					spellings[spellHundreds(n, hyphen, and)] = true
+				}
+			}
+		}
+		switch {
+		case n == 12:
+			spellings[wordA+" "+wordDozen] = true
+		case n == 100:
+			spellings[wordA+" "+wordHundred] = true
+		}
+		for _, scale := range scaleNames {
+			if n == scale.value {
+				spellings[wordA+" "+scale.name] = true
+			}
+		}
+	}
+
+	words := make([]string, 0, len(spellings))
+	for spelling := range spellings {
+		words = append(words, spelling)
+	}
+	sort.Strings(words)
+
+	return words
+}
+
+// spellNumber spells n by scales, e.g. "one thousand two hundred and five". hyphen joins tens and units with a
+// hyphen, and writes "and" before the tens and units following a hundred or a scale.
+func spellNumber(n int64, hyphen, and bool) string {
+	if n == 0 {
+		return unitNames[0]
+	}
+
+	var words []string
+	for _, scale := range scaleNames {
+		if group := n / scale.value; group > 0 {
+			words = append(words, spellBelowThousand(group, hyphen, and), scale.name)
+			n %= scale.value
+		}
+	}
+	if n > 0 {
+		if and && len(words) > 0 && n < 100 {
+			words = append(words, wordAnd)
+		}
+		words = append(words, spellBelowThousand(n, hyphen, and))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// spellHundreds spells n from 1100 to 9999 by hundreds, e.g. "twelve hundred and five".
+func spellHundreds(n int64, hyphen, and bool) string {
+	words := []string{spellBelowHundred(n/100, hyphen), wordHundred}
+	if rest := n % 100; rest > 0 {
+		if and {
+			words = append(words, wordAnd)
+		}
+		words = append(words, spellBelowHundred(rest, hyphen))
+	}
+	return strings.Join(words, " ")
+}
+
+func spellBelowThousand(n int64, hyphen, and bool) string {
+	if n < 100 {
+		return spellBelowHundred(n, hyphen)
+	}
+	words := []string{unitNames[n/100], wordHundred}
+	if rest := n % 100; rest > 0 {
+		if and {
+			words = append(words, wordAnd)
+		}
+		words = append(words, spellBelowHundred(rest, hyphen))
+	}
+	return strings.Join(words, " ")
+}
+
+func spellBelowHundred(n int64, hyphen bool) string {
+	if n < int64(len(unitNames)) {
+		return unitNames[n]
+	}
+	if n%10 == 0 {
+		return tensNames[n/10]
+	}
+	if hyphen {
+		return tensNames[n/10] + "-" + unitNames[n%10]
+	}
+	return tensNames[n/10] + " " + unitNames[n%10]
+}
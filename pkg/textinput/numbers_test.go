@@ -0,0 +1,224 @@
+package textinput_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/pkg/textinput"
+)
+
+func TestRecognizedAsNumber(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Input    string
+		Expected string
+	}{
+		{
+			Name:     "string without number",
+			Input:    "cat",
+			Expected: "cat",
+		},
+		{
+			Name:     "string with valid number",
+			Input:    "zero, one!, two, three, four, five, six, seven, eight or (nine)",
+			Expected: "0, 1!, 2, 3, 4, 5, 6, 7, 8 or (9)",
+		},
+		{
+			Name:     "string contains number but not counted as number representative",
+			Input:    "walking2",
+			Expected: "walking2",
+		},
+		{
+			Name:     "teens and tens",
+			Input:    "Eleven, nineteen and ninety",
+			Expected: "11, 19 and 90",
+		},
+		{
+			Name:     "hyphenated compound",
+			Input:    "twenty-one legs",
+			Expected: "21 legs",
+		},
+		{
+			Name:     "compound with space",
+			Input:    "Twenty One",
+			Expected: "21",
+		},
+		{
+			Name:     "hundreds with and",
+			Input:    "one hundred and five",
+			Expected: "105",
+		},
+		{
+			Name:     "hundreds without and",
+			Input:    "three hundred forty-two",
+			Expected: "342",
+		},
+		{
+			Name:     "scales",
+			Input:    "one thousand two hundred",
+			Expected: "1200",
+		},
+		{
+			Name:     "hundreds of a year",
+			Input:    "twelve hundred",
+			Expected: "1200",
+		},
+		{
+			Name:     "scale followed by and",
+			Input:    "one thousand and five",
+			Expected: "1005",
+		},
+		{
+			Name:     "large number",
+			Input:    "four trillion six hundred billion seven million eight hundred and eighty thousand twenty-two",
+			Expected: "4600007880022",
+		},
+		{
+			Name:     "a hundred and a dozen",
+			Input:    "a hundred, a dozen eggs and a million",
+			Expected: "100, 12 eggs and 1000000",
+		},
+		{
+			Name:     "dozens",
+			Input:    "three dozen",
+			Expected: "36",
+		},
+		{
+			Name:     "a without number",
+			Input:    "a cat and a dog",
+			Expected: "a cat and a dog",
+		},
+		{
+			Name:     "negative numbers",
+			Input:    "minus three, negative twenty-one or minus 4",
+			Expected: "-3, -21 or -4",
+		},
+		{
+			Name:     "minus without number",
+			Input:    "minus sign",
+			Expected: "minus sign",
+		},
+		{
+			Name:     "numbers not joined",
+			Input:    "one two three",
+			Expected: "1 2 3",
+		},
+		{
+			Name:     "scales not descending",
+			Input:    "one thousand two thousand",
+			Expected: "1000 2000",
+		},
+		{
+			Name:     "trailing and is not part of the number",
+			Input:    "one hundred and cats",
+			Expected: "100 and cats",
+		},
+		{
+			Name:     "hyphenated words not all numbers",
+			Input:    "one-way and twenty-something",
+			Expected: "one-way and twenty-something",
+		},
+		{
+			Name:     "number ending in a hyphenated part",
+			Input:    "twenty one-way",
+			Expected: "20 one-way",
+		},
+		{
+			Name:     "zero stands alone",
+			Input:    "zero one",
+			Expected: "0 1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			s := textinput.RecognizedAsNumber(tc.Input)
+			if s != tc.Expected {
+				t.Fatalf("expected: %s, got: %s", tc.Expected, s)
+			}
+		})
+	}
+}
+
+func TestNumberWords(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Input    string
+		Expected []string
+	}{
+		{
+			Name:     "unit",
+			Input:    "10",
+			Expected: []string{"ten"},
+		},
+		{
+			Name:     "dozen",
+			Input:    "12",
+			Expected: []string{"a dozen", "twelve"},
+		},
+		{
+			Name:     "compound",
+			Input:    "21",
+			Expected: []string{"twenty one", "twenty-one"},
+		},
+		{
+			Name:     "hundreds",
+			Input:    "105",
+			Expected: []string{"one hundred and five", "one hundred five"},
+		},
+		{
+			Name:     "hundreds of a year",
+			Input:    "1200",
+			Expected: []string{"one thousand two hundred", "twelve hundred"},
+		},
+		{
+			Name:     "scale",
+			Input:    "1000000",
+			Expected: []string{"a million", "one million"},
+		},
+		{
+			Name:     "negative",
+			Input:    "-3",
+			Expected: []string{"minus three", "negative three"},
+		},
+		{
+			Name:     "not a number",
+			Input:    "eleven",
+			Expected: nil,
+		},
+		{
+			Name:     "not canonical",
+			Input:    "011",
+			Expected: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			words := textinput.NumberWords(tc.Input)
+			if diff := cmp.Diff(tc.Expected, words); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestNumberWordsRecognizedAsNumber(t *testing.T) {
+	numbers := []int64{0, 7, 13, 40, 99, 100, 101, 110, 999, 1001, 1099, 1100, 1999, 9999, 10000, 12345, 100100,
+		999999, 1000001, 20300405, 1e12, 7e15 + 42, 999999999999999999, -1, -1234}
+	for _, n := range numbers {
+		number := strconv.FormatInt(n, 10)
+		words := textinput.NumberWords(number)
+		if len(words) == 0 {
+			t.Fatalf("%s: expected words", number)
+		}
+		for _, word := range words {
+			if s := textinput.RecognizedAsNumber(word); s != number {
+				t.Fatalf("%q: expected: %s, got: %s", word, number, s)
+			}
+		}
+	}
+}
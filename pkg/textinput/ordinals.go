@@ -0,0 +1,185 @@
+package textinput
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ordinals are the cardinal words of the ordinal words, e.g. "three" for "third".
+	ordinals = ordinalValues()
+
+	ordinalPattern = regexp.MustCompile(`^(?i)(\d+)(st|nd|rd|th)$`)
+	romanPattern   = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+	romanValues    = map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+)
+
+const (
+	wordAn  = "an"
+	wordThe = "the"
+)
+
+func ordinalValues() map[string]string {
+	ordinals := make(map[string]string)
+	for _, name := range unitNames[1:] {
+		ordinal := ordinalOf(name)
+		if ordinal == "" {
+			ordinal = name + "th"
+		}
+		ordinals[ordinal] = name
+	}
+	for _, name := range tensNames {
+		if name != "" {
+			ordinals[strings.TrimSuffix(name, "y")+"ieth"] = name
+		}
+	}
+	ordinals[wordHundred+"th"] = wordHundred
+	for _, scale := range scaleNames {
+		ordinals[scale.name+"th"] = scale.name
+	}
+	return ordinals
+}
+
+// ordinalOf returns the irregular ordinal word of a unit name, "" when it's regular.
+func ordinalOf(unit string) string {
+	switch unit {
+	case "one":
+		return "first"
+	case "two":
+		return "second"
+	case "three":
+		return "third"
+	case "five":
+		return "fifth"
+	case "eight":
+		return "eighth"
+	case "nine":
+		return "ninth"
+	case "twelve":
+		return "twelfth"
+	}
+	return ""
+}
+
+// ordinalSuffix returns the suffix of the ordinal of a number written in digits, e.g. "rd" for "23".
+func ordinalSuffix(digits string) string {
+	n, _ := strconv.Atoi(digits[max(0, len(digits)-2):])
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// recognizeOrdinals replaces the ordinals of parts by their number, in digits when written with a suffix and in
+// words otherwise, and leaves out the "the" between a capitalized word and an ordinal.
+func recognizeOrdinals(parts []string) {
+	for i, part := range parts {
+		if part == "" || (len(part) == 1 && isNumberSeparator(rune(part[0]))) {
+			continue
+		}
+
+		var number string
+		if m := ordinalPattern.FindStringSubmatch(part); m != nil && strings.ToLower(m[2]) == ordinalSuffix(m[1]) {
+			number = m[1]
+		} else if number = ordinalNumber(parts, i); number == "" {
+			continue
+		}
+		parts[i] = number
+
+		if the := previousWord(parts, i); the >= 0 && strings.ToLower(parts[the]) == wordThe {
+			if name := previousWord(parts, the); name >= 0 && unicode.IsUpper([]rune(parts[name])[0]) {
+				for k := the; k < i; k++ {
+					parts[k] = ""
+				}
+			}
+		}
+	}
+}
+
+// ordinalNumber returns the number in words of the ordinal word parts[i], e.g. "twenty-one" for "twenty-first",
+// "" when it's not an ordinal or it's the ordinal word of a fraction, e.g. "third" in "one third".
+func ordinalNumber(parts []string, i int) string {
+	words := strings.Split(strings.ToLower(parts[i]), "-")
+	cardinal, ok := ordinals[words[len(words)-1]]
+	if !ok {
+		return ""
+	}
+	if len(words) > 1 {
+		for _, word := range words[:len(words)-1] {
+			if !isNumberWord(word) {
+				return ""
+			}
+		}
+		return strings.Join(append(words[:len(words)-1], cardinal), "-")
+	}
+
+	var prev, beforePrev string
+	if p := previousWord(parts, i); p >= 0 {
+		prev = strings.ToLower(parts[p])
+		if b := previousWord(parts, p); b >= 0 {
+			beforePrev = strings.ToLower(parts[b])
+		}
+	}
+	_, afterTens := tens[prev]
+	_, afterScale := scales[prev]
+	_, scaleBeforeAnd := scales[beforePrev]
+	unit, isUnit := units[cardinal]
+	_, isScale := scales[cardinal]
+	switch {
+	case afterTens && isUnit && unit < 10:
+		return cardinal // e.g. "twenty first"
+	case afterScale || prev == wordHundred || (prev == wordAnd && (scaleBeforeAnd || beforePrev == wordHundred)):
+		return cardinal // e.g. "one hundred and first"
+	case (isScale || cardinal == wordHundred) && isNumberWord(prev) && prev != unitNames[1]:
+		return cardinal // e.g. "two thousandth", "one thousandth" is a fraction
+	case isNumberWord(prev) || isDigits(prev) || prev == wordA || prev == wordAn:
+		return "" // e.g. "one third"
+	case isScale || cardinal == wordHundred:
+		return unitNames[1] + "-" + cardinal // e.g. "hundredth"
+	}
+	return cardinal
+}
+
+// previousWord returns the index of the word of parts before parts[i] with nothing but spaces between them, -1
+// when there is none.
+func previousWord(parts []string, i int) int {
+	for k := i - 1; k >= 0; k-- {
+		switch {
+		case parts[k] == "" || parts[k] == " ":
+			continue
+		case len(parts[k]) == 1 && isNumberSeparator(rune(parts[k][0])):
+			return -1
+		}
+		return k
+	}
+	return -1
+}
+
+// recognizeRomanNumerals replaces the Roman numerals of parts by their number in digits.
+func recognizeRomanNumerals(parts []string) {
+	for i, part := range parts {
+		upper := strings.ToUpper(part)
+		if part == "" || (part != upper && part != strings.ToLower(part)) || !romanPattern.MatchString(upper) {
+			continue
+		}
+		var v int
+		for k := 0; k < len(upper); k++ {
+			if k+1 < len(upper) && romanValues[upper[k]] < romanValues[upper[k+1]] {
+				v -= romanValues[upper[k]]
+			} else {
+				v += romanValues[upper[k]]
+			}
+		}
+		parts[i] = strconv.Itoa(v)
+	}
+}
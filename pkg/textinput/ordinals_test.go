@@ -0,0 +1,42 @@
+package textinput_test
+
+import (
+	"testing"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+)
+
+func TestRecognizedAsNumberWithOptions(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Input    string
+		Ordinals bool
+		Roman    bool
+		Expected string
+	}{
+		{Name: "ordinals in words", Input: "first, Third and twelfth", Ordinals: true, Expected: "1, 3 and 12"},
+		{Name: "ordinals in digits", Input: "1st, 2ND, 3rd, 11th, 22nd and 101st", Ordinals: true, Expected: "1, 2, 3, 11, 22 and 101"},
+		{Name: "wrong suffix", Input: "3th and 11st", Ordinals: true, Expected: "3th and 11st"},
+		{Name: "compound ordinals", Input: "twenty-first, twenty first and one hundred and first", Ordinals: true, Expected: "21, 21 and 101"},
+		{Name: "tens and scales", Input: "the ninetieth, hundredth or two thousandth", Ordinals: true, Expected: "the 90, 100 or 2000"},
+		{Name: "regnal ordinals", Input: "Henry the Eighth and Henry the 8th", Ordinals: true, Expected: "Henry 8 and Henry 8"},
+		{Name: "the of other words", Input: "the third day", Ordinals: true, Expected: "the 3 day"},
+		{Name: "fractions", Input: "one third, two thirds, a second and 1 fifth", Ordinals: true, Expected: "1 third, 2 thirds, a second and 1 fifth"},
+		{Name: "ordinals left as is", Input: "3rd and third", Expected: "3rd and third"},
+		{Name: "roman numerals", Input: "III, xiv, MCMXCIX and IV", Roman: true, Expected: "3, 14, 1999 and 4"},
+		{Name: "regnal roman numerals", Input: "Henry VIII", Roman: true, Expected: "Henry 8"},
+		{Name: "not roman numerals", Input: "Mix, IIII, VV and IC", Roman: true, Expected: "Mix, IIII, VV and IC"},
+		{Name: "roman numerals left as is", Input: "I mix III", Expected: "I mix III"},
+		{Name: "roman numerals mangle words", Input: "I mix", Roman: true, Expected: "1 1009"},
+		{Name: "both", Input: "Henry the Eighth, Henry VIII, three", Ordinals: true, Roman: true, Expected: "Henry 8, Henry 8, 3"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if s := textinput.RecognizedAsNumberWithOptions(tc.Input, tc.Ordinals, tc.Roman); s != tc.Expected {
+				t.Fatalf("expected: %q, got: %q", tc.Expected, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package textinput
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Number is a number read by ParseNumber.
+type Number struct {
+	// Value is the exact value of the number, e.g. 1/2 for "50%".
+	Value *big.Rat
+	// Percent tells it's written as a percentage, e.g. "50%" or "50 percent".
+	Percent bool
+	// Fraction tells it's written as a fraction, e.g. "1/2", "1 1/2" or "one half".
+	Fraction bool
+	// Decimals is the number of digits after the decimal point, e.g. 2 for "0.50".
+	Decimals int
+}
+
+var (
+	decimalPattern  = regexp.MustCompile(`^([+-]?)(\d*|\d{1,3}(?:,\d{3})+)(?:\.(\d+))?$`)
+	fractionPattern = regexp.MustCompile(`^([+-]?\d+)/(\d+)$`)
+)
+
+// denominators are the words of fractions by denominator, singular and plural, e.g. "quarter" in "three
+// quarters".
+var denominators = map[string]int64{
+	"half": 2, "halves": 2, "third": 3, "thirds": 3, "quarter": 4, "quarters": 4, "fourth": 4, "fourths": 4,
+	"fifth": 5, "fifths": 5, "sixth": 6, "sixths": 6, "seventh": 7, "sevenths": 7, "eighth": 8, "eighths": 8,
+	"ninth": 9, "ninths": 9, "tenth": 10, "tenths": 10, "hundredth": 100, "hundredths": 100,
+	"thousandth": 1000, "thousandths": 1000,
+}
+
+// ParseNumber parses s as a single number, written in digits or in words as RecognizedAsNumber converts them,
+// ok reports whether s is one. A number is a decimal, a fraction or a mixed number, optionally followed by "%"
+// or "percent".
+// Examples:
+//
+//   - 0.5, .50, +0.5 -> 1/2
+//   - 1/2, one half, a half -> 1/2
+//   - 1 1/2, one and a half -> 3/2
+//   - three quarters -> 3/4
+//   - 50%, fifty percent -> 1/2
+//   - 1,000 -> 1000
+func ParseNumber(s string) (n Number, ok bool) {
+	fields := strings.Fields(strings.ToLower(RecognizedAsNumber(strings.TrimSpace(s))))
+	if len(fields) == 0 {
+		return n, false
+	}
+
+	switch last := fields[len(fields)-1]; {
+	case last == "%" || last == "percent":
+		fields, n.Percent = fields[:len(fields)-1], true
+	case len(fields) > 1 && last == "cent" && fields[len(fields)-2] == "per":
+		fields, n.Percent = fields[:len(fields)-2], true
+	case strings.HasSuffix(last, "%"):
+		fields[len(fields)-1], n.Percent = strings.TrimSuffix(last, "%"), true
+	}
+
+	negative := len(fields) > 0 && negativeWords[fields[0]]
+	if negative {
+		fields = fields[1:]
+	}
+	if n.Value, ok = parseMixed(fields, &n); !ok {
+		return n, false
+	}
+	if negative {
+		n.Value.Neg(n.Value)
+	}
+	if n.Percent {
+		n.Value.Quo(n.Value, big.NewRat(100, 1))
+	}
+
+	return n, true
+}
+
+// parseMixed parses fields as a decimal, a fraction or a whole number followed by a fraction, e.g. "1 1/2" or
+// "1 and a half".
+func parseMixed(fields []string, n *Number) (*big.Rat, bool) {
+	switch len(fields) {
+	case 1:
+		if v, ok := parseFraction(fields, n); ok {
+			return v, true
+		}
+		return parseDecimal(fields[0], n)
+	case 2:
+		if v, ok := parseFraction(fields, n); ok {
+			return v, true
+		}
+	}
+
+	whole, rest := fields, []string(nil)
+	for i, field := range fields {
+		if field == wordAnd {
+			whole, rest = fields[:i], fields[i+1:]
+			break
+		}
+	}
+	if rest == nil {
+		if len(fields) != 2 {
+			return nil, false
+		}
+		whole, rest = fields[:1], fields[1:]
+	}
+	if len(whole) != 1 || !isDigits(strings.TrimLeft(whole[0], "+-")) {
+		return nil, false
+	}
+	v, ok := new(big.Rat).SetString(whole[0])
+	if !ok {
+		return nil, false
+	}
+	fraction, ok := parseFraction(rest, n)
+	if !ok || fraction.Sign() < 0 {
+		return nil, false
+	}
+	if v.Sign() < 0 {
+		fraction.Neg(fraction)
+	}
+
+	return v.Add(v, fraction), true
+}
+
+// parseFraction parses fields as a fraction, e.g. "1/2", "1 half" or "a half".
+func parseFraction(fields []string, n *Number) (*big.Rat, bool) {
+	switch len(fields) {
+	case 1:
+		m := fractionPattern.FindStringSubmatch(fields[0])
+		if m == nil {
+			return nil, false
+		}
+		v, ok := new(big.Rat).SetString(m[1] + "/" + m[2])
+		n.Fraction = ok
+		return v, ok
+	case 2:
+		denominator, ok := denominators[fields[1]]
+		if !ok {
+			return nil, false
+		}
+		numerator := fields[0]
+		if numerator == wordA {
+			numerator = "1"
+		}
+		if !isDigits(strings.TrimLeft(numerator, "+-")) {
+			return nil, false
+		}
+		v, ok := new(big.Rat).SetString(numerator)
+		if !ok {
+			return nil, false
+		}
+		n.Fraction = true
+		return v.Quo(v, big.NewRat(denominator, 1)), true
+	}
+	return nil, false
+}
+
+// parseDecimal parses s as a decimal, e.g. "-1,000.50" or ".5".
+func parseDecimal(s string, n *Number) (*big.Rat, bool) {
+	m := decimalPattern.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return nil, false
+	}
+	whole := strings.ReplaceAll(m[2], ",", "")
+	if whole == "" {
+		whole = "0"
+	}
+	v, ok := new(big.Rat).SetString(m[1] + whole + "." + m[3] + "0")
+	n.Decimals = len(m[3])
+	return v, ok
+}
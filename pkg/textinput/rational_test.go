@@ -0,0 +1,57 @@
+package textinput_test
+
+import (
+	"testing"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+)
+
+func TestParseNumber(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Input            string
+		ExpectedOk       bool
+		ExpectedValue    string
+		ExpectedPercent  bool
+		ExpectedFraction bool
+		ExpectedDecimals int
+	}{
+		{Name: "integer", Input: "42", ExpectedOk: true, ExpectedValue: "42"},
+		{Name: "number in words", Input: "minus twenty-one", ExpectedOk: true, ExpectedValue: "-21"},
+		{Name: "decimal", Input: " 0.50 ", ExpectedOk: true, ExpectedValue: "1/2", ExpectedDecimals: 2},
+		{Name: "decimal without whole part", Input: "-.5", ExpectedOk: true, ExpectedValue: "-1/2", ExpectedDecimals: 1},
+		{Name: "thousands separators", Input: "1,234.5", ExpectedOk: true, ExpectedValue: "2469/2", ExpectedDecimals: 1},
+		{Name: "fraction", Input: "2/4", ExpectedOk: true, ExpectedValue: "1/2", ExpectedFraction: true},
+		{Name: "mixed number", Input: "-1 1/2", ExpectedOk: true, ExpectedValue: "-3/2", ExpectedFraction: true},
+		{Name: "fraction in words", Input: "Three quarters", ExpectedOk: true, ExpectedValue: "3/4", ExpectedFraction: true},
+		{Name: "mixed number in words", Input: "two and a half", ExpectedOk: true, ExpectedValue: "5/2", ExpectedFraction: true},
+		{Name: "negative fraction in words", Input: "minus a third", ExpectedOk: true, ExpectedValue: "-1/3", ExpectedFraction: true},
+		{Name: "percentage", Input: "12.5%", ExpectedOk: true, ExpectedValue: "1/8", ExpectedPercent: true, ExpectedDecimals: 1},
+		{Name: "percentage in words", Input: "fifty percent", ExpectedOk: true, ExpectedValue: "1/2", ExpectedPercent: true},
+		{Name: "text", Input: "8 legs", ExpectedOk: false},
+		{Name: "zero denominator", Input: "1/0", ExpectedOk: false},
+		{Name: "misplaced separators", Input: "1,5", ExpectedOk: false},
+		{Name: "fraction word alone", Input: "half", ExpectedOk: false},
+		{Name: "empty", Input: "", ExpectedOk: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			n, ok := textinput.ParseNumber(tc.Input)
+			if ok != tc.ExpectedOk {
+				t.Fatalf("expected ok: %t, got: %t", tc.ExpectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+			if value := n.Value.RatString(); value != tc.ExpectedValue {
+				t.Fatalf("expected value: %s, got: %s", tc.ExpectedValue, value)
+			}
+			if n.Percent != tc.ExpectedPercent || n.Fraction != tc.ExpectedFraction || n.Decimals != tc.ExpectedDecimals {
+				t.Fatalf("expected percent: %t, fraction: %t, decimals: %d, got: %+v",
+					tc.ExpectedPercent, tc.ExpectedFraction, tc.ExpectedDecimals, n)
+			}
+		})
+	}
+}
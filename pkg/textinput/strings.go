@@ -1,9 +1,5 @@
 package textinput
 
-import (
-	"strings"
-)
-
 // Split slices s into all substrings separated by any of given seps and returns a slice of
 // the substrings between those separators with quote awareness.
 // Examples:
@@ -68,42 +64,3 @@ func SplitWithOptions(s string, seps []rune, quoteAware, includeSep bool) []stri
 
 	return subseps
 }
-
-var stringsNumber = map[string]string{
-	"zero":  "0",
-	"one":   "1",
-	"two":   "2",
-	"three": "3",
-	"four":  "4",
-	"five":  "5",
-	"six":   "6",
-	"seven": "7",
-	"eight": "8",
-	"nine":  "9",
-	"ten":   "10",
-}
-
-// RecognizedAsNumber converts all substrings in the given s that represent numbers.
-// Limitation only handle 0 to 10.
-// Examples:
-//
-//   - one, two, three or four?! -> 1, 2, 3 or 4?!
-//   - loss (one) usd -> loss (1) usd
-func RecognizedAsNumber(s string) string {
-	var (
-		sep                      rune = ' '
-		commonSymbolsCoverNumber      = []rune{',', '.', '?', '!', '(', ')'}
-	)
-
-	parts := SplitWithOptions(s, append(commonSymbolsCoverNumber, sep), false, true)
-	for i, part := range parts {
-		part := strings.ToLower(part)
-		v, ok := stringsNumber[part]
-		if !ok {
-			continue
-		}
-		parts[i] = v
-	}
-
-	return strings.Join(parts, "")
-}
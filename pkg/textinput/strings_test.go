@@ -75,37 +75,3 @@ func TestSplitWithOptions(t *testing.T) {
 		})
 	}
 }
-
-func TestRecognizedAsNumber(t *testing.T) {
-	tt := []struct {
-		Name     string
-		Input    string
-		Expected string
-	}{
-		{
-			Name:     "string without number",
-			Input:    "cat",
-			Expected: "cat",
-		},
-		{
-			Name:     "string with valid number",
-			Input:    "zero, one!, two, three, four, five, six, seven, eight or (nine)",
-			Expected: "0, 1!, 2, 3, 4, 5, 6, 7, 8 or (9)",
-		},
-		{
-			Name:     "string contains number but not counted as number representative",
-			Input:    "walking2",
-			Expected: "walking2",
-		},
-	}
-
-	for _, tc := range tt {
-		tc := tc
-		t.Run(tc.Name, func(t *testing.T) {
-			s := textinput.RecognizedAsNumber(tc.Input)
-			if s != tc.Expected {
-				t.Fatalf("expected: %s, got: %s", tc.Expected, s)
-			}
-		})
-	}
-}
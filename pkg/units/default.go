@@ -0,0 +1,183 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Default is the registry of the SI units, with the usual prefixes, and of the common imperial and US customary
+// units, e.g. "km", "kilometres", "mi", "miles", "°F" or "degrees Fahrenheit". A US gallon, quart, pint and fluid
+// ounce is meant by the name, not the imperial one, and a year is a Julian year of 365.25 days.
+var Default = newDefault()
+
+// prefix is an SI prefix, e.g. "kilo" in "kilometre" and "km".
+type prefix struct {
+	name    string
+	symbols []string
+	factor  string
+}
+
+var (
+	giga  = prefix{"giga", []string{"G"}, "1e9"}
+	mega  = prefix{"mega", []string{"M"}, "1e6"}
+	kilo  = prefix{"kilo", []string{"k"}, "1e3"}
+	hecto = prefix{"hecto", []string{"h"}, "1e2"}
+	deci  = prefix{"deci", []string{"d"}, "1/10"}
+	centi = prefix{"centi", []string{"c"}, "1/100"}
+	milli = prefix{"milli", []string{"m"}, "1/1000"}
+	micro = prefix{"micro", []string{"µ", "u"}, "1/1000000"}
+	nano  = prefix{"nano", []string{"n"}, "1/1000000000"}
+)
+
+// definition defines a unit of the Default registry, its factor and offset are exact decimals or fractions.
+type definition struct {
+	dimension      Dimension
+	factor, offset string
+	names          []string // the first one is the name of the unit
+	symbols        []string
+	prefixes       []prefix // the prefixed units defined after the unit, e.g. kilo for "kilometre"
+}
+
+var definitions = []definition{
+	// Length
+	{dimension: Length, factor: "1", names: []string{"metre", "meter"}, symbols: []string{"m"},
+		prefixes: []prefix{kilo, centi, milli, micro, nano}},
+	{dimension: Length, factor: "0.0254", names: []string{"inch"}, symbols: []string{"in"}},
+	{dimension: Length, factor: "0.3048", names: []string{"foot", "feet"}, symbols: []string{"ft"}},
+	{dimension: Length, factor: "0.9144", names: []string{"yard"}, symbols: []string{"yd"}},
+	{dimension: Length, factor: "1609.344", names: []string{"mile"}, symbols: []string{"mi"}},
+	{dimension: Length, factor: "1852", names: []string{"nautical mile", "nautical miles"}, symbols: []string{"nmi"}},
+
+	// Mass
+	{dimension: Mass, factor: "1/1000", names: []string{"gram", "gramme"}, symbols: []string{"g"},
+		prefixes: []prefix{kilo, milli, micro}},
+	{dimension: Mass, factor: "1000", names: []string{"tonne", "metric ton", "metric tons"}, symbols: []string{"t"}},
+	{dimension: Mass, factor: "0.028349523125", names: []string{"ounce"}, symbols: []string{"oz"}},
+	{dimension: Mass, factor: "0.45359237", names: []string{"pound"}, symbols: []string{"lb", "lbs"}},
+	{dimension: Mass, factor: "6.35029318", names: []string{"stone"}},
+
+	// Time
+	{dimension: Time, factor: "1", names: []string{"second"}, symbols: []string{"s", "sec"},
+		prefixes: []prefix{milli, micro, nano}},
+	{dimension: Time, factor: "60", names: []string{"minute"}, symbols: []string{"min"}},
+	{dimension: Time, factor: "3600", names: []string{"hour"}, symbols: []string{"h", "hr"}},
+	{dimension: Time, factor: "86400", names: []string{"day"}, symbols: []string{"d"}},
+	{dimension: Time, factor: "604800", names: []string{"week"}, symbols: []string{"wk"}},
+	{dimension: Time, factor: "31557600", names: []string{"year"}, symbols: []string{"yr"}},
+
+	// Other SI base units
+	{dimension: Current, factor: "1", names: []string{"ampere", "amp"}, symbols: []string{"A"},
+		prefixes: []prefix{kilo, milli}},
+	{dimension: Temperature, factor: "1", names: []string{"kelvin"}, symbols: []string{"K"}},
+	{dimension: Temperature, factor: "1", offset: "273.15",
+		names:   []string{"degree Celsius", "degrees Celsius", "celsius", "degree centigrade", "degrees centigrade"},
+		symbols: []string{"°C", "℃"}},
+	{dimension: Temperature, factor: "5/9", offset: "45967/180",
+		names:   []string{"degree Fahrenheit", "degrees Fahrenheit", "fahrenheit"},
+		symbols: []string{"°F", "℉"}},
+	{dimension: Amount, factor: "1", names: []string{"mole"}, symbols: []string{"mol"}, prefixes: []prefix{milli}},
+	{dimension: Luminosity, factor: "1", names: []string{"candela"}, symbols: []string{"cd"}},
+
+	// Area
+	{dimension: Area, factor: "1", names: []string{"square metre", "square metres", "square meter", "square meters"},
+		symbols: []string{"m²", "m^2", "m2", "sq m"}},
+	{dimension: Area, factor: "1000000",
+		names:   []string{"square kilometre", "square kilometres", "square kilometer", "square kilometers"},
+		symbols: []string{"km²", "km^2", "km2", "sq km"}},
+	{dimension: Area, factor: "1/10000",
+		names:   []string{"square centimetre", "square centimetres", "square centimeter", "square centimeters"},
+		symbols: []string{"cm²", "cm^2", "cm2", "sq cm"}},
+	{dimension: Area, factor: "10000", names: []string{"hectare"}, symbols: []string{"ha"}},
+	{dimension: Area, factor: "4046.8564224", names: []string{"acre"}, symbols: []string{"ac"}},
+	{dimension: Area, factor: "0.09290304", names: []string{"square foot", "square feet"},
+		symbols: []string{"ft²", "ft^2", "sq ft"}},
+	{dimension: Area, factor: "2589988.110336", names: []string{"square mile", "square miles"},
+		symbols: []string{"mi²", "mi^2", "sq mi"}},
+
+	// Volume
+	{dimension: Volume, factor: "1", names: []string{"cubic metre", "cubic metres", "cubic meter", "cubic meters"},
+		symbols: []string{"m³", "m^3", "m3"}},
+	{dimension: Volume, factor: "1/1000000",
+		names:   []string{"cubic centimetre", "cubic centimetres", "cubic centimeter", "cubic centimeters"},
+		symbols: []string{"cm³", "cm^3", "cm3", "cc"}},
+	{dimension: Volume, factor: "1/1000", names: []string{"litre", "liter"}, symbols: []string{"L", "l"},
+		prefixes: []prefix{deci, centi, milli}},
+	{dimension: Volume, factor: "0.003785411784", names: []string{"gallon"}, symbols: []string{"gal"}},
+	{dimension: Volume, factor: "0.000946352946", names: []string{"quart"}, symbols: []string{"qt"}},
+	{dimension: Volume, factor: "0.000473176473", names: []string{"pint"}, symbols: []string{"pt"}},
+	{dimension: Volume, factor: "0.0000295735295625", names: []string{"fluid ounce", "fluid ounces"},
+		symbols: []string{"fl oz"}},
+
+	// Speed
+	{dimension: Speed, factor: "1", names: []string{"metre per second", "metres per second", "meter per second",
+		"meters per second"}, symbols: []string{"m/s"}},
+	{dimension: Speed, factor: "5/18", names: []string{"kilometre per hour", "kilometres per hour",
+		"kilometer per hour", "kilometers per hour"}, symbols: []string{"km/h", "kph"}},
+	{dimension: Speed, factor: "0.44704", names: []string{"mile per hour", "miles per hour"}, symbols: []string{"mph"}},
+	{dimension: Speed, factor: "463/900", names: []string{"knot"}, symbols: []string{"kn", "kt"}},
+
+	// Other SI derived units
+	{dimension: Acceleration, factor: "1", symbols: []string{"m/s²", "m/s^2", "m/s2"},
+		names: []string{"metre per second squared", "metres per second squared", "meter per second squared",
+			"meters per second squared"}},
+	{dimension: Force, factor: "1", names: []string{"newton"}, symbols: []string{"N"}, prefixes: []prefix{kilo}},
+	{dimension: Energy, factor: "1", names: []string{"joule"}, symbols: []string{"J"}, prefixes: []prefix{mega, kilo}},
+	{dimension: Energy, factor: "4.184", names: []string{"calorie"}, symbols: []string{"cal"},
+		prefixes: []prefix{kilo}},
+	{dimension: Energy, factor: "3600000", names: []string{"kilowatt hour", "kilowatt hours", "kilowatt-hour",
+		"kilowatt-hours"}, symbols: []string{"kWh"}},
+	{dimension: Energy, factor: "1.602176634e-19", names: []string{"electronvolt", "electron volt",
+		"electron volts"}, symbols: []string{"eV"}},
+	{dimension: Power, factor: "1", names: []string{"watt"}, symbols: []string{"W"},
+		prefixes: []prefix{giga, mega, kilo, milli}},
+	{dimension: Power, factor: "745.69987158227022", names: []string{"horsepower"}, symbols: []string{"hp"}},
+	{dimension: Pressure, factor: "1", names: []string{"pascal"}, symbols: []string{"Pa"},
+		prefixes: []prefix{mega, kilo, hecto}},
+	{dimension: Pressure, factor: "100000", names: []string{"bar"}, symbols: []string{"bar"}, prefixes: []prefix{milli}},
+	{dimension: Pressure, factor: "101325", names: []string{"atmosphere"}, symbols: []string{"atm"}},
+	{dimension: Pressure, factor: "44482216152605/6451600000", names: []string{"pound per square inch",
+		"pounds per square inch"}, symbols: []string{"psi"}},
+	{dimension: Frequency, factor: "1", names: []string{"hertz"}, symbols: []string{"Hz"},
+		prefixes: []prefix{giga, mega, kilo}},
+	{dimension: Voltage, factor: "1", names: []string{"volt"}, symbols: []string{"V"}, prefixes: []prefix{kilo, milli}},
+}
+
+// newDefault creates the Default registry, it panics when the definitions are wrong.
+func newDefault() *Registry {
+	r := NewRegistry()
+	for _, def := range definitions {
+		mustDefine(r, def.dimension, rat(def.factor), def.offset, def.names, def.symbols)
+		for _, p := range def.prefixes {
+			var names, symbols []string
+			for _, name := range def.names {
+				names = append(names, p.name+name)
+			}
+			for _, ps := range p.symbols {
+				for _, symbol := range def.symbols {
+					symbols = append(symbols, ps+symbol)
+				}
+			}
+			factor := new(big.Rat).Mul(rat(def.factor), rat(p.factor))
+			mustDefine(r, def.dimension, factor, def.offset, names, symbols)
+		}
+	}
+	return r
+}
+
+func mustDefine(r *Registry, dimension Dimension, factor *big.Rat, offset string, names, symbols []string) {
+	unit := &Unit{Name: names[0], Dimension: dimension, Factor: factor}
+	if offset != "" {
+		unit.Offset = rat(offset)
+	}
+	if err := r.Define(unit, names[1:], symbols); err != nil {
+		panic(fmt.Sprintf("units: %v", err))
+	}
+}
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic(fmt.Sprintf("units: invalid number %q", s))
+	}
+	return r
+}
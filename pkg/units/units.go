@@ -0,0 +1,241 @@
+// Package units reads the units of measurement written after numbers, e.g. "km" in "5 km", and converts values
+// between units of the same dimension.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrDuplicateUnit     = errors.New("unit name or symbol already defined")
+	ErrInvalidUnit       = errors.New("invalid unit, should have a name and a positive factor")
+	ErrDimensionMismatch = errors.New("units of different dimensions")
+)
+
+// Dimension is a physical dimension, the exponents of the SI base quantities it's the product of, e.g. speed is
+// length per time.
+type Dimension struct {
+	Length, Mass, Time, Current, Temperature, Amount, Luminosity int8
+}
+
+// Dimensions of the units of the Default registry.
+var (
+	Length       = Dimension{Length: 1}
+	Mass         = Dimension{Mass: 1}
+	Time         = Dimension{Time: 1}
+	Current      = Dimension{Current: 1}
+	Temperature  = Dimension{Temperature: 1}
+	Amount       = Dimension{Amount: 1}
+	Luminosity   = Dimension{Luminosity: 1}
+	Area         = Dimension{Length: 2}
+	Volume       = Dimension{Length: 3}
+	Speed        = Dimension{Length: 1, Time: -1}
+	Acceleration = Dimension{Length: 1, Time: -2}
+	Force        = Dimension{Length: 1, Mass: 1, Time: -2}
+	Energy       = Dimension{Length: 2, Mass: 1, Time: -2}
+	Power        = Dimension{Length: 2, Mass: 1, Time: -3}
+	Pressure     = Dimension{Length: -1, Mass: 1, Time: -2}
+	Frequency    = Dimension{Time: -1}
+	Voltage      = Dimension{Length: 2, Mass: 1, Time: -3, Current: -1}
+)
+
+var dimensionNames = map[Dimension]string{
+	{}:           "dimensionless",
+	Length:       "length",
+	Mass:         "mass",
+	Time:         "time",
+	Current:      "electric current",
+	Temperature:  "temperature",
+	Amount:       "amount of substance",
+	Luminosity:   "luminous intensity",
+	Area:         "area",
+	Volume:       "volume",
+	Speed:        "speed",
+	Acceleration: "acceleration",
+	Force:        "force",
+	Energy:       "energy",
+	Power:        "power",
+	Pressure:     "pressure",
+	Frequency:    "frequency",
+	Voltage:      "voltage",
+}
+
+// String returns the name of the dimension, e.g. "speed", or its base quantities when it has no name, e.g.
+// "L^2·T^-1".
+func (d Dimension) String() string {
+	if name, ok := dimensionNames[d]; ok {
+		return name
+	}
+
+	var factors []string
+	for _, f := range []struct {
+		symbol   string
+		exponent int8
+	}{
+		{"L", d.Length}, {"M", d.Mass}, {"T", d.Time}, {"I", d.Current},
+		{"Θ", d.Temperature}, {"N", d.Amount}, {"J", d.Luminosity},
+	} {
+		switch f.exponent {
+		case 0:
+		case 1:
+			factors = append(factors, f.symbol)
+		default:
+			factors = append(factors, fmt.Sprintf("%s^%d", f.symbol, f.exponent))
+		}
+	}
+
+	return strings.Join(factors, "·")
+}
+
+// Unit is a unit of measurement.
+type Unit struct {
+	// Name is the name of the unit in singular, e.g. "kilometre".
+	Name      string
+	Dimension Dimension
+	// Factor and Offset convert a value in the unit to the SI unit of its dimension as value*Factor + Offset,
+	// e.g. 1000 and 0 for the kilometre, 1 and 273.15 for the degree Celsius. Offset may be nil.
+	Factor, Offset *big.Rat
+}
+
+// ToSI converts v in the unit to the SI unit of its dimension, e.g. 5 km is 5000 m.
+func (u *Unit) ToSI(v *big.Rat) *big.Rat {
+	si := new(big.Rat).Mul(v, u.Factor)
+	if u.Offset != nil {
+		si.Add(si, u.Offset)
+	}
+	return si
+}
+
+// FromSI converts v in the SI unit of the dimension of the unit to the unit, e.g. 5000 m is 5 km.
+func (u *Unit) FromSI(v *big.Rat) *big.Rat {
+	value := new(big.Rat).Set(v)
+	if u.Offset != nil {
+		value.Sub(value, u.Offset)
+	}
+	return value.Quo(value, u.Factor)
+}
+
+// maxNameWords is the number of words of the longest name of unit looked up after a number, e.g. "kilometres
+// per hour".
+const maxNameWords = 4
+
+// Registry is a set of units looked up by name or symbol.
+type Registry struct {
+	names   map[string]*Unit
+	symbols map[string]*Unit
+}
+
+// NewRegistry creates an empty registry, see Default for the common units.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]*Unit), symbols: make(map[string]*Unit)}
+}
+
+// Define adds unit to the registry under its name, names and symbols. Names are looked up ignoring case and a
+// name of a single word is also looked up in plural, with "s" added or "es" after s, x, z, ch or sh, other plurals
+// are given among names, e.g. "feet" or "degrees Celsius". Symbols are looked up as written, e.g. "mm" is not
+// "Mm". Returns ErrDuplicateUnit when a name or a symbol is already defined, ErrInvalidUnit when the unit has no
+// name or no positive factor.
+func (r *Registry) Define(unit *Unit, names, symbols []string) error {
+	if unit.Name == "" || unit.Factor == nil || unit.Factor.Sign() <= 0 {
+		return fmt.Errorf("%q: %w", unit.Name, ErrInvalidUnit)
+	}
+
+	names = append([]string{unit.Name}, names...)
+	for i, name := range names {
+		names[i] = strings.ToLower(strings.Join(strings.Fields(name), " "))
+		if _, ok := r.names[names[i]]; ok {
+			return fmt.Errorf("%q: %w", name, ErrDuplicateUnit)
+		}
+	}
+	for _, symbol := range symbols {
+		if _, ok := r.symbols[symbol]; ok {
+			return fmt.Errorf("%q: %w", symbol, ErrDuplicateUnit)
+		}
+	}
+
+	for _, name := range names {
+		r.names[name] = unit
+	}
+	for _, name := range names {
+		if plural := pluralOf(name); plural != "" {
+			if _, ok := r.names[plural]; !ok {
+				r.names[plural] = unit
+			}
+		}
+	}
+	for _, symbol := range symbols {
+		r.symbols[symbol] = unit
+	}
+
+	return nil
+}
+
+// pluralOf returns the regular plural of a name of a single word, "" for a name of several words.
+func pluralOf(name string) string {
+	if strings.Contains(name, " ") {
+		return ""
+	}
+	for _, suffix := range []string{"s", "x", "z", "ch", "sh"} {
+		if strings.HasSuffix(name, suffix) {
+			return name + "es"
+		}
+	}
+	return name + "s"
+}
+
+// Lookup gets the unit of a symbol or a name, ok reports whether there is one. Symbols come first, e.g. "K" is
+// the kelvin even though "k" is no unit.
+func (r *Registry) Lookup(s string) (unit *Unit, ok bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if unit, ok = r.symbols[s]; ok {
+		return unit, true
+	}
+	unit, ok = r.names[strings.ToLower(s)]
+	return unit, ok
+}
+
+// Cut cuts the unit written at the end of s off the rest, ok reports whether s ends with a unit after something
+// else. The unit is either a separate word or words, or the symbol written right after a digit.
+// Examples:
+//
+//   - 5 km -> "5", kilometre
+//   - five kilometres per hour -> "five", kilometre per hour
+//   - -40°C -> "-40", degree Celsius
+//   - 8849 ±10 m -> "8849 ±10", metre
+func (r *Registry) Cut(s string) (before string, unit *Unit, ok bool) {
+	fields := strings.Fields(s)
+	for n := min(maxNameWords, len(fields)-1); n >= 1; n-- {
+		if unit, ok := r.Lookup(strings.Join(fields[len(fields)-n:], " ")); ok {
+			return strings.Join(fields[:len(fields)-n], " "), unit, true
+		}
+	}
+
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	last := fields[len(fields)-1]
+	i := strings.IndexFunc(last, func(r rune) bool {
+		return !unicode.IsDigit(r) && !strings.ContainsRune("+-.,/", r)
+	})
+	if i <= 0 || !strings.ContainsFunc(last[:i], unicode.IsDigit) {
+		return "", nil, false
+	}
+	if unit, ok := r.Lookup(last[i:]); ok {
+		return strings.Join(append(fields[:len(fields)-1:len(fields)-1], last[:i]), " "), unit, true
+	}
+
+	return "", nil, false
+}
+
+// Convert converts v in unit from to unit to, returns error matching ErrDimensionMismatch when they don't measure
+// the same dimension.
+func Convert(v *big.Rat, from, to *Unit) (*big.Rat, error) {
+	if from.Dimension != to.Dimension {
+		return nil, fmt.Errorf("%s to %s: %w", from.Dimension, to.Dimension, ErrDimensionMismatch)
+	}
+	return to.FromSI(from.ToSI(v)), nil
+}
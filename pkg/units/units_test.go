@@ -0,0 +1,150 @@
+package units_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/muktihari/quiz_master/pkg/units"
+)
+
+func TestRegistryCut(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          string
+		ExpectedOk     bool
+		ExpectedBefore string
+		ExpectedUnit   string
+	}{
+		{Name: "symbol", Input: "5 km", ExpectedOk: true, ExpectedBefore: "5", ExpectedUnit: "kilometre"},
+		{Name: "symbol after a digit", Input: "5000m", ExpectedOk: true, ExpectedBefore: "5000", ExpectedUnit: "metre"},
+		{Name: "negative symbol after a digit", Input: "-40°C", ExpectedOk: true, ExpectedBefore: "-40", ExpectedUnit: "degree Celsius"},
+		{Name: "plural name", Input: "five Kilometres", ExpectedOk: true, ExpectedBefore: "five", ExpectedUnit: "kilometre"},
+		{Name: "irregular plural", Input: "6 feet", ExpectedOk: true, ExpectedBefore: "6", ExpectedUnit: "foot"},
+		{Name: "name of several words", Input: "ninety  miles per hour", ExpectedOk: true, ExpectedBefore: "ninety", ExpectedUnit: "mile per hour"},
+		{Name: "symbol of several words", Input: "3 fl oz", ExpectedOk: true, ExpectedBefore: "3", ExpectedUnit: "fluid ounce"},
+		{Name: "tolerance", Input: "8849 ±10 m", ExpectedOk: true, ExpectedBefore: "8849 ±10", ExpectedUnit: "metre"},
+		{Name: "symbols are case sensitive", Input: "5 KM", ExpectedOk: false},
+		{Name: "unit alone", Input: "km", ExpectedOk: false},
+		{Name: "symbol after a word", Input: "Tom", ExpectedOk: false},
+		{Name: "ordinal", Input: "1st", ExpectedOk: false},
+		{Name: "percentage", Input: "50%", ExpectedOk: false},
+		{Name: "text", Input: "21 legs", ExpectedOk: false},
+		{Name: "empty", Input: "", ExpectedOk: false},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			before, unit, ok := units.Default.Cut(tc.Input)
+			if ok != tc.ExpectedOk {
+				t.Fatalf("expected ok: %t, got: %t", tc.ExpectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+			if before != tc.ExpectedBefore || unit.Name != tc.ExpectedUnit {
+				t.Fatalf("expected: %q %s, got: %q %s", tc.ExpectedBefore, tc.ExpectedUnit, before, unit.Name)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tt := []struct {
+		Name        string
+		Value       string
+		From, To    string
+		Expected    string
+		ExpectedErr error
+	}{
+		{Name: "prefixes", Value: "5", From: "km", To: "mm", Expected: "5000000"},
+		{Name: "imperial to SI", Value: "1", From: "mile", To: "metres", Expected: "201168/125"},
+		{Name: "SI to imperial", Value: "100", From: "kg", To: "lb", Expected: "10000000000/45359237"},
+		{Name: "speed", Value: "36", From: "km/h", To: "m/s", Expected: "10"},
+		{Name: "volume", Value: "1", From: "L", To: "cm³", Expected: "1000"},
+		{Name: "celsius to fahrenheit", Value: "100", From: "°C", To: "°F", Expected: "212"},
+		{Name: "fahrenheit to kelvin", Value: "-459.67", From: "degrees Fahrenheit", To: "K", Expected: "0"},
+		{Name: "different dimensions", Value: "5", From: "km", To: "kg", ExpectedErr: units.ErrDimensionMismatch},
+		{Name: "length and area", Value: "5", From: "m", To: "m²", ExpectedErr: units.ErrDimensionMismatch},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			from, ok := units.Default.Lookup(tc.From)
+			if !ok {
+				t.Fatalf("unit %q not found", tc.From)
+			}
+			to, ok := units.Default.Lookup(tc.To)
+			if !ok {
+				t.Fatalf("unit %q not found", tc.To)
+			}
+			value, _ := new(big.Rat).SetString(tc.Value)
+
+			converted, err := units.Convert(value, from, to)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if err != nil {
+				return
+			}
+			if converted.RatString() != tc.Expected {
+				t.Fatalf("expected: %s, got: %s", tc.Expected, converted.RatString())
+			}
+		})
+	}
+}
+
+func TestRegistryDefine(t *testing.T) {
+	r := units.NewRegistry()
+	furlong := &units.Unit{Name: "furlong", Dimension: units.Length, Factor: big.NewRat(201168, 1000)}
+	if err := r.Define(furlong, nil, []string{"fur"}); err != nil {
+		t.Fatal(err)
+	}
+	if unit, ok := r.Lookup("Furlongs"); !ok || unit != furlong {
+		t.Fatalf("expected furlong in plural, got: %v", unit)
+	}
+
+	tt := []struct {
+		Name        string
+		Unit        *units.Unit
+		Symbols     []string
+		ExpectedErr error
+	}{
+		{Name: "name taken", Unit: &units.Unit{Name: "FURLONG", Factor: big.NewRat(1, 1)}, ExpectedErr: units.ErrDuplicateUnit},
+		{Name: "symbol taken", Unit: &units.Unit{Name: "fur coat", Factor: big.NewRat(1, 1)}, Symbols: []string{"fur"}, ExpectedErr: units.ErrDuplicateUnit},
+		{Name: "no name", Unit: &units.Unit{Factor: big.NewRat(1, 1)}, ExpectedErr: units.ErrInvalidUnit},
+		{Name: "no factor", Unit: &units.Unit{Name: "chain"}, ExpectedErr: units.ErrInvalidUnit},
+		{Name: "negative factor", Unit: &units.Unit{Name: "chain", Factor: big.NewRat(-1, 1)}, ExpectedErr: units.ErrInvalidUnit},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := r.Define(tc.Unit, nil, tc.Symbols); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected: %v, got: %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDimensionString(t *testing.T) {
+	tt := []struct {
+		Dimension units.Dimension
+		Expected  string
+	}{
+		{Dimension: units.Speed, Expected: "speed"},
+		{Dimension: units.Dimension{}, Expected: "dimensionless"},
+		{Dimension: units.Dimension{Length: 2, Time: -1}, Expected: "L^2·T^-1"},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Expected, func(t *testing.T) {
+			if s := tc.Dimension.String(); s != tc.Expected {
+				t.Fatalf("expected: %q, got: %q", tc.Expected, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package questionnaire
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/pkg/units"
+)
+
+var (
+	ErrInvalidMatching = errors.New("invalid matching option, should be strict-percent, strict-fraction, strict-zeros, ordinals or roman")
+	ErrWrongDimension  = errors.New("answer of the wrong dimension")
+)
+
+// WrongDimensionError is returned by Answer when the answer is in a unit of another dimension than the answer of
+// the question, e.g. "5 kg" to "5 km", the answer is incorrect.
+type WrongDimensionError struct {
+	ID       int
+	Expected units.Dimension
+	Given    units.Dimension
+}
+
+func (e *WrongDimensionError) Error() string {
+	return fmt.Sprintf("answer measures %s, question %d expects %s", e.Given, e.ID, e.Expected)
+}
+
+func (e *WrongDimensionError) Is(target error) bool {
+	return target == ErrWrongDimension
+}
+
+// Matching options of a question, see Question.Matching. Numeric answers are compared by value, e.g. "0.5",
+// ".50", "1/2", "one half" and "50%" are the same answer, every strict option makes a form of number count on its
+// own.
+const (
+	// MatchStrictPercent makes a percentage only equal to a percentage, e.g. "50%" is not "0.5".
+	MatchStrictPercent = "strict-percent"
+	// MatchStrictFraction makes a fraction only equal to a fraction, e.g. "1/2" is not "0.5".
+	MatchStrictFraction = "strict-fraction"
+	// MatchStrictZeros makes a decimal only equal to a decimal of as many decimals, e.g. "0.50" is not "0.5".
+	MatchStrictZeros = "strict-zeros"
+	// MatchOrdinals makes ordinals read as numbers in text answers, e.g. "3rd" and "third" are "3". It's left out
+	// by default so that words like "second" are kept as they are.
+	MatchOrdinals = "ordinals"
+	// MatchRoman makes Roman numerals read as numbers in text answers, e.g. "III" is "3", with MatchOrdinals
+	// "Henry VIII" is "Henry the Eighth". It's left out by default so that words like "I" or "mix" are kept as they
+	// are.
+	MatchRoman = "roman"
+)
+
+// NormalizeMatching returns the matching options in lower case, sorted and without duplicate, nil when there is
+// none. Returns ErrInvalidMatching when one of them is unknown.
+func NormalizeMatching(options []string) ([]string, error) {
+	normalized := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.ToLower(strings.TrimSpace(option))
+		switch option {
+		case MatchStrictPercent, MatchStrictFraction, MatchStrictZeros, MatchOrdinals, MatchRoman:
+			if !hasTag(normalized, option) {
+				normalized = append(normalized, option)
+				sort.Strings(normalized)
+			}
+		default:
+			return nil, fmt.Errorf("%q: %w", option, ErrInvalidMatching)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+
+	return normalized, nil
+}
+
+// checkAnswer reports whether answer is the answer of question. When the answer of question is a number with a
+// unit of registry, answer is compared by value once both are converted to SI units, e.g. "5 km" is "5000 m" and
+// "five kilometres", an answer without a unit is incorrect and one of another dimension is incorrect with a
+// *WrongDimensionError. Other answers are compared by answerMatches.
+func checkAnswer(registry *units.Registry, question *Question, answer string) (bool, error) {
+	expectedNumber, expectedUnit, ok := registry.Cut(question.Answer)
+	if !ok || !isNumeric(expectedNumber) {
+		return answerMatches(question, answer), nil
+	}
+
+	givenNumber, givenUnit, ok := registry.Cut(answer)
+	if !ok {
+		return false, nil
+	}
+	given, ok := textinput.ParseNumber(givenNumber)
+	if !ok {
+		return false, nil
+	}
+	if givenUnit.Dimension != expectedUnit.Dimension {
+		return false, &WrongDimensionError{ID: question.ID, Expected: expectedUnit.Dimension, Given: givenUnit.Dimension}
+	}
+	given.Value = givenUnit.ToSI(given.Value)
+
+	if interval, ok := textinput.ParseInterval(expectedNumber); ok {
+		interval.Low, interval.High = expectedUnit.ToSI(interval.Low), expectedUnit.ToSI(interval.High)
+		return interval.Contains(given.Value), nil
+	}
+	expected, _ := textinput.ParseNumber(expectedNumber)
+	expected.Value = expectedUnit.ToSI(expected.Value)
+
+	return numbersMatch(expected, given, question.Matching), nil
+}
+
+// isNumeric reports whether s is a number or a number with a tolerance or a range of numbers.
+func isNumeric(s string) bool {
+	if _, ok := textinput.ParseNumber(s); ok {
+		return true
+	}
+	_, ok := textinput.ParseInterval(s)
+	return ok
+}
+
+// answerMatches reports whether answer is the answer of question. An answer written with a tolerance or as a range
+// is matched by any number inside it, the matching options left aside. Numeric answers are compared by value as the
+// matching options of question tell, other answers once their numbers written in words are written in digits, with
+// their ordinals and Roman numerals when the matching options tell, e.g. "3rd" and "third" are 3 with ordinals.
+func answerMatches(question *Question, answer string) bool {
+	if interval, ok := textinput.ParseInterval(question.Answer); ok {
+		given, ok := textinput.ParseNumber(answer)
+		return ok && interval.Contains(given.Value)
+	}
+	if expected, ok := textinput.ParseNumber(question.Answer); ok {
+		if given, ok := textinput.ParseNumber(answer); ok {
+			return numbersMatch(expected, given, question.Matching)
+		}
+	}
+
+	ordinals, roman := hasTag(question.Matching, MatchOrdinals), hasTag(question.Matching, MatchRoman)
+	return textinput.RecognizedAsNumberWithOptions(question.Answer, ordinals, roman) ==
+		textinput.RecognizedAsNumberWithOptions(answer, ordinals, roman)
+}
+
+// numbersMatch reports whether the numbers are equal as the matching options tell.
+func numbersMatch(expected, given textinput.Number, matching []string) bool {
+	switch {
+	case hasTag(matching, MatchStrictPercent) && expected.Percent != given.Percent:
+		return false
+	case hasTag(matching, MatchStrictFraction) && expected.Fraction != given.Fraction:
+		return false
+	case hasTag(matching, MatchStrictZeros) && !expected.Fraction && !given.Fraction &&
+		expected.Decimals != given.Decimals:
+		return false
+	}
+
+	return expected.Value.Cmp(given.Value) == 0
+}
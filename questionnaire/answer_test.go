@@ -0,0 +1,301 @@
+package questionnaire
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/pkg/units"
+)
+
+func TestNormalizeMatching(t *testing.T) {
+	tt := []struct {
+		Options          []string
+		ExpectedMatching []string
+		ExpectedErr      error
+	}{
+		{Options: []string{"Strict-Zeros", " strict-percent", "strict-zeros"}, ExpectedMatching: []string{"strict-percent", "strict-zeros"}},
+		{Options: []string{"Roman", "strict-fraction", "ORDINALS"}, ExpectedMatching: []string{"ordinals", "roman", "strict-fraction"}},
+		{Options: []string{}, ExpectedMatching: nil},
+		{Options: []string{"strict-case"}, ExpectedErr: ErrInvalidMatching},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(strings.Join(tc.Options, ","), func(t *testing.T) {
+			matching, err := NormalizeMatching(tc.Options)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedMatching, matching); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestAnswerMatches(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Answer   string
+		Matching []string
+		Given    []string
+		Expected bool
+	}{
+		{
+			Name:     "forms of a half are equal",
+			Answer:   "0.5",
+			Given:    []string{"0.5", ".50", "1/2", "2/4", "one half", "a half", "50%", "50 percent", "fifty per cent"},
+			Expected: true,
+		},
+		{
+			Name:     "other values are not equal",
+			Answer:   "1/2",
+			Given:    []string{"0.51", "1/3", "5", "50", "-0.5", "half"},
+			Expected: false,
+		},
+		{
+			Name:     "mixed numbers",
+			Answer:   "1.5",
+			Given:    []string{"1 1/2", "one and a half", "3/2", "150%", "1.50"},
+			Expected: true,
+		},
+		{
+			Name:     "thousands separators",
+			Answer:   "1,000",
+			Given:    []string{"1000", "one thousand", "1000.0"},
+			Expected: true,
+		},
+		{
+			Name:     "strict percent keeps percentages apart",
+			Answer:   "50%",
+			Matching: []string{MatchStrictPercent},
+			Given:    []string{"0.5", "1/2"},
+			Expected: false,
+		},
+		{
+			Name:     "strict percent matches percentages",
+			Answer:   "50%",
+			Matching: []string{MatchStrictPercent},
+			Given:    []string{"50 %", "fifty percent", "50.0%"},
+			Expected: true,
+		},
+		{
+			Name:     "strict fraction keeps fractions apart",
+			Answer:   "3/4",
+			Matching: []string{MatchStrictFraction},
+			Given:    []string{"0.75", "75%"},
+			Expected: false,
+		},
+		{
+			Name:     "strict fraction matches fractions",
+			Answer:   "3/4",
+			Matching: []string{MatchStrictFraction},
+			Given:    []string{"6/8", "three quarters"},
+			Expected: true,
+		},
+		{
+			Name:     "strict zeros keeps decimals apart",
+			Answer:   "2.50",
+			Matching: []string{MatchStrictZeros},
+			Given:    []string{"2.5", "2.500"},
+			Expected: false,
+		},
+		{
+			Name:     "strict zeros matches decimals of as many decimals",
+			Answer:   "2.50",
+			Matching: []string{MatchStrictZeros},
+			Given:    []string{"2.50", "+2.50", "5/2"},
+			Expected: true,
+		},
+		{
+			Name:     "numbers within an absolute tolerance",
+			Answer:   "8849 ±10",
+			Given:    []string{"8849", "8839", "8859", "8,850.5", "eight thousand eight hundred and fifty"},
+			Expected: true,
+		},
+		{
+			Name:     "numbers out of an absolute tolerance",
+			Answer:   "8849 +/- 10",
+			Given:    []string{"8838.99", "8860", "8849 ±10", "about 8849"},
+			Expected: false,
+		},
+		{
+			Name:     "numbers within a relative tolerance",
+			Answer:   "two hundred ±5%",
+			Matching: []string{MatchStrictPercent},
+			Given:    []string{"190", "210", "two hundred and five"},
+			Expected: true,
+		},
+		{
+			Name:     "numbers within a range",
+			Answer:   "1/4..0.5",
+			Given:    []string{"0.25", "1/3", "50%", "a quarter"},
+			Expected: true,
+		},
+		{
+			Name:     "numbers out of a range",
+			Answer:   "twenty to thirty",
+			Given:    []string{"19", "thirty-one", "twenty-five years"},
+			Expected: false,
+		},
+		{
+			Name:     "text answers compare numbers written in words",
+			Answer:   "21 legs",
+			Given:    []string{"twenty-one legs"},
+			Expected: true,
+		},
+		{
+			Name:     "ordinals",
+			Answer:   "3rd place",
+			Matching: []string{MatchOrdinals},
+			Given:    []string{"third place", "3RD place", "Third place"},
+			Expected: true,
+		},
+		{
+			Name:     "ordinals are left as is by default",
+			Answer:   "second",
+			Given:    []string{"2", "2nd", "two"},
+			Expected: false,
+		},
+		{
+			Name:     "sentences with ordinals are left as is by default",
+			Answer:   "He came second",
+			Given:    []string{"He came 2", "He came 2nd"},
+			Expected: false,
+		},
+		{
+			Name:     "regnal ordinals",
+			Answer:   "Henry the Eighth",
+			Matching: []string{MatchOrdinals},
+			Given:    []string{"Henry the 8th", "Henry 8th"},
+			Expected: true,
+		},
+		{
+			Name:     "roman numerals are left as is by default",
+			Answer:   "Henry the Eighth",
+			Matching: []string{MatchOrdinals},
+			Given:    []string{"Henry VIII"},
+			Expected: false,
+		},
+		{
+			Name:     "roman numerals",
+			Answer:   "Henry the Eighth",
+			Matching: []string{MatchOrdinals, MatchRoman},
+			Given:    []string{"Henry VIII", "Henry viii"},
+			Expected: true,
+		},
+		{
+			Name:     "roman numerals without ordinals",
+			Answer:   "World War II",
+			Matching: []string{MatchRoman},
+			Given:    []string{"World War 2", "World War two"},
+			Expected: true,
+		},
+		{
+			Name:     "words read as roman numerals",
+			Answer:   "I mix",
+			Matching: []string{MatchRoman},
+			Given:    []string{"1 1009"},
+			Expected: true,
+		},
+		{
+			Name:     "fractions are not ordinals",
+			Answer:   "a third",
+			Matching: []string{MatchOrdinals},
+			Given:    []string{"3", "3rd", "a 3rd"},
+			Expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			question := &Question{Answer: tc.Answer, Matching: tc.Matching}
+			for _, given := range tc.Given {
+				if matched := answerMatches(question, given); matched != tc.Expected {
+					t.Fatalf("%q: expected: %t, got: %t", given, tc.Expected, matched)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAnswer(t *testing.T) {
+	tt := []struct {
+		Name        string
+		Answer      string
+		Matching    []string
+		Given       []string
+		Expected    bool
+		ExpectedErr error
+	}{
+		{
+			Name:     "lengths in any unit are equal",
+			Answer:   "5 km",
+			Given:    []string{"5 km", "5000 m", "5000m", "five kilometres", "5,000 metres", "500000 cm", "5 Kilometers"},
+			Expected: true,
+		},
+		{
+			Name:     "other lengths are not equal",
+			Answer:   "5 km",
+			Given:    []string{"5 m", "5 mi", "5", "five", "5 KM"},
+			Expected: false,
+		},
+		{
+			Name:     "temperatures",
+			Answer:   "100 °C",
+			Given:    []string{"100°C", "212 °F", "373.15 K", "one hundred degrees Celsius"},
+			Expected: true,
+		},
+		{
+			Name:     "imperial units",
+			Answer:   "1 mile",
+			Given:    []string{"1.609344 km", "5280 feet", "1760 yd"},
+			Expected: true,
+		},
+		{
+			Name:     "within a tolerance",
+			Answer:   "8849 ±10 m",
+			Given:    []string{"8.85 km", "8839 metres"},
+			Expected: true,
+		},
+		{
+			Name:     "strict percent and fraction apply to the numbers",
+			Answer:   "1/2 kg",
+			Matching: []string{MatchStrictFraction},
+			Given:    []string{"0.5 kg", "500 g"},
+			Expected: false,
+		},
+		{
+			Name:        "wrong dimension",
+			Answer:      "5 km",
+			Given:       []string{"5 kg", "5 km²", "5 km/h", "five seconds"},
+			Expected:    false,
+			ExpectedErr: ErrWrongDimension,
+		},
+		{
+			Name:     "answers without unit",
+			Answer:   "21 legs",
+			Given:    []string{"twenty-one legs"},
+			Expected: true,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			question := &Question{ID: 1, Answer: tc.Answer, Matching: tc.Matching}
+			for _, given := range tc.Given {
+				correct, err := checkAnswer(units.Default, question, given)
+				if !errors.Is(err, tc.ExpectedErr) {
+					t.Fatalf("%q: %v", given, err)
+				}
+				if correct != tc.Expected {
+					t.Fatalf("%q: expected: %t, got: %t", given, tc.Expected, correct)
+				}
+			}
+		})
+	}
+}
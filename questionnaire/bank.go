@@ -0,0 +1,49 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrBankNotFound       = errors.New("bank not found")
+	ErrBankIsAlreadyExist = errors.New("bank is already exist")
+	ErrInvalidBankName    = errors.New("invalid bank name, should be letters, digits, \"-\" or \"_\"")
+)
+
+// DefaultBank is the bank holding the questions when no other bank is chosen, it always exists.
+const DefaultBank = "default"
+
+// Bank is a named set of questions, question IDs are unique within a bank.
+type Bank struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var bankNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateBankName returns ErrInvalidBankName when name can't be used as a bank name.
+func ValidateBankName(name string) error {
+	if len(name) > 64 || !bankNamePattern.MatchString(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidBankName)
+	}
+	return nil
+}
+
+type bankKey struct{}
+
+// WithBank returns a copy of ctx scoping the methods of Repository and Service called with it to bank.
+func WithBank(ctx context.Context, bank string) context.Context {
+	return context.WithValue(ctx, bankKey{}, bank)
+}
+
+// BankFromContext returns the bank carried by ctx, DefaultBank when there is none.
+func BankFromContext(ctx context.Context) string {
+	if bank, _ := ctx.Value(bankKey{}).(string); bank != "" {
+		return bank
+	}
+	return DefaultBank
+}
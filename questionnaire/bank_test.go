@@ -0,0 +1,116 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepositoryBanks(t *testing.T) {
+	var (
+		at      = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		science = Bank{Name: "science", CreatedAt: at}
+
+		inDefault = Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1}
+		inScience = Question{ID: 1, Question: "How many planets are there in the solar system?", Answer: "8", Version: 1}
+		revision  = Revision{QuestionID: 1, Number: 1, Change: ChangeCreate, At: at, New: &inScience}
+	)
+
+	var (
+		ctx        = context.Background()
+		scienceCtx = WithBank(ctx, science.Name)
+		missingCtx = WithBank(ctx, "history")
+	)
+
+	for name, openRepository := range repositoryOpeners() {
+		dir := t.TempDir()
+		r := openRepository(t, dir)
+
+		bank := science
+		if err := r.CreateBank(ctx, &bank); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.CreateBank(ctx, &bank); !errors.Is(err, ErrBankIsAlreadyExist) {
+			t.Fatalf("%s: expected error: %v, got: %v", name, ErrBankIsAlreadyExist, err)
+		}
+
+		// The same ID is used in both banks.
+		question := Question{ID: 1, Question: inDefault.Question, Answer: inDefault.Answer}
+		if err := r.Create(ctx, &question); err != nil {
+			t.Fatal(err)
+		}
+		question = Question{ID: 1, Question: inScience.Question, Answer: inScience.Answer}
+		if err := r.Create(scienceCtx, &question); err != nil {
+			t.Fatal(err)
+		}
+		question = Question{ID: 2, Question: inScience.Question, Answer: inScience.Answer}
+		if err := r.Create(scienceCtx, &question); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Delete(scienceCtx, 2); err != nil {
+			t.Fatal(err)
+		}
+		added := revision
+		if err := r.AddRevision(scienceCtx, &added); err != nil {
+			t.Fatal(err)
+		}
+
+		question = Question{ID: 1, Question: inScience.Question, Answer: inScience.Answer}
+		if err := r.Create(missingCtx, &question); !errors.Is(err, ErrBankNotFound) {
+			t.Fatalf("%s: expected error: %v, got: %v", name, ErrBankNotFound, err)
+		}
+		if err := r.Update(missingCtx, &question); !errors.Is(err, ErrQuestionNotFound) {
+			t.Fatalf("%s: expected error: %v, got: %v", name, ErrQuestionNotFound, err)
+		}
+
+		errAbort := errors.New("abort")
+		err := r.WithTx(ctx, func(tx Repository) error {
+			if err := tx.CreateBank(ctx, &Bank{Name: "history", CreatedAt: at}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("%s: expected error: %v, got: %v", name, errAbort, err)
+		}
+
+		for _, r := range []Repository{r, openRepository(t, dir)} {
+			banks, err := r.GetBanks(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]Bank{{Name: DefaultBank}, science}, banks); diff != "" {
+				t.Fatalf("%s: %s", name, diff)
+			}
+
+			for _, c := range []struct {
+				ctx       context.Context
+				questions []Question
+				revisions []Revision
+			}{
+				{ctx: ctx, questions: []Question{inDefault}, revisions: []Revision{}},
+				{ctx: scienceCtx, questions: []Question{inScience}, revisions: []Revision{revision}},
+				{ctx: missingCtx, questions: []Question{}, revisions: []Revision{}},
+			} {
+				questions, err := r.GetAll(c.ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(c.questions, questions); diff != "" {
+					t.Fatalf("%s: %s", name, diff)
+				}
+
+				revisions, err := r.GetRevisions(c.ctx, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(c.revisions, revisions); diff != "" {
+					t.Fatalf("%s: %s", name, diff)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package questionnaire
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnsupportedBankFileVersion = errors.New("unsupported bank file version, written by a newer release")
+	ErrUnknownBankFileFormat      = errors.New("unknown bank file format, should be .json, .yaml or .yml")
+)
+
+// BankFileVersion is the version of the bank files written by this release.
+const BankFileVersion = 2
+
+// bankFileUpgrades[v] upgrades a bank file of version v to version v+1. When a field is added to Question, bump
+// BankFileVersion and append the upgrade filling the field for the files written before.
+var bankFileUpgrades = []func(f *BankFile){
+	// 0: the file of the "json" store, its questions may predate versions.
+	func(f *BankFile) {
+		for i := range f.Questions {
+			if f.Questions[i].Version == 0 {
+				f.Questions[i].Version = 1
+			}
+		}
+	},
+	// 1: the file written before matching options, its questions have none, they compare numbers by value.
+	func(f *BankFile) {},
+}
+
+// BankFile is the content of a bank file, a file holding a bank and its questions written in JSON or YAML:
+//
+//	{
+//	  "version": 2,
+//	  "bank": {"name": "science", "created_at": "2024-01-02T03:04:05Z"},
+//	  "questions": [
+//	    {"id": 1, "question": "How many legs does a spider have?", "answer": "8", "version": 3,
+//	     "tags": ["biology", "easy"], "category": "science/biology", "matching": ["strict-zeros"]},
+//	    {"id": 2, "question": "1 + 1?", "answer": "2", "version": 1, "deleted_at": "2024-02-03T04:05:06Z"}
+//	  ]
+//	}
+//
+// version is the version of the format, files of older versions are upgraded when read. bank.created_at is
+// informational and left out for the default bank. A question is written with every field of Question: version
+// is the number of updates it went through, deleted_at is set when it's in trash, tags, category and matching are
+// left out when empty.
+//
+// Files without version are version 0, the files of the "json" store. Only the questions of the default bank
+// are read from them and the questions written before versions existed are upgraded to version 1. Files of version 1
+// are written before matching options existed.
+type BankFile struct {
+	Version   int                `json:"version" yaml:"version"`
+	Bank      BankFileBank       `json:"bank" yaml:"bank"`
+	Questions []BankFileQuestion `json:"questions" yaml:"questions"`
+}
+
+// BankFileBank is the metadata of the bank of a bank file.
+type BankFileBank struct {
+	Name      string     `json:"name" yaml:"name"`
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+}
+
+// BankFileQuestion is a question of a bank file.
+type BankFileQuestion struct {
+	ID        int        `json:"id" yaml:"id"`
+	Question  string     `json:"question" yaml:"question"`
+	Answer    string     `json:"answer" yaml:"answer"`
+	Version   int        `json:"version" yaml:"version"`
+	Tags      []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Category  string     `json:"category,omitempty" yaml:"category,omitempty"`
+	Matching  []string   `json:"matching,omitempty" yaml:"matching,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+}
+
+// BankFileFormat is the encoding of a bank file.
+type BankFileFormat int
+
+const (
+	BankFileJSON BankFileFormat = iota
+	BankFileYAML
+)
+
+// BankFileFormatOf returns the format of the bank file at path, told by its extension.
+func BankFileFormatOf(path string) (BankFileFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return BankFileJSON, nil
+	case ".yaml", ".yml":
+		return BankFileYAML, nil
+	}
+	return 0, fmt.Errorf("%q: %w", path, ErrUnknownBankFileFormat)
+}
+
+// ReadBankFile reads a bank file written in format and upgrades it to BankFileVersion, returns error if any.
+func ReadBankFile(r io.Reader, format BankFileFormat) (*BankFile, error) {
+	var f BankFile
+	switch format {
+	case BankFileJSON:
+		if err := json.NewDecoder(r).Decode(&f); err != nil {
+			return nil, fmt.Errorf("could not decode bank file: %w", err)
+		}
+	case BankFileYAML:
+		if err := yaml.NewDecoder(r).Decode(&f); err != nil {
+			return nil, fmt.Errorf("could not decode bank file: %w", err)
+		}
+	default:
+		return nil, ErrUnknownBankFileFormat
+	}
+
+	if f.Version < 0 || f.Version > BankFileVersion {
+		return nil, fmt.Errorf("version %d: %w", f.Version, ErrUnsupportedBankFileVersion)
+	}
+	for ; f.Version < BankFileVersion; f.Version++ {
+		bankFileUpgrades[f.Version](&f)
+	}
+
+	return &f, nil
+}
+
+// WriteBankFile writes f in format, returns error if any.
+func WriteBankFile(w io.Writer, f *BankFile, format BankFileFormat) error {
+	switch format {
+	case BankFileJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(f)
+	case BankFileYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return ErrUnknownBankFileFormat
+}
+
+// ExportBank returns the bank file of the bank of ctx, holding its questions sorted by ID, trash included.
+func ExportBank(ctx context.Context, qs Service) (*BankFile, error) {
+	bank, err := qs.Bank(ctx, BankFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	questions, err := qs.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trash, err := qs.Trash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	questions = append(questions, trash...)
+	sort.Slice(questions, func(i, j int) bool { return questions[i].ID < questions[j].ID })
+
+	f := &BankFile{
+		Version:   BankFileVersion,
+		Bank:      BankFileBank{Name: bank.Name},
+		Questions: make([]BankFileQuestion, len(questions)),
+	}
+	if !bank.CreatedAt.IsZero() {
+		f.Bank.CreatedAt = &bank.CreatedAt
+	}
+	for i, q := range questions {
+		f.Questions[i] = BankFileQuestion{ID: q.ID, Question: q.Question, Answer: q.Answer, Version: q.Version,
+			Tags: q.Tags, Category: q.Category, Matching: q.Matching, DeletedAt: q.DeletedAt}
+	}
+
+	return f, nil
+}
+
+// ImportBank imports the questions of f as Import does, into the bank named in f which is created when it doesn't
+// exist, or into the bank of ctx when f names none. The versions of the questions aren't kept, the questions are
+// created at version 1. Returns the name of the bank, the report and error if any.
+func ImportBank(ctx context.Context, qs Service, f *BankFile, opts ImportOptions) (string, *ImportReport, error) {
+	name := f.Bank.Name
+	if name == "" {
+		name = BankFromContext(ctx)
+	}
+	ctx = WithBank(ctx, name)
+
+	questions := make([]Question, len(f.Questions))
+	for i, q := range f.Questions {
+		questions[i] = Question{ID: q.ID, Question: q.Question, Answer: q.Answer, Tags: q.Tags, Category: q.Category,
+			Matching: q.Matching, DeletedAt: q.DeletedAt}
+	}
+
+	var report *ImportReport
+	err := withImportTx(ctx, qs, opts, func(tx Service) (err error) {
+		if _, err := tx.Bank(ctx, name); errors.Is(err, ErrBankNotFound) {
+			if err := tx.CreateBank(ctx, &Bank{Name: name}); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		report, err = importQuestions(ctx, tx, questions, opts.Conflict)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, report, nil
+}
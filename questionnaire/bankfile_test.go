@@ -0,0 +1,183 @@
+package questionnaire
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadBankFile(t *testing.T) {
+	tt := []struct {
+		Name         string
+		In           string
+		Format       BankFileFormat
+		ExpectedFile *BankFile
+		ExpectedErr  error
+	}{
+		{
+			Name: "json",
+			In: `{"version": 2, "bank": {"name": "science"}, "questions": [
+				{"id": 1, "question": "1 + 1?", "answer": "2", "version": 3, "tags": ["easy"], "category": "math"}]}`,
+			Format: BankFileJSON,
+			ExpectedFile: &BankFile{Version: 2, Bank: BankFileBank{Name: "science"}, Questions: []BankFileQuestion{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3, Tags: []string{"easy"}, Category: "math"},
+			}},
+		},
+		{
+			Name: "yaml",
+			In: "version: 2\nbank:\n  name: science\nquestions:\n" +
+				"  - id: 1\n    question: 1 + 1?\n    answer: \"2\"\n    version: 3\n    tags: [easy]\n    category: math\n" +
+				"    matching: [strict-zeros]\n",
+			Format: BankFileYAML,
+			ExpectedFile: &BankFile{Version: 2, Bank: BankFileBank{Name: "science"}, Questions: []BankFileQuestion{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3, Tags: []string{"easy"}, Category: "math",
+					Matching: []string{"strict-zeros"}},
+			}},
+		},
+		{
+			Name:   "version 0, file of the json store written before versions",
+			In:     `{"questions": [{"id": 1, "question": "1 + 1?", "answer": "2"}]}`,
+			Format: BankFileJSON,
+			ExpectedFile: &BankFile{Version: 2, Questions: []BankFileQuestion{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+			}},
+		},
+		{
+			Name: "version 1, file written before matching options",
+			In: `{"version": 1, "bank": {"name": "science"}, "questions": [
+				{"id": 1, "question": "1 + 1?", "answer": "2", "version": 3, "category": "math"}]}`,
+			Format: BankFileJSON,
+			ExpectedFile: &BankFile{Version: 2, Bank: BankFileBank{Name: "science"}, Questions: []BankFileQuestion{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3, Category: "math"},
+			}},
+		},
+		{
+			Name:        "newer version",
+			In:          `{"version": 3, "questions": []}`,
+			Format:      BankFileJSON,
+			ExpectedErr: ErrUnsupportedBankFileVersion,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			f, err := ReadBankFile(strings.NewReader(tc.In), tc.Format)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectedErr, err)
+			}
+			if diff := cmp.Diff(tc.ExpectedFile, f); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestBankFileFormatOf(t *testing.T) {
+	for path, expected := range map[string]BankFileFormat{"bank.json": BankFileJSON, "bank.YAML": BankFileYAML, "bank.yml": BankFileYAML} {
+		format, err := BankFileFormatOf(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != expected {
+			t.Fatalf("%s: expected format: %d, got: %d", path, expected, format)
+		}
+	}
+	if _, err := BankFileFormatOf("bank.csv"); !errors.Is(err, ErrUnknownBankFileFormat) {
+		t.Fatalf("expected error: %v, got: %v", ErrUnknownBankFileFormat, err)
+	}
+}
+
+func TestExportImportBank(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		scienceCtx = WithBank(ctx, "science")
+		at         = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		qs         = NewService(NewRepository(), WithClock(func() time.Time { return at }))
+	)
+
+	if err := qs.CreateBank(ctx, &Bank{Name: "science"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy"}, Category: "biology"},
+		{ID: 2, Question: "1 + 1?", Answer: "2"},
+		{ID: 3, Question: "What is H2O?", Answer: "water"},
+	} {
+		if err := qs.Create(scienceCtx, &q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := qs.Update(scienceCtx, &Question{ID: 3, Question: "What is H2O?", Answer: "Water"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.Delete(scienceCtx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &BankFile{
+		Version: BankFileVersion,
+		Bank:    BankFileBank{Name: "science", CreatedAt: &at},
+		Questions: []BankFileQuestion{
+			{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Version: 1, Tags: []string{"easy"}, Category: "biology"},
+			{ID: 2, Question: "1 + 1?", Answer: "2", Version: 1, DeletedAt: &at},
+			{ID: 3, Question: "What is H2O?", Answer: "Water", Version: 2},
+		},
+	}
+
+	f, err := ExportBank(scienceCtx, qs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected, f); diff != "" {
+		t.Fatal(diff)
+	}
+
+	for _, format := range []BankFileFormat{BankFileJSON, BankFileYAML} {
+		var buf bytes.Buffer
+		if err := WriteBankFile(&buf, f, format); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadBankFile(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, read); diff != "" {
+			t.Fatalf("format %d: %s", format, diff)
+		}
+
+		// Imported into a new store, the bank is created.
+		other := NewService(NewRepository(), WithClock(func() time.Time { return at }))
+		name, report, err := ImportBank(ctx, other, read, ImportOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "science" || report.Count(ImportCreated) != 3 {
+			t.Fatalf("expected 3 questions created in bank science, got: %d in bank %s", report.Count(ImportCreated), name)
+		}
+		imported, err := ExportBank(scienceCtx, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedImported := *expected
+		expectedImported.Questions = append([]BankFileQuestion(nil), expected.Questions...)
+		expectedImported.Questions[2].Version = 1 // versions aren't kept
+		if diff := cmp.Diff(&expectedImported, imported); diff != "" {
+			t.Fatalf("format %d: %s", format, diff)
+		}
+	}
+
+	// A dry run creates neither the bank nor the questions.
+	other := NewService(NewRepository())
+	if _, _, err := ImportBank(ctx, other, f, ImportOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Bank(ctx, "science"); !errors.Is(err, ErrBankNotFound) {
+		t.Fatalf("expected error: %v, got: %v", ErrBankNotFound, err)
+	}
+}
@@ -0,0 +1,242 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+// ankiGUIDPrefix starts the GUID of the notes written by WriteAnki, it's followed by "<bank>/<id>".
+const ankiGUIDPrefix = "quiz_master/"
+
+// ankiSeparators are the separators of the "#separator:" header by name.
+var ankiSeparators = map[string]rune{
+	"tab": '\t', "comma": ',', "semicolon": ';', "space": ' ', "pipe": '|', "colon": ':',
+}
+
+// ankiHeaders are the headers of an Anki text file, see https://docs.ankiweb.net/importing/text-files.html.
+type ankiHeaders struct {
+	separator rune
+	html      bool
+	// guid, notetype, deck and tags are the 0-based index of their column, -1 when there is none.
+	guid, notetype, deck, tags int
+}
+
+// ReadAnki reads the notes of an Anki text file, see https://docs.ankiweb.net/importing/text-files.html. The
+// first field of a note is read as the question and the second as the answer. The GUID of a note written by
+// WriteAnki is read as the ID of its question, the question gets an ID when imported otherwise. The first deck of
+// the deck path names the bank and is left out, its subdecks are read as the category, e.g. "science::Human
+// Biology" is "human-biology". Cloze notes and the fields after the answer are reported and left out, HTML is
+// read as plain text. Returns the questions, the issues and error if any.
+func ReadAnki(r io.Reader) ([]questionnaire.Question, []Issue, error) {
+	var (
+		questions []questionnaire.Question
+		issues    issues
+		headers   = ankiHeaders{separator: '\t', guid: -1, notetype: -1, deck: -1, tags: -1}
+		br        = bufio.NewReader(r)
+		offset    int // number of header lines
+	)
+
+	for {
+		line, err := br.Peek(1)
+		if err != nil || line[0] != '#' {
+			break
+		}
+		text, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, err
+		}
+		offset++
+		if err := headers.parse(strings.TrimRight(text, "\r\n")); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", offset, err)
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = headers.separator
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if question, ok := readAnkiNote(record, &headers, offset+line, &issues); ok {
+			questions = append(questions, question)
+		}
+	}
+
+	return questions, issues, nil
+}
+
+// parse parses a header line, e.g. "#deck column:3".
+func (h *ankiHeaders) parse(line string) error {
+	name, value, found := strings.Cut(strings.TrimPrefix(line, "#"), ":")
+	if !found {
+		return nil // a comment
+	}
+
+	column := func() (int, error) {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid %s %q, should be a column number", name, value)
+		}
+		return n - 1, nil
+	}
+
+	var err error
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "separator":
+		if sep, ok := ankiSeparators[strings.ToLower(value)]; ok {
+			h.separator = sep
+		} else if len([]rune(value)) == 1 {
+			h.separator = []rune(value)[0]
+		} else {
+			return fmt.Errorf("invalid separator %q", value)
+		}
+	case "html":
+		h.html, err = strconv.ParseBool(strings.TrimSpace(value))
+	case "guid column":
+		h.guid, err = column()
+	case "notetype column":
+		h.notetype, err = column()
+	case "deck column":
+		h.deck, err = column()
+	case "tags column":
+		h.tags, err = column()
+	}
+
+	return err
+}
+
+// readAnkiNote reads the note of record found at line, ok reports whether it could be mapped.
+func readAnkiNote(record []string, headers *ankiHeaders, line int, issues *issues) (question questionnaire.Question, ok bool) {
+	var (
+		fields []string
+		guid   string
+		deck   string
+		tags   string
+	)
+	for i, field := range record {
+		switch i {
+		case headers.guid:
+			guid = strings.TrimSpace(field)
+		case headers.notetype:
+			if notetype := strings.TrimSpace(field); strings.Contains(strings.ToLower(notetype), "cloze") {
+				issues.add(line, 0, "%s note left out", notetype)
+				return question, false
+			}
+		case headers.deck:
+			deck = strings.TrimSpace(field)
+		case headers.tags:
+			tags = field
+		default:
+			fields = append(fields, field)
+		}
+	}
+
+	if guid != "" {
+		if !strings.HasPrefix(guid, ankiGUIDPrefix) {
+			issues.add(line, 0, "GUID %q left out, only the GUID of an exported question is kept as its number", guid)
+		} else if question.ID = questionID(guid[strings.LastIndex(guid, "/")+1:]); question.ID == 0 {
+			issues.add(line, 0, "GUID %q left out, it has no question number", guid)
+		}
+	}
+
+	if len(fields) < 2 {
+		issues.add(line, question.ID, "note left out, it has no answer")
+		return question, false
+	}
+	question.Question = ankiPlainText(fields[0], headers.html, line, question.ID, issues)
+	question.Answer = ankiPlainText(fields[1], headers.html, line, question.ID, issues)
+	for i, field := range fields[2:] {
+		if strings.TrimSpace(field) != "" {
+			issues.add(line, question.ID, "field %d left out, only the first two fields are kept", i+3)
+		}
+	}
+
+	if _, subdecks, found := strings.Cut(deck, "::"); found {
+		category, err := convertCategory(strings.ReplaceAll(subdecks, "::", "/"))
+		if err != nil {
+			issues.add(line, question.ID, "deck %q left out: %v", deck, err)
+		}
+		question.Category = category
+	}
+
+	for _, tag := range strings.Fields(tags) {
+		normalized, err := questionnaire.NormalizeTag(tag)
+		if err != nil {
+			issues.add(line, question.ID, "tag left out: %v", err)
+			continue
+		}
+		question.Tags = append(question.Tags, normalized)
+	}
+
+	return question, true
+}
+
+var (
+	// htmlLineBreak is the line break of HTML, the end of a paragraph or of a div breaks lines as well.
+	htmlLineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	htmlBlock     = regexp.MustCompile(`(?i)<div>|<p>`)
+)
+
+// ankiPlainText returns field as plain text when it's written in HTML, markup is reported and left out.
+func ankiPlainText(field string, isHTML bool, line, id int, issues *issues) string {
+	if !isHTML {
+		return strings.TrimSpace(field)
+	}
+
+	s := htmlLineBreak.ReplaceAllString(strings.TrimSpace(field), "\n")
+	s = htmlBlock.ReplaceAllString(s, "")
+	if stripped := htmlTag.ReplaceAllString(s, ""); stripped != s {
+		issues.add(line, id, "HTML markup of %q left out", field)
+		s = stripped
+	}
+
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// WriteAnki writes questions in Anki text file format with HTML, one Basic note per question. The GUID of a note
+// is made of the bank and the ID of its question, it keeps the note when it's imported back into Anki and the ID
+// of the question when it's read back by ReadAnki. The deck of a note is the bank and its subdecks the category,
+// e.g. "science::human-biology". Returns error if any.
+func WriteAnki(w io.Writer, questions []questionnaire.Question, bank string) error {
+	var buf bytes.Buffer
+	buf.WriteString("#separator:tab\n#html:true\n")
+	buf.WriteString("#columns:GUID\tNotetype\tDeck\tFront\tBack\tTags\n")
+	buf.WriteString("#guid column:1\n#notetype column:2\n#deck column:3\n#tags column:6\n")
+
+	for _, question := range questions {
+		deck := bank
+		if question.Category != "" {
+			deck += "::" + strings.ReplaceAll(question.Category, "/", "::")
+		}
+		fmt.Fprintf(&buf, "%s%s/%d\tBasic\t%s\t%s\t%s\t%s\n", ankiGUIDPrefix, bank, question.ID, deck,
+			escapeAnki(question.Question), escapeAnki(question.Answer), strings.Join(question.Tags, " "))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// escapeAnki escapes s as an HTML field, it's written without quotes: it has neither quote, tab nor line break.
+func escapeAnki(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\t", "&#9;")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,109 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestReadAnki(t *testing.T) {
+	tt := []struct {
+		Name              string
+		In                string
+		ExpectedQuestions []questionnaire.Question
+		ExpectedIssues    []Issue
+	}{
+		{
+			Name: "notes with headers",
+			In: "#separator:tab\n#html:true\n#guid column:1\n#notetype column:2\n#deck column:3\n#tags column:6\n" +
+				"quiz_master/science/7\tBasic\tscience::Human Biology\tHow many legs does a spider have?\t8\tEasy legs\n" +
+				"quiz_master/science/8\tBasic\tscience\t<div>1 &lt; 2?</div><div>Or not?</div>\ttrue\t\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{ID: 7, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy", "legs"}, Category: "human-biology"},
+				{ID: 8, Question: "1 < 2?\nOr not?", Answer: "true"},
+			},
+		},
+		{
+			Name: "plain text notes without headers",
+			In:   "What is H2O?\twater\n\"Tab\tin a quoted field\"\t<b>bold</b>\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{Question: "What is H2O?", Answer: "water"},
+				{Question: "Tab\tin a quoted field", Answer: "<b>bold</b>"},
+			},
+		},
+		{
+			Name: "unmapped constructs are reported",
+			In: "#separator:Semicolon\n#html:true\n#guid column:1\n#notetype column:2\n#tags column:5\n" +
+				"a1b2c3;Basic;What is <b>H2O</b>?;water;chemistry::molecules\n" +
+				"d4e5f6;Cloze;{{c1::Water}} is H2O;;\n" +
+				"quiz_master/science/x;Basic (and reversed card);Capital of France?;Paris;\n" +
+				"g7h8i9;Basic;Question only\n" +
+				"j0k1l2;Basic;1 + 1?;2;;extra\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{Question: "What is H2O?", Answer: "water"},
+				{Question: "Capital of France?", Answer: "Paris"},
+				{Question: "1 + 1?", Answer: "2"},
+			},
+			ExpectedIssues: []Issue{
+				{Line: 6, Message: `GUID "a1b2c3" left out, only the GUID of an exported question is kept as its number`},
+				{Line: 6, Message: `HTML markup of "What is <b>H2O</b>?" left out`},
+				{Line: 6, Message: `tag left out: "chemistry::molecules": invalid tag, should be letters, digits, "-" or "_" and not AND, OR or NOT`},
+				{Line: 7, Message: "Cloze note left out"},
+				{Line: 8, Message: `GUID "quiz_master/science/x" left out, it has no question number`},
+				{Line: 9, Message: `GUID "g7h8i9" left out, only the GUID of an exported question is kept as its number`},
+				{Line: 9, Message: "note left out, it has no answer"},
+				{Line: 10, Message: `GUID "j0k1l2" left out, only the GUID of an exported question is kept as its number`},
+				{Line: 10, Message: "field 3 left out, only the first two fields are kept"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			questions, issues, err := ReadAnki(strings.NewReader(tc.In))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.ExpectedIssues, issues); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWriteAnki(t *testing.T) {
+	questions := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy", "legs"}, Category: "biology/animals"},
+		{ID: 2, Question: "Is 1 < 2?\nSay \"true\"\tor \"false\".", Answer: "true"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAnki(&buf, questions, "science"); err != nil {
+		t.Fatal(err)
+	}
+	expected := "#separator:tab\n#html:true\n#columns:GUID\tNotetype\tDeck\tFront\tBack\tTags\n" +
+		"#guid column:1\n#notetype column:2\n#deck column:3\n#tags column:6\n" +
+		"quiz_master/science/1\tBasic\tscience::biology::animals\tHow many legs does a spider have?\t8\teasy legs\n" +
+		"quiz_master/science/2\tBasic\tscience\tIs 1 &lt; 2?<br>Say &#34;true&#34;&#9;or &#34;false&#34;.\ttrue\t\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	read, issues, err := ReadAnki(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(questions, read); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got: %v", issues)
+	}
+}
@@ -0,0 +1,187 @@
+// Package convert converts questions from and to the formats of other quiz tools.
+//
+// A construct of a format that can't be mapped to questionnaire.Question is reported as an Issue, the question
+// is kept when only part of it is lost, e.g. a feedback, and left out when its answer can't be mapped, e.g. a
+// multiple choice question.
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/pkg/units"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+var ErrUnknownMoodleFormat = errors.New("unknown Moodle format, should be .gift, .txt or .xml")
+
+// MoodleFormat is a format Moodle imports and exports questions in.
+type MoodleFormat int
+
+const (
+	MoodleGIFT MoodleFormat = iota
+	MoodleXML
+)
+
+// MoodleFormatOf returns the Moodle format of the file at path, told by its extension.
+func MoodleFormatOf(path string) (MoodleFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gift", ".txt":
+		return MoodleGIFT, nil
+	case ".xml":
+		return MoodleXML, nil
+	}
+	return 0, fmt.Errorf("%q: %w", path, ErrUnknownMoodleFormat)
+}
+
+// ReadMoodle reads questions written in format, see ReadGIFT and ReadMoodleXML.
+func ReadMoodle(r io.Reader, format MoodleFormat) ([]questionnaire.Question, []Issue, error) {
+	switch format {
+	case MoodleGIFT:
+		return ReadGIFT(r)
+	case MoodleXML:
+		return ReadMoodleXML(r)
+	}
+	return nil, nil, ErrUnknownMoodleFormat
+}
+
+// WriteMoodle writes questions in format, see WriteGIFT and WriteMoodleXML.
+func WriteMoodle(w io.Writer, questions []questionnaire.Question, format MoodleFormat) ([]Issue, error) {
+	switch format {
+	case MoodleGIFT:
+		return WriteGIFT(w, questions)
+	case MoodleXML:
+		return nil, WriteMoodleXML(w, questions)
+	}
+	return nil, ErrUnknownMoodleFormat
+}
+
+// Issue is a construct that couldn't be converted.
+type Issue struct {
+	// Line is the line of the construct in the source, 0 when converting from questions.
+	Line int
+	// ID is the ID of the question, 0 when it's not known.
+	ID      int
+	Message string
+}
+
+func (i Issue) String() string {
+	switch {
+	case i.Line > 0:
+		return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+	case i.ID != 0:
+		return fmt.Sprintf("question [%d]: %s", i.ID, i.Message)
+	}
+	return i.Message
+}
+
+// issues collects the issues of a conversion.
+type issues []Issue
+
+func (is *issues) add(line, id int, format string, args ...any) {
+	*is = append(*is, Issue{Line: line, ID: id, Message: fmt.Sprintf(format, args...)})
+}
+
+// Answers of true/false questions.
+const (
+	answerTrue  = "true"
+	answerFalse = "false"
+)
+
+// questionKind is how a question is written in formats telling the kind of question apart.
+type questionKind int
+
+const (
+	kindShortAnswer questionKind = iota
+	kindNumerical
+	kindTrueFalse
+)
+
+// kindOf tells the kind of question from its answer: numerical when it's a decimal number or a number with a
+// tolerance or a range, see textinput.ParseInterval, true/false when it's "true" or "false" and short-answer
+// otherwise.
+func kindOf(question *questionnaire.Question) questionKind {
+	answer := strings.TrimSpace(question.Answer)
+	if isNumber(answer) {
+		return kindNumerical
+	}
+	if _, ok := textinput.ParseInterval(answer); ok {
+		return kindNumerical
+	}
+	if strings.EqualFold(answer, answerTrue) || strings.EqualFold(answer, answerFalse) {
+		return kindTrueFalse
+	}
+	return kindShortAnswer
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// isQuantity reports whether s is a number followed by a unit of units.Default, e.g. "5 km" or "8849 ±10 m".
+func isQuantity(s string) bool {
+	before, _, ok := units.Default.Cut(s)
+	if !ok {
+		return false
+	}
+	if _, ok := textinput.ParseNumber(before); ok {
+		return true
+	}
+	_, ok = textinput.ParseInterval(before)
+	return ok
+}
+
+// withTolerance returns number written with tolerance as read by textinput.ParseInterval, number alone when the
+// tolerance is empty or 0.
+func withTolerance(number, tolerance string) string {
+	if t, err := strconv.ParseFloat(tolerance, 64); err != nil || t == 0 {
+		return number
+	}
+	return number + " ±" + tolerance
+}
+
+// decimalString returns r written as a decimal, rounded to 6 decimals.
+func decimalString(r *big.Rat) string {
+	s := r.FloatString(6)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// moodleContexts are the first names of the category paths of Moodle, they tell where the category is shared.
+var moodleContexts = map[string]bool{"$system$": true, "$course$": true, "$module$": true, "$coursecategory$": true}
+
+// convertCategory returns the normalized category of a Moodle category path, e.g. "$course$/top/Human Biology" is
+// "human-biology". The context and the top category are left out, spaces are replaced by "-".
+func convertCategory(path string) (string, error) {
+	names := strings.Split(path, "/")
+	if len(names) > 0 && moodleContexts[strings.ToLower(strings.TrimSpace(names[0]))] {
+		names = names[1:]
+	}
+	if len(names) > 0 && strings.EqualFold(strings.TrimSpace(names[0]), "top") {
+		names = names[1:]
+	}
+	for i := range names {
+		names[i] = strings.Join(strings.Fields(names[i]), "-")
+	}
+
+	return questionnaire.NormalizeCategory(strings.Join(names, "/"))
+}
+
+// questionID returns the ID written as the name of a question, 0 when the name isn't an ID.
+func questionID(name string) int {
+	id, err := strconv.Atoi(strings.TrimSpace(name))
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
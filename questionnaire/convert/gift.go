@@ -0,0 +1,359 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+// giftSpecial are the characters of GIFT escaped with a backslash in text.
+const giftSpecial = `~=#{}:\`
+
+// giftBlank replaces the answer of a missing word question, e.g. "The sky is {=blue} today."
+const giftBlank = "_____"
+
+// ReadGIFT reads the questions of GIFT text, see https://docs.moodle.org/en/GIFT_format. The name of a question is
+// read as its ID when it's a positive integer, the question gets an ID when imported otherwise. Short-answer,
+// numerical and true/false questions are read, the others are reported and left out. Returns the questions, the
+// issues and error if any.
+func ReadGIFT(r io.Reader) ([]questionnaire.Question, []Issue, error) {
+	var (
+		questions []questionnaire.Question
+		issues    issues
+		category  string
+		block     []string
+		start     int
+	)
+
+	flush := func() {
+		if len(block) > 0 {
+			if question, ok := readGIFTQuestion(strings.Join(block, "\n"), start, &issues); ok {
+				question.Category = category
+				questions = append(questions, question)
+			}
+		}
+		block = block[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(text, "//"):
+			continue
+		case text == "":
+			flush()
+			continue
+		case len(block) == 0 && strings.HasPrefix(text, "$CATEGORY:"):
+			path := strings.TrimSpace(strings.TrimPrefix(text, "$CATEGORY:"))
+			var err error
+			if category, err = convertCategory(path); err != nil {
+				issues.add(line, 0, "category %q left out: %v", path, err)
+			}
+			continue
+		}
+		if len(block) == 0 {
+			start = line
+		}
+		block = append(block, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	flush()
+
+	return questions, issues, nil
+}
+
+// readGIFTQuestion reads the question written in text starting at line, ok reports whether it could be mapped.
+func readGIFTQuestion(text string, line int, issues *issues) (question questionnaire.Question, ok bool) {
+	if strings.HasPrefix(text, "::") {
+		end := indexUnescaped(text[2:], "::")
+		if end < 0 {
+			issues.add(line, 0, "question left out, its name isn't closed by \"::\"")
+			return question, false
+		}
+		name := strings.TrimSpace(unescapeGIFT(text[2 : 2+end]))
+		if question.ID = questionID(name); question.ID == 0 {
+			issues.add(line, 0, "name %q left out, only a number is kept as the question number", name)
+		}
+		text = strings.TrimSpace(text[2+end+2:])
+	}
+
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "]"); end > 0 {
+			switch format := strings.ToLower(text[1:end]); format {
+			case "moodle", "plain":
+			case "html", "markdown":
+				issues.add(line, question.ID, "%s text format left out, the text is kept as is", format)
+			default:
+				end = -1
+			}
+			if end > 0 {
+				text = strings.TrimSpace(text[end+1:])
+			}
+		}
+	}
+
+	open := indexUnescaped(text, "{")
+	if open < 0 {
+		issues.add(line, question.ID, "description left out, it has no answer")
+		return question, false
+	}
+	closing := indexUnescaped(text[open:], "}")
+	if closing < 0 {
+		issues.add(line, question.ID, "question left out, its answer isn't closed by \"}\"")
+		return question, false
+	}
+	closing += open
+
+	question.Question = strings.TrimSpace(unescapeGIFT(text[:open]))
+	if after := strings.TrimSpace(unescapeGIFT(text[closing+1:])); after != "" {
+		question.Question = strings.TrimSpace(question.Question + " " + giftBlank + " " + after)
+	}
+
+	answer, ok := readGIFTAnswer(strings.TrimSpace(text[open+1:closing]), line, question.ID, issues)
+	if !ok {
+		return question, false
+	}
+	question.Answer = answer
+
+	return question, true
+}
+
+// readGIFTAnswer reads the answer of a question written in s, the text between braces.
+func readGIFTAnswer(s string, line, id int, issues *issues) (answer string, ok bool) {
+	if s == "" {
+		issues.add(line, id, "essay question left out, it has no answer")
+		return "", false
+	}
+
+	if value, feedback := cutFeedback(s); isGIFTTrueFalse(value) {
+		if feedback != "" {
+			issues.add(line, id, "feedback left out")
+		}
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(value)), "T") {
+			return answerTrue, true
+		}
+		return answerFalse, true
+	}
+
+	numerical := strings.HasPrefix(s, "#")
+	if numerical {
+		s = strings.TrimSpace(s[1:])
+		if !strings.HasPrefix(s, "=") {
+			s = "=" + s
+		}
+	}
+
+	choices := splitGIFTChoices(s)
+	if len(choices) == 0 {
+		issues.add(line, id, "question left out, its answer %q can't be read", s)
+		return "", false
+	}
+
+	var answers []string
+	for _, choice := range choices {
+		if choice[0] == '~' {
+			issues.add(line, id, "multiple choice question left out")
+			return "", false
+		}
+		value, feedback := cutFeedback(choice[1:])
+		if indexUnescaped(value, "->") >= 0 {
+			issues.add(line, id, "matching question left out")
+			return "", false
+		}
+		if feedback != "" {
+			issues.add(line, id, "feedback left out")
+		}
+
+		weight := 100
+		if strings.HasPrefix(value, "%") {
+			if end := strings.Index(value[1:], "%"); end >= 0 {
+				weight, _ = strconv.Atoi(value[1 : 1+end])
+				value = value[1+end+1:]
+			}
+		}
+		value = strings.TrimSpace(unescapeGIFT(value))
+		if weight != 100 {
+			issues.add(line, id, "answer %q worth %d%% left out, only fully correct answers are kept", value, weight)
+			continue
+		}
+
+		if numerical {
+			if low, high, found := strings.Cut(value, ".."); found {
+				low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+				if !isNumber(low) || !isNumber(high) {
+					issues.add(line, id, "numerical question left out, its answer %q isn't a range of numbers", value)
+					return "", false
+				}
+				answers = append(answers, low+".."+high)
+				continue
+			}
+			number, tolerance, found := strings.Cut(value, ":")
+			number, tolerance = strings.TrimSpace(number), strings.TrimSpace(tolerance)
+			if !isNumber(number) || (found && !isNumber(tolerance)) {
+				issues.add(line, id, "numerical question left out, its answer %q isn't a number", value)
+				return "", false
+			}
+			value = withTolerance(number, tolerance)
+		}
+		answers = append(answers, value)
+	}
+
+	if len(answers) == 0 {
+		issues.add(line, id, "question left out, it has no fully correct answer")
+		return "", false
+	}
+	for _, alternative := range answers[1:] {
+		issues.add(line, id, "alternative answer %q left out, only the first correct answer is kept", alternative)
+	}
+
+	return answers[0], true
+}
+
+func isGIFTTrueFalse(s string) bool {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "T", "TRUE", "F", "FALSE":
+		return true
+	}
+	return false
+}
+
+// splitGIFTChoices splits s into the choices starting with an unescaped "=" or "~", e.g. "=a =b ~c" is
+// ["=a ", "=b ", "~c"]. It returns nil when s doesn't start with a choice.
+func splitGIFTChoices(s string) []string {
+	var (
+		choices []string
+		start   = -1
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if start < 0 && strings.TrimSpace(s[:i]) != "" {
+				return nil
+			}
+			if start >= 0 {
+				choices = append(choices, s[start:i])
+			}
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	return append(choices, s[start:])
+}
+
+// cutFeedback cuts s around its first unescaped "#", the feedback of an answer.
+func cutFeedback(s string) (value, feedback string) {
+	if i := indexUnescaped(s, "#"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
+}
+
+// indexUnescaped returns the index of the first instance of substr in s not escaped by a backslash, or -1.
+func indexUnescaped(s, substr string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeGIFT(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch c := s[i+1]; {
+			case c == 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case strings.IndexByte(giftSpecial, c) >= 0:
+				sb.WriteByte(c)
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func escapeGIFT(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case strings.ContainsRune(giftSpecial, c):
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// WriteGIFT writes questions in GIFT, a question is named after its ID and written as a numerical question when
+// its answer is a number, a true/false question when its answer is "true" or "false" and a short-answer question
+// otherwise. Tags are reported, GIFT has none. Returns the issues and error if any.
+func WriteGIFT(w io.Writer, questions []questionnaire.Question) ([]Issue, error) {
+	var (
+		issues   issues
+		bw       = bufio.NewWriter(w)
+		category = ""
+	)
+
+	for _, question := range questions {
+		if question.Category != category {
+			fmt.Fprintf(bw, "$CATEGORY: $course$/top/%s\n\n", question.Category)
+			category = question.Category
+		}
+		if len(question.Tags) > 0 {
+			issues.add(0, question.ID, "tags %s left out, GIFT has no tags", strings.Join(question.Tags, ", "))
+		}
+		if len(question.Matching) > 0 {
+			issues.add(0, question.ID, "matching options %s left out, GIFT has no matching options",
+				strings.Join(question.Matching, ", "))
+		}
+
+		var answer string
+		switch kindOf(&question) {
+		case kindNumerical:
+			answer = "#" + strings.TrimSpace(question.Answer)
+			if interval, ok := textinput.ParseInterval(question.Answer); ok && interval.Target != nil {
+				answer = "#" + decimalString(interval.Target) + ":" + decimalString(interval.Tolerance)
+			} else if ok {
+				answer = "#" + decimalString(interval.Low) + ".." + decimalString(interval.High)
+			}
+		case kindTrueFalse:
+			answer = "F"
+			if strings.EqualFold(strings.TrimSpace(question.Answer), answerTrue) {
+				answer = "T"
+			}
+		default:
+			answer = "=" + escapeGIFT(question.Answer)
+		}
+		fmt.Fprintf(bw, "::%d:: %s {%s}\n\n", question.ID, escapeGIFT(question.Question), answer)
+	}
+
+	return issues, bw.Flush()
+}
@@ -0,0 +1,142 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestReadGIFT(t *testing.T) {
+	tt := []struct {
+		Name              string
+		In                string
+		ExpectedQuestions []questionnaire.Question
+		ExpectedIssues    []Issue
+	}{
+		{
+			Name: "short-answer, numerical and true/false questions",
+			In: "// comment\n" +
+				"$CATEGORY: $course$/top/Human Biology\n\n" +
+				"::1:: How many legs does a spider have? {#8}\n\n" +
+				"::2::[plain] What is H2O?\n{=water}\n\n" +
+				"The sun rises in the east.{T}\n\n" +
+				"::4:: What is 3 \\{ 4\\}? {=a\\=b}\n\n" +
+				"::5:: Pick a number. {#1..5}\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Category: "human-biology"},
+				{ID: 2, Question: "What is H2O?", Answer: "water", Category: "human-biology"},
+				{Question: "The sun rises in the east.", Answer: "true", Category: "human-biology"},
+				{ID: 4, Question: "What is 3 { 4}?", Answer: "a=b", Category: "human-biology"},
+				{ID: 5, Question: "Pick a number.", Answer: "1..5", Category: "human-biology"},
+			},
+		},
+		{
+			Name: "missing word",
+			In:   "::1:: The sky is {=blue} today.\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{ID: 1, Question: "The sky is _____ today.", Answer: "blue"},
+			},
+		},
+		{
+			Name: "partly mapped constructs are reported",
+			In: "::1:: What is pi? {#3.14:0.01}\n\n" +
+				"::2:: Name a primary color. {=red =blue#Right! =%50%purple}\n\n" +
+				"::Sky:: Is the sky green? {F#No}\n",
+			ExpectedQuestions: []questionnaire.Question{
+				{ID: 1, Question: "What is pi?", Answer: "3.14 ±0.01"},
+				{ID: 2, Question: "Name a primary color.", Answer: "red"},
+				{Question: "Is the sky green?", Answer: "false"},
+			},
+			ExpectedIssues: []Issue{
+				{Line: 3, ID: 2, Message: "feedback left out"},
+				{Line: 3, ID: 2, Message: `answer "purple" worth 50% left out, only fully correct answers are kept`},
+				{Line: 3, ID: 2, Message: `alternative answer "blue" left out, only the first correct answer is kept`},
+				{Line: 5, Message: `name "Sky" left out, only a number is kept as the question number`},
+				{Line: 5, Message: "feedback left out"},
+			},
+		},
+		{
+			Name: "unmapped questions are reported",
+			In: "::1:: Which is a fruit? {~carrot =apple}\n\n" +
+				"::2:: Match. {=cat -> meow =dog -> woof}\n\n" +
+				"::3:: Write an essay. {}\n\n" +
+				"::4:: Pick a number. {#one..5}\n\n" +
+				"Just a description.\n\n" +
+				"$CATEGORY: $course$/top/a b/c\n",
+			ExpectedIssues: []Issue{
+				{Line: 1, ID: 1, Message: "multiple choice question left out"},
+				{Line: 3, ID: 2, Message: "matching question left out"},
+				{Line: 5, ID: 3, Message: "essay question left out, it has no answer"},
+				{Line: 7, ID: 4, Message: `numerical question left out, its answer "one..5" isn't a range of numbers`},
+				{Line: 9, Message: "description left out, it has no answer"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			questions, issues, err := ReadGIFT(strings.NewReader(tc.In))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.ExpectedIssues, issues); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWriteGIFT(t *testing.T) {
+	questions := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy"}, Category: "biology"},
+		{ID: 2, Question: "Is water {wet}?", Answer: "True", Category: "biology"},
+		{ID: 3, Question: "What is H2O?", Answer: "water = H2O", Matching: []string{"strict-zeros"}},
+		{ID: 4, Question: "How tall is Everest in metres?", Answer: "eight thousand eight hundred forty-nine ±10"},
+		{ID: 5, Question: "How old?", Answer: "twenty to thirty"},
+	}
+
+	var buf bytes.Buffer
+	issues, err := WriteGIFT(&buf, questions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "$CATEGORY: $course$/top/biology\n\n" +
+		"::1:: How many legs does a spider have? {#8}\n\n" +
+		"::2:: Is water \\{wet\\}? {T}\n\n" +
+		"$CATEGORY: $course$/top/\n\n" +
+		"::3:: What is H2O? {=water \\= H2O}\n\n" +
+		"::4:: How tall is Everest in metres? {#8849:10}\n\n" +
+		"::5:: How old? {#20..30}\n\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+	expectedIssues := []Issue{
+		{ID: 1, Message: "tags easy left out, GIFT has no tags"},
+		{ID: 3, Message: "matching options strict-zeros left out, GIFT has no matching options"},
+	}
+	if diff := cmp.Diff(expectedIssues, issues); diff != "" {
+		t.Fatal(diff)
+	}
+
+	read, _, err := ReadGIFT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRead := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Category: "biology"},
+		{ID: 2, Question: "Is water {wet}?", Answer: "true", Category: "biology"},
+		{ID: 3, Question: "What is H2O?", Answer: "water = H2O"},
+		{ID: 4, Question: "How tall is Everest in metres?", Answer: "8849 ±10"},
+		{ID: 5, Question: "How old?", Answer: "20..30"},
+	}
+	if diff := cmp.Diff(expectedRead, read); diff != "" {
+		t.Fatal(diff)
+	}
+}
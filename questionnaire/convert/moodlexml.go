@@ -0,0 +1,297 @@
+package convert
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+// Moodle XML question types, see https://docs.moodle.org/en/Moodle_XML_format.
+const (
+	moodleCategory    = "category"
+	moodleShortAnswer = "shortanswer"
+	moodleNumerical   = "numerical"
+	moodleTrueFalse   = "truefalse"
+)
+
+type moodleQuiz struct {
+	XMLName   xml.Name         `xml:"quiz"`
+	Questions []moodleQuestion `xml:"question"`
+}
+
+type moodleQuestion struct {
+	Type            string         `xml:"type,attr"`
+	Category        *moodleText    `xml:"category"`
+	Name            *moodleText    `xml:"name"`
+	QuestionText    *moodleText    `xml:"questiontext"`
+	GeneralFeedback *moodleText    `xml:"generalfeedback"`
+	UseCase         *int           `xml:"usecase"`
+	Answers         []moodleAnswer `xml:"answer"`
+	Units           *struct{}      `xml:"units"`
+	Tags            *moodleTags    `xml:"tags"`
+}
+
+type moodleText struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+type moodleAnswer struct {
+	Fraction  string      `xml:"fraction,attr"`
+	Format    string      `xml:"format,attr,omitempty"`
+	Text      string      `xml:"text"`
+	Feedback  *moodleText `xml:"feedback"`
+	Tolerance string      `xml:"tolerance,omitempty"`
+}
+
+type moodleTags struct {
+	Tags []moodleText `xml:"tag"`
+}
+
+// ReadMoodleXML reads the questions of Moodle XML, see https://docs.moodle.org/en/Moodle_XML_format. The name of a
+// question is read as its ID when it's a positive integer, the question gets an ID when imported otherwise.
+// Short-answer, numerical and true/false questions are read, the others are reported and left out. HTML text is
+// read as plain text. Returns the questions, the issues and error if any.
+func ReadMoodleXML(r io.Reader) ([]questionnaire.Question, []Issue, error) {
+	var (
+		questions []questionnaire.Question
+		issues    issues
+		category  string
+		dec       = xml.NewDecoder(r)
+	)
+
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "question" {
+			continue
+		}
+
+		line, _ := dec.InputPos()
+		var mq moodleQuestion
+		if err := dec.DecodeElement(&mq, &start); err != nil {
+			return nil, nil, err
+		}
+
+		if mq.Type == moodleCategory {
+			if mq.Category == nil {
+				continue
+			}
+			path := strings.TrimSpace(mq.Category.Text)
+			if category, err = convertCategory(path); err != nil {
+				issues.add(line, 0, "category %q left out: %v", path, err)
+			}
+			continue
+		}
+
+		if question, ok := readMoodleQuestion(&mq, line, &issues); ok {
+			question.Category = category
+			questions = append(questions, question)
+		}
+	}
+
+	return questions, issues, nil
+}
+
+// readMoodleQuestion reads mq found at line, ok reports whether it could be mapped.
+func readMoodleQuestion(mq *moodleQuestion, line int, issues *issues) (question questionnaire.Question, ok bool) {
+	if mq.Name != nil {
+		name := strings.TrimSpace(mq.Name.Text)
+		if question.ID = questionID(name); question.ID == 0 && name != "" {
+			issues.add(line, 0, "name %q left out, only a number is kept as the question number", name)
+		}
+	}
+
+	switch mq.Type {
+	case moodleShortAnswer, moodleNumerical, moodleTrueFalse:
+	default:
+		issues.add(line, question.ID, "%s question left out", mq.Type)
+		return question, false
+	}
+
+	if mq.QuestionText != nil {
+		question.Question = moodlePlainText(mq.QuestionText, line, question.ID, issues)
+	}
+	if mq.GeneralFeedback != nil && strings.TrimSpace(mq.GeneralFeedback.Text) != "" {
+		issues.add(line, question.ID, "general feedback left out")
+	}
+	if mq.UseCase != nil && *mq.UseCase == 1 {
+		issues.add(line, question.ID, "case sensitivity left out, answers are matched ignoring case")
+	}
+	if mq.Units != nil {
+		issues.add(line, question.ID, "units left out")
+	}
+
+	var answers []string
+	for _, answer := range mq.Answers {
+		value := strings.TrimSpace(moodlePlainText(&moodleText{Format: answer.Format, Text: answer.Text}, line, question.ID, issues))
+		if answer.Feedback != nil && strings.TrimSpace(answer.Feedback.Text) != "" {
+			issues.add(line, question.ID, "feedback of answer %q left out", value)
+		}
+
+		fraction, err := strconv.ParseFloat(strings.TrimSpace(answer.Fraction), 64)
+		if err != nil {
+			issues.add(line, question.ID, "answer %q left out, its fraction %q isn't a number", value, answer.Fraction)
+			continue
+		}
+		if fraction != 100 {
+			// The wrong answer of a true/false question is implied by the right one.
+			if mq.Type != moodleTrueFalse {
+				issues.add(line, question.ID, "answer %q worth %s%% left out, only fully correct answers are kept", value, answer.Fraction)
+			}
+			continue
+		}
+
+		switch mq.Type {
+		case moodleNumerical:
+			tolerance := strings.TrimSpace(answer.Tolerance)
+			if !isNumber(value) || (tolerance != "" && !isNumber(tolerance)) {
+				issues.add(line, question.ID, "numerical question left out, its answer %q isn't a number", value)
+				return question, false
+			}
+			value = withTolerance(value, tolerance)
+		case moodleTrueFalse:
+			value = strings.ToLower(value)
+			if value != answerTrue && value != answerFalse {
+				issues.add(line, question.ID, "true/false question left out, its answer %q is neither true nor false", value)
+				return question, false
+			}
+		}
+		answers = append(answers, value)
+	}
+
+	if len(answers) == 0 {
+		issues.add(line, question.ID, "question left out, it has no fully correct answer")
+		return question, false
+	}
+	for _, alternative := range answers[1:] {
+		issues.add(line, question.ID, "alternative answer %q left out, only the first correct answer is kept", alternative)
+	}
+	question.Answer = answers[0]
+
+	if mq.Tags != nil {
+		for _, tag := range mq.Tags.Tags {
+			normalized, err := questionnaire.NormalizeTag(tag.Text)
+			if err != nil {
+				issues.add(line, question.ID, "tag left out: %v", err)
+				continue
+			}
+			question.Tags = append(question.Tags, normalized)
+		}
+	}
+
+	return question, true
+}
+
+var (
+	// htmlParagraph is a text made of a single paragraph, the way Moodle writes text.
+	htmlParagraph = regexp.MustCompile(`(?s)^\s*<p>(.*)</p>\s*$`)
+	htmlTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// moodlePlainText returns text as plain text, HTML markup is reported and left out.
+func moodlePlainText(text *moodleText, line, id int, issues *issues) string {
+	s := text.Text
+	switch text.Format {
+	case "html":
+		if m := htmlParagraph.FindStringSubmatch(s); m != nil && !strings.Contains(m[1], "<p>") {
+			s = m[1]
+		}
+		if stripped := htmlTag.ReplaceAllString(s, ""); stripped != s {
+			issues.add(line, id, "HTML markup of %q left out", s)
+			s = stripped
+		}
+		s = html.UnescapeString(s)
+	case "markdown":
+		issues.add(line, id, "markdown text format left out, the text is kept as is")
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// WriteMoodleXML writes questions in Moodle XML, a question is named after its ID and written as a numerical
+// question when its answer is a number, a true/false question when its answer is "true" or "false" and a
+// short-answer question otherwise. Returns error if any.
+func WriteMoodleXML(w io.Writer, questions []questionnaire.Question) error {
+	var (
+		quiz     moodleQuiz
+		category string
+	)
+
+	for i, question := range questions {
+		if i == 0 || question.Category != category {
+			category = question.Category
+			quiz.Questions = append(quiz.Questions, moodleQuestion{
+				Type:     moodleCategory,
+				Category: &moodleText{Text: strings.TrimSuffix("$course$/top/"+category, "/")},
+			})
+		}
+
+		mq := moodleQuestion{
+			Name:         &moodleText{Text: strconv.Itoa(question.ID)},
+			QuestionText: &moodleText{Format: "plain_text", Text: question.Question},
+		}
+		answer := strings.TrimSpace(question.Answer)
+		switch kindOf(&question) {
+		case kindNumerical:
+			mq.Type = moodleNumerical
+			mq.Answers = []moodleAnswer{{Fraction: "100", Text: answer, Tolerance: "0"}}
+			if interval, ok := textinput.ParseInterval(answer); ok {
+				// A range is written as its middle with half its width as tolerance.
+				target, tolerance := interval.Target, interval.Tolerance
+				if target == nil {
+					target = new(big.Rat).Add(interval.Low, interval.High)
+					target.Quo(target, big.NewRat(2, 1))
+					tolerance = new(big.Rat).Sub(interval.High, target)
+				}
+				mq.Answers[0].Text, mq.Answers[0].Tolerance = decimalString(target), decimalString(tolerance)
+			}
+		case kindTrueFalse:
+			mq.Type = moodleTrueFalse
+			right, wrong := answerTrue, answerFalse
+			if strings.EqualFold(answer, answerFalse) {
+				right, wrong = wrong, right
+			}
+			mq.Answers = []moodleAnswer{{Fraction: "100", Text: right}, {Fraction: "0", Text: wrong}}
+		default:
+			mq.Type = moodleShortAnswer
+			useCase := 0
+			mq.UseCase = &useCase
+			mq.Answers = []moodleAnswer{{Fraction: "100", Format: "plain_text", Text: question.Answer}}
+		}
+		if len(question.Tags) > 0 {
+			mq.Tags = &moodleTags{}
+			for _, tag := range question.Tags {
+				mq.Tags.Tags = append(mq.Tags.Tags, moodleText{Text: tag})
+			}
+		}
+		quiz.Questions = append(quiz.Questions, mq)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(quiz); err != nil {
+		return fmt.Errorf("could not encode quiz: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestReadMoodleXML(t *testing.T) {
+	tt := []struct {
+		Name              string
+		In                string
+		ExpectedQuestions []questionnaire.Question
+		ExpectedIssues    []Issue
+	}{
+		{
+			Name: "short-answer, numerical and true/false questions",
+			In: `<?xml version="1.0" encoding="UTF-8"?>
+<quiz>
+  <question type="category">
+    <category><text>$course$/top/Human Biology</text></category>
+  </question>
+  <question type="numerical">
+    <name><text>1</text></name>
+    <questiontext format="html"><text><![CDATA[<p>How many legs does a spider have?</p>]]></text></questiontext>
+    <answer fraction="100"><text>8</text><tolerance>0</tolerance></answer>
+    <tags><tag><text>Easy</text></tag></tags>
+  </question>
+  <question type="shortanswer">
+    <name><text>2</text></name>
+    <questiontext format="moodle_auto_format"><text>What is H2O &amp; CO2?</text></questiontext>
+    <usecase>0</usecase>
+    <answer fraction="100"><text>water and carbon dioxide</text></answer>
+  </question>
+  <question type="truefalse">
+    <name><text>3</text></name>
+    <questiontext format="plain_text"><text>Is the sky green?</text></questiontext>
+    <answer fraction="0"><text>true</text></answer>
+    <answer fraction="100"><text>false</text></answer>
+  </question>
+</quiz>
+`,
+			ExpectedQuestions: []questionnaire.Question{
+				{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy"}, Category: "human-biology"},
+				{ID: 2, Question: "What is H2O & CO2?", Answer: "water and carbon dioxide", Category: "human-biology"},
+				{ID: 3, Question: "Is the sky green?", Answer: "false", Category: "human-biology"},
+			},
+		},
+		{
+			Name: "partly mapped constructs are reported",
+			In: `<quiz>
+  <question type="numerical">
+    <name><text>Pi</text></name>
+    <questiontext format="html"><text><![CDATA[<p>What is <b>pi</b>?</p>]]></text></questiontext>
+    <generalfeedback><text>Pi is irrational.</text></generalfeedback>
+    <answer fraction="100"><text>3.14</text><tolerance>0.01</tolerance><feedback><text>Right!</text></feedback></answer>
+    <units><unit><unit_name>rad</unit_name></unit></units>
+  </question>
+  <question type="shortanswer">
+    <name><text>2</text></name>
+    <questiontext><text>Name a primary color.</text></questiontext>
+    <usecase>1</usecase>
+    <answer fraction="100"><text>Red</text></answer>
+    <answer fraction="100"><text>Blue</text></answer>
+    <answer fraction="50"><text>Purple</text></answer>
+  </question>
+</quiz>
+`,
+			ExpectedQuestions: []questionnaire.Question{
+				{Question: "What is pi?", Answer: "3.14 ±0.01"},
+				{ID: 2, Question: "Name a primary color.", Answer: "Red"},
+			},
+			ExpectedIssues: []Issue{
+				{Line: 2, Message: `name "Pi" left out, only a number is kept as the question number`},
+				{Line: 2, Message: `HTML markup of "What is <b>pi</b>?" left out`},
+				{Line: 2, Message: "general feedback left out"},
+				{Line: 2, Message: "units left out"},
+				{Line: 2, Message: `feedback of answer "3.14" left out`},
+				{Line: 9, ID: 2, Message: "case sensitivity left out, answers are matched ignoring case"},
+				{Line: 9, ID: 2, Message: `answer "Purple" worth 50% left out, only fully correct answers are kept`},
+				{Line: 9, ID: 2, Message: `alternative answer "Blue" left out, only the first correct answer is kept`},
+			},
+		},
+		{
+			Name: "unmapped questions are reported",
+			In: `<quiz>
+  <question type="multichoice">
+    <name><text>1</text></name>
+    <questiontext><text>Which is a fruit?</text></questiontext>
+  </question>
+  <question type="shortanswer">
+    <name><text>2</text></name>
+    <questiontext><text>Name a primary color.</text></questiontext>
+    <answer fraction="50"><text>Purple</text></answer>
+  </question>
+</quiz>
+`,
+			ExpectedIssues: []Issue{
+				{Line: 2, ID: 1, Message: "multichoice question left out"},
+				{Line: 6, ID: 2, Message: `answer "Purple" worth 50% left out, only fully correct answers are kept`},
+				{Line: 6, ID: 2, Message: "question left out, it has no fully correct answer"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			questions, issues, err := ReadMoodleXML(strings.NewReader(tc.In))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.ExpectedIssues, issues); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWriteMoodleXML(t *testing.T) {
+	questions := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy"}, Category: "biology"},
+		{ID: 2, Question: "Is water <wet>?", Answer: "True", Category: "biology"},
+		{ID: 3, Question: "What is H2O?", Answer: "Water"},
+		{ID: 4, Question: "How tall is Everest in metres?", Answer: "8849 +/- 10"},
+		{ID: 5, Question: "Pick a number.", Answer: "1/4..0.5"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMoodleXML(&buf, questions); err != nil {
+		t.Fatal(err)
+	}
+
+	read, issues, err := ReadMoodleXML(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "8", Tags: []string{"easy"}, Category: "biology"},
+		{ID: 2, Question: "Is water <wet>?", Answer: "true", Category: "biology"},
+		{ID: 3, Question: "What is H2O?", Answer: "Water"},
+		{ID: 4, Question: "How tall is Everest in metres?", Answer: "8849 ±10"},
+		{ID: 5, Question: "Pick a number.", Answer: "0.375 ±0.125"},
+	}
+	if diff := cmp.Diff(expected, read); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got: %v", issues)
+	}
+}
@@ -0,0 +1,270 @@
+package convert
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+// Namespaces and schemas of IMS QTI 2.1 and of its content packages.
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiSchemaLocation = qtiNamespace + " http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	cpNamespace       = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	cpSchemaLocation  = cpNamespace + " http://www.imsglobal.org/xsd/qti/qtiv2p1/qtiv2p1_imscpv1p2_v1p0.xsd"
+	xsiNamespace      = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// qtiManifest is the imsmanifest.xml of a content package.
+type qtiManifest struct {
+	XMLName        xml.Name      `xml:"manifest"`
+	Xmlns          string        `xml:"xmlns,attr"`
+	XmlnsXSI       string        `xml:"xmlns:xsi,attr"`
+	SchemaLocation string        `xml:"xsi:schemaLocation,attr"`
+	Identifier     string        `xml:"identifier,attr"`
+	Schema         string        `xml:"metadata>schema"`
+	SchemaVersion  string        `xml:"metadata>schemaversion"`
+	Organizations  struct{}      `xml:"organizations"`
+	Resources      []qtiResource `xml:"resources>resource"`
+}
+
+type qtiResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	File       struct {
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+}
+
+// qtiItem is an assessmentItem asking a question answered in a textEntryInteraction.
+type qtiItem struct {
+	XMLName        xml.Name `xml:"assessmentItem"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	XmlnsXSI       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Identifier     string   `xml:"identifier,attr"`
+	Title          string   `xml:"title,attr"`
+	Adaptive       bool     `xml:"adaptive,attr"`
+	TimeDependent  bool     `xml:"timeDependent,attr"`
+
+	ResponseDeclaration struct {
+		Identifier      string `xml:"identifier,attr"`
+		Cardinality     string `xml:"cardinality,attr"`
+		BaseType        string `xml:"baseType,attr"`
+		CorrectResponse string `xml:"correctResponse>value"`
+	} `xml:"responseDeclaration"`
+
+	OutcomeDeclaration struct {
+		Identifier   string `xml:"identifier,attr"`
+		Cardinality  string `xml:"cardinality,attr"`
+		BaseType     string `xml:"baseType,attr"`
+		DefaultValue string `xml:"defaultValue>value"`
+	} `xml:"outcomeDeclaration"`
+
+	ItemBody []qtiParagraph `xml:"itemBody>p"`
+
+	ResponseIf struct {
+		PatternMatch struct {
+			Pattern  string `xml:"pattern,attr"`
+			Variable struct {
+				Identifier string `xml:"identifier,attr"`
+			} `xml:"variable"`
+		} `xml:"patternMatch"`
+		SetOutcomeValue struct {
+			Identifier string `xml:"identifier,attr"`
+			BaseValue  struct {
+				BaseType string `xml:"baseType,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"baseValue"`
+		} `xml:"setOutcomeValue"`
+	} `xml:"responseProcessing>responseCondition>responseIf"`
+}
+
+type qtiParagraph struct {
+	Text        string                   `xml:",chardata"`
+	Interaction *qtiTextEntryInteraction `xml:"textEntryInteraction"`
+}
+
+type qtiTextEntryInteraction struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+	ExpectedLength     int    `xml:"expectedLength,attr"`
+}
+
+// WriteQTI writes questions as an IMS QTI 2.1 content package, a zip archive holding an assessment item per
+// question and the manifest listing them, see https://www.imsglobal.org/question/qtiv2p1/imsqti_implv2p1.html.
+// bank names the package. A question is asked in a textEntryInteraction scoring 1 when the response is equal to
+// the answer once its numbers written in words are written in digits, see qtiPattern. What Service.Answer accepts
+// besides is reported: the other forms of the value of a numeric answer, e.g. "1/2" or "50%" for "0.5", the other
+// units of an answer with a unit, tolerances, ranges and matching options. Tags and categories are reported as
+// well, QTI items have none. Returns the issues and error if any.
+func WriteQTI(w io.Writer, questions []questionnaire.Question, bank string) ([]Issue, error) {
+	var (
+		issues   issues
+		zw       = zip.NewWriter(w)
+		manifest = qtiManifest{
+			Xmlns:          cpNamespace,
+			XmlnsXSI:       xsiNamespace,
+			SchemaLocation: cpSchemaLocation,
+			Identifier:     "MANIFEST-" + bank,
+			Schema:         "QTIv2.1 Package",
+			SchemaVersion:  "1.0.0",
+		}
+	)
+
+	for i := range questions {
+		question := &questions[i]
+		if len(question.Tags) > 0 {
+			issues.add(0, question.ID, "tags %s left out, QTI items have no tags", strings.Join(question.Tags, ", "))
+		}
+		if question.Category != "" {
+			issues.add(0, question.ID, "category %s left out, QTI items have no category", question.Category)
+		}
+		if len(question.Matching) > 0 {
+			issues.add(0, question.ID, "matching options %s left out, QTI responses are matched by pattern",
+				strings.Join(question.Matching, ", "))
+		}
+
+		matched := *question
+		if interval, ok := textinput.ParseInterval(question.Answer); ok && interval.Target != nil {
+			matched.Answer = decimalString(interval.Target)
+			issues.add(0, question.ID, "tolerance of answer %s left out, only %s is accepted", question.Answer, matched.Answer)
+		} else if ok {
+			issues.add(0, question.ID, "range of answer %s left out, only the answer as written is accepted", question.Answer)
+		} else if _, ok := textinput.ParseNumber(question.Answer); ok {
+			issues.add(0, question.ID, "other forms of the value of answer %s left out, only its spellings are accepted",
+				question.Answer)
+		} else if isQuantity(question.Answer) {
+			issues.add(0, question.ID, "other units of answer %s left out, only the answer as written is accepted",
+				question.Answer)
+		}
+
+		identifier := fmt.Sprintf("question-%d", question.ID)
+		href := "items/" + identifier + ".xml"
+		if err := writeZipXML(zw, href, newQTIItem(&matched, identifier)); err != nil {
+			return nil, err
+		}
+
+		resource := qtiResource{Identifier: identifier, Type: "imsqti_item_xmlv2p1", Href: href}
+		resource.File.Href = href
+		manifest.Resources = append(manifest.Resources, resource)
+	}
+
+	if err := writeZipXML(zw, "imsmanifest.xml", manifest); err != nil {
+		return nil, err
+	}
+
+	return issues, zw.Close()
+}
+
+func newQTIItem(question *questionnaire.Question, identifier string) *qtiItem {
+	item := &qtiItem{
+		Xmlns:          qtiNamespace,
+		XmlnsXSI:       xsiNamespace,
+		SchemaLocation: qtiSchemaLocation,
+		Identifier:     identifier,
+		Title:          fmt.Sprintf("Question %d", question.ID),
+	}
+
+	item.ResponseDeclaration.Identifier = "RESPONSE"
+	item.ResponseDeclaration.Cardinality = "single"
+	item.ResponseDeclaration.BaseType = "string"
+	item.ResponseDeclaration.CorrectResponse = question.Answer
+
+	item.OutcomeDeclaration.Identifier = "SCORE"
+	item.OutcomeDeclaration.Cardinality = "single"
+	item.OutcomeDeclaration.BaseType = "float"
+	item.OutcomeDeclaration.DefaultValue = "0"
+
+	for _, line := range strings.Split(question.Question, "\n") {
+		item.ItemBody = append(item.ItemBody, qtiParagraph{Text: line})
+	}
+	item.ItemBody = append(item.ItemBody, qtiParagraph{Interaction: &qtiTextEntryInteraction{
+		ResponseIdentifier: "RESPONSE",
+		ExpectedLength:     utf8.RuneCountInString(question.Answer),
+	}})
+
+	item.ResponseIf.PatternMatch.Pattern = qtiPattern(question.Answer)
+	item.ResponseIf.PatternMatch.Variable.Identifier = "RESPONSE"
+	item.ResponseIf.SetOutcomeValue.Identifier = "SCORE"
+	item.ResponseIf.SetOutcomeValue.BaseValue.BaseType = "float"
+	item.ResponseIf.SetOutcomeValue.BaseValue.Value = "1"
+
+	return item
+}
+
+// qtiPattern returns the XML Schema regular expression matching the responses equal to answer after
+// textinput.RecognizedAsNumber, e.g. "8 legs" is "(8|[eE][iI][gG][hH][tT]) legs". Numbers written in words
+// match in their usual spellings given by textinput.NumberWords, single spaces between the words.
+func qtiPattern(answer string) string {
+	var sb strings.Builder
+	for _, part := range textinput.NumberParts(textinput.RecognizedAsNumber(answer)) {
+		words := textinput.NumberWords(part)
+		if len(words) == 0 {
+			sb.WriteString(escapeXSDPattern(part))
+			continue
+		}
+
+		sb.WriteString("(" + escapeXSDPattern(part))
+		for _, word := range words {
+			sb.WriteByte('|')
+			for _, c := range word {
+				if !unicode.IsLetter(c) {
+					sb.WriteString(escapeXSDPattern(string(c))) // e.g. "twenty-one"
+					continue
+				}
+				fmt.Fprintf(&sb, "[%c%c]", unicode.ToLower(c), unicode.ToUpper(c))
+			}
+		}
+		sb.WriteByte(')')
+	}
+
+	return sb.String()
+}
+
+// escapeXSDPattern escapes the metacharacters of XML Schema regular expressions in s.
+func escapeXSDPattern(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		switch c {
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// writeZipXML writes v in XML as the file name of zw.
+func writeZipXML(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("could not encode %s: %w", name, err)
+	}
+	_, err = io.WriteString(f, "\n")
+
+	return err
+}
@@ -0,0 +1,147 @@
+package convert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestQTIPattern(t *testing.T) {
+	tt := []struct {
+		Name            string
+		Answer          string
+		ExpectedPattern string
+		Responses       []string
+	}{
+		{
+			Name:            "number",
+			Answer:          "8",
+			ExpectedPattern: "(8|[eE][iI][gG][hH][tT])",
+			Responses:       []string{"8", "eight", "Eight", "EIGHT", "08", "eighty", "8 legs", ""},
+		},
+		{
+			Name:            "number written in words among text",
+			Answer:          "ten (or nine) legs.",
+			ExpectedPattern: `(10|[tT][eE][nN]) \(or (9|[nN][iI][nN][eE])\) legs\.`,
+			Responses:       []string{"10 (or 9) legs.", "Ten (or nine) legs.", "ten (or nine) legs", "ten or nine legs."},
+		},
+		{
+			Name:            "number of several words",
+			Answer:          "21",
+			ExpectedPattern: "(21|[tT][wW][eE][nN][tT][yY] [oO][nN][eE]|[tT][wW][eE][nN][tT][yY]\\-[oO][nN][eE])",
+			Responses:       []string{"21", "twenty-one", "Twenty One", "twenty", "twenty-two"},
+		},
+		{
+			Name:            "metacharacters",
+			Answer:          "a+b-c [d]? ^",
+			ExpectedPattern: `a\+b\-c \[d\]\? \^`,
+			Responses:       []string{"a+b-c [d]? ^", "aab-c [d]? ^"},
+		},
+		{
+			Name:            "case sensitive text",
+			Answer:          "Jakarta",
+			ExpectedPattern: "Jakarta",
+			Responses:       []string{"Jakarta", "jakarta"},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			pattern := qtiPattern(tc.Answer)
+			if pattern != tc.ExpectedPattern {
+				t.Fatalf("expected pattern: %s, got: %s", tc.ExpectedPattern, pattern)
+			}
+
+			// XML Schema patterns match the whole response, the pattern is written in the syntax they share with Go.
+			re := regexp.MustCompile("^(?:" + pattern + ")$")
+			for _, response := range tc.Responses {
+				expected := textinput.RecognizedAsNumber(response) == textinput.RecognizedAsNumber(tc.Answer)
+				if matched := re.MatchString(response); matched != expected {
+					t.Fatalf("response %q: expected match: %t, got: %t", response, expected, matched)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteQTI(t *testing.T) {
+	questions := []questionnaire.Question{
+		{ID: 1, Question: "How many legs does a spider have?", Answer: "eight", Tags: []string{"easy"}, Category: "biology"},
+		{ID: 2, Question: "What is H2O?", Answer: "water", Matching: []string{"strict-fraction"}},
+		{ID: 3, Question: "How tall is Everest in metres?", Answer: "8849 ±10"},
+		{ID: 4, Question: "How old?", Answer: "20..30"},
+		{ID: 5, Question: "How far is a 5K run?", Answer: "5 km"},
+	}
+
+	var buf bytes.Buffer
+	issues, err := WriteQTI(&buf, questions, "science")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedIssues := []Issue{
+		{ID: 1, Message: "tags easy left out, QTI items have no tags"},
+		{ID: 1, Message: "category biology left out, QTI items have no category"},
+		{ID: 1, Message: "other forms of the value of answer eight left out, only its spellings are accepted"},
+		{ID: 2, Message: "matching options strict-fraction left out, QTI responses are matched by pattern"},
+		{ID: 3, Message: "tolerance of answer 8849 ±10 left out, only 8849 is accepted"},
+		{ID: 4, Message: "range of answer 20..30 left out, only the answer as written is accepted"},
+		{ID: 5, Message: "other units of answer 5 km left out, only the answer as written is accepted"},
+	}
+	if diff := cmp.Diff(expectedIssues, issues); diff != "" {
+		t.Fatal(diff)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files[f.Name], err = io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+
+	var manifest qtiManifest
+	if err := xml.Unmarshal(files["imsmanifest.xml"], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Identifier != "MANIFEST-science" || len(manifest.Resources) != len(questions) {
+		t.Fatalf("expected manifest MANIFEST-science of %d resources, got: %s of %d", len(questions), manifest.Identifier, len(manifest.Resources))
+	}
+
+	answers := []string{"eight", "water", "8849", "20..30", "5 km"} // the answers accepted by the items
+	for i, resource := range manifest.Resources {
+		var item qtiItem
+		if err := xml.Unmarshal(files[resource.Href], &item); err != nil {
+			t.Fatalf("%s: %v", resource.Href, err)
+		}
+		if item.Identifier != resource.Identifier || resource.Type != "imsqti_item_xmlv2p1" {
+			t.Fatalf("%s: expected item %s of type imsqti_item_xmlv2p1, got: %s of type %s", resource.Href, resource.Identifier, item.Identifier, resource.Type)
+		}
+
+		if len(item.ItemBody) != 2 || item.ItemBody[0].Text != questions[i].Question {
+			t.Fatalf("%s: expected question %q in the first of 2 paragraphs, got: %v", resource.Href, questions[i].Question, item.ItemBody)
+		}
+		expected := &qtiTextEntryInteraction{ResponseIdentifier: "RESPONSE", ExpectedLength: len(answers[i])}
+		if diff := cmp.Diff(expected, item.ItemBody[1].Interaction); diff != "" {
+			t.Fatalf("%s: %s", resource.Href, diff)
+		}
+		if item.ResponseIf.PatternMatch.Pattern != qtiPattern(answers[i]) {
+			t.Fatalf("%s: expected pattern: %s, got: %s", resource.Href, qtiPattern(answers[i]), item.ResponseIf.PatternMatch.Pattern)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package questionnaire
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCSVColumns = errors.New("invalid csv columns")
+
+// CSVField is a field of Question held by a CSV column.
+type CSVField string
+
+const (
+	CSVID       CSVField = "id"
+	CSVQuestion CSVField = "question"
+	CSVAnswer   CSVField = "answer"
+	CSVTags     CSVField = "tags"     // comma separated
+	CSVCategory CSVField = "category" // e.g. "science/physics"
+	CSVMatching CSVField = "matching" // comma separated
+)
+
+// CSVColumn maps the CSV column named Header to a field of Question.
+type CSVColumn struct {
+	Header string
+	Field  CSVField
+}
+
+// DefaultCSVColumns are the columns of every field, named after the field.
+var DefaultCSVColumns = []CSVColumn{
+	{Header: "id", Field: CSVID},
+	{Header: "question", Field: CSVQuestion},
+	{Header: "answer", Field: CSVAnswer},
+	{Header: "tags", Field: CSVTags},
+	{Header: "category", Field: CSVCategory},
+	{Header: "matching", Field: CSVMatching},
+}
+
+// ParseCSVColumns parses columns written as "<header>=<field>,...", e.g. "No=id,Q=question,A=answer".
+// A column written as "<field>" is named after its field.
+func ParseCSVColumns(s string) ([]CSVColumn, error) {
+	var (
+		columns []CSVColumn
+		seen    = make(map[CSVField]bool)
+	)
+	for _, part := range strings.Split(s, ",") {
+		header, field, found := strings.Cut(part, "=")
+		if !found {
+			field = header
+		}
+		header, field = strings.TrimSpace(header), strings.ToLower(strings.TrimSpace(field))
+
+		column := CSVColumn{Header: header, Field: CSVField(field)}
+		switch {
+		case header == "":
+			return nil, fmt.Errorf("%q: missing header: %w", part, ErrInvalidCSVColumns)
+		case !column.Field.valid():
+			return nil, fmt.Errorf("%q: unknown field %q, should be one of: id, question, answer, tags, category, matching: %w",
+				part, field, ErrInvalidCSVColumns)
+		case seen[column.Field]:
+			return nil, fmt.Errorf("%q: field %q is mapped twice: %w", part, field, ErrInvalidCSVColumns)
+		}
+		seen[column.Field] = true
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func (f CSVField) valid() bool {
+	switch f {
+	case CSVID, CSVQuestion, CSVAnswer, CSVTags, CSVCategory, CSVMatching:
+		return true
+	}
+	return false
+}
+
+// CSVRow is a question read from a CSV row.
+type CSVRow struct {
+	// Line is the line the row starts at, the header is on line 1.
+	Line     int
+	Question Question
+	// Err tells why the row couldn't be read, Question is then incomplete.
+	Err error
+}
+
+// ReadCSV reads the questions of a RFC 4180 CSV whose first row is the header. The columns are found by
+// their header, ignoring case, the question and answer columns are required and the columns not mapped are
+// ignored. An empty ID is left 0. A row that can't be read is returned with its error and the reading goes
+// on, the error returned is about the header or the reading of r.
+func ReadCSV(r io.Reader, columns []CSVColumn) ([]CSVRow, error) {
+	cr := csv.NewReader(r)
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		// Spreadsheets often start the file with a byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	positions := make(map[CSVField]int, len(columns))
+	for _, column := range columns {
+		for i := range header {
+			if strings.EqualFold(strings.TrimSpace(header[i]), column.Header) {
+				positions[column.Field] = i
+				break
+			}
+		}
+	}
+	for _, field := range []CSVField{CSVQuestion, CSVAnswer} {
+		if _, ok := positions[field]; !ok {
+			return nil, fmt.Errorf("missing %s column", field)
+		}
+	}
+
+	var rows []CSVRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, CSVRow{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		question, err := csvQuestion(record, positions)
+		rows = append(rows, CSVRow{Line: line, Question: question, Err: err})
+	}
+
+	return rows, nil
+}
+
+// csvQuestion reads a question from record, positions maps the fields to their position in record.
+func csvQuestion(record []string, positions map[CSVField]int) (question Question, err error) {
+	field := func(f CSVField) string {
+		if i, ok := positions[f]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	if id := strings.TrimSpace(field(CSVID)); id != "" {
+		if question.ID, err = strconv.Atoi(id); err != nil {
+			return question, fmt.Errorf("invalid id %q, should be integer", id)
+		}
+	}
+	question.Question, question.Answer = field(CSVQuestion), field(CSVAnswer)
+	if strings.TrimSpace(question.Question) == "" {
+		return question, errors.New("missing question")
+	}
+	if strings.TrimSpace(question.Answer) == "" {
+		return question, errors.New("missing answer")
+	}
+	for _, tag := range strings.Split(field(CSVTags), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			question.Tags = append(question.Tags, tag)
+		}
+	}
+	question.Category = strings.TrimSpace(field(CSVCategory))
+	for _, option := range strings.Split(field(CSVMatching), ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			question.Matching = append(question.Matching, option)
+		}
+	}
+
+	return question, nil
+}
+
+// WriteCSV writes questions as a RFC 4180 CSV, the header first and a row per question.
+func WriteCSV(w io.Writer, questions []Question, columns []CSVColumn) error {
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for _, question := range questions {
+		for i, column := range columns {
+			switch column.Field {
+			case CSVID:
+				record[i] = strconv.Itoa(question.ID)
+			case CSVQuestion:
+				record[i] = question.Question
+			case CSVAnswer:
+				record[i] = question.Answer
+			case CSVTags:
+				record[i] = strings.Join(question.Tags, ",")
+			case CSVCategory:
+				record[i] = question.Category
+			case CSVMatching:
+				record[i] = strings.Join(question.Matching, ",")
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,219 @@
+package questionnaire
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseCSVColumns(t *testing.T) {
+	tt := []struct {
+		Name            string
+		In              string
+		ExpectedColumns []CSVColumn
+		ExpectedErr     error
+	}{
+		{
+			Name: "headers and fields",
+			In:   "No=id, Q = Question,answer",
+			ExpectedColumns: []CSVColumn{
+				{Header: "No", Field: CSVID},
+				{Header: "Q", Field: CSVQuestion},
+				{Header: "answer", Field: CSVAnswer},
+			},
+		},
+		{
+			Name:        "unknown field",
+			In:          "No=number",
+			ExpectedErr: ErrInvalidCSVColumns,
+		},
+		{
+			Name:        "field mapped twice",
+			In:          "Q=question,Question=question",
+			ExpectedErr: ErrInvalidCSVColumns,
+		},
+		{
+			Name:        "missing header",
+			In:          "=question",
+			ExpectedErr: ErrInvalidCSVColumns,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			columns, err := ParseCSVColumns(tc.In)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectedErr, err)
+			}
+			if diff := cmp.Diff(tc.ExpectedColumns, columns); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tt := []struct {
+		Name         string
+		In           string
+		Columns      []CSVColumn
+		ExpectedRows []CSVRow
+		ExpectedErr  string
+	}{
+		{
+			Name: "quoted fields",
+			In: "\ufeffid,question,answer,tags,category\n" +
+				"1,\"How many characters are there in \"\"Quipper\"\"?\",7,\"easy, spelling\",words\n" +
+				",\"First line\nsecond line\",\"a, b\",,\n" +
+				"3,1 + 1?,2,,\n",
+			Columns: DefaultCSVColumns,
+			ExpectedRows: []CSVRow{
+				{Line: 2, Question: Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7",
+					Tags: []string{"easy", "spelling"}, Category: "words"}},
+				{Line: 3, Question: Question{Question: "First line\nsecond line", Answer: "a, b"}},
+				{Line: 5, Question: Question{ID: 3, Question: "1 + 1?", Answer: "2"}},
+			},
+		},
+		{
+			Name:    "mapped columns in any order, extra columns ignored",
+			In:      "Notes,A,Q,No\nnone,7,Quipper?,1\n",
+			Columns: []CSVColumn{{Header: "no", Field: CSVID}, {Header: "Q", Field: CSVQuestion}, {Header: "A", Field: CSVAnswer}},
+			ExpectedRows: []CSVRow{
+				{Line: 2, Question: Question{ID: 1, Question: "Quipper?", Answer: "7"}},
+			},
+		},
+		{
+			Name: "row errors",
+			In: "id,question,answer\n" +
+				"x,1 + 1?,2\n" +
+				"2,,2\n" +
+				"3,1 + 2?\n" +
+				"4,1 \"+\" 3?,4\n" +
+				"5,1 + 4?,5\n",
+			Columns: DefaultCSVColumns,
+			ExpectedRows: []CSVRow{
+				{Line: 2, Err: errors.New("invalid id \"x\", should be integer")},
+				{Line: 3, Err: errors.New("missing question")},
+				{Line: 4, Err: errors.New("wrong number of fields")},
+				{Line: 5, Err: errors.New("bare \" in non-quoted-field")},
+				{Line: 6, Question: Question{ID: 5, Question: "1 + 4?", Answer: "5"}},
+			},
+		},
+		{
+			Name:        "missing answer column",
+			In:          "id,question\n1,1 + 1?\n",
+			Columns:     DefaultCSVColumns,
+			ExpectedErr: "missing answer column",
+		},
+		{
+			Name:        "empty",
+			Columns:     DefaultCSVColumns,
+			ExpectedErr: "missing header",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(tc.In), tc.Columns)
+			if err != nil {
+				if err.Error() != tc.ExpectedErr {
+					t.Fatalf("expected error: %s, got: %v", tc.ExpectedErr, err)
+				}
+				return
+			}
+			if tc.ExpectedErr != "" {
+				t.Fatalf("expected error: %s, got nil", tc.ExpectedErr)
+			}
+
+			if diff := cmp.Diff(tc.ExpectedRows, rows,
+				cmpopts.IgnoreFields(CSVRow{}, "Question"),
+				cmp.Comparer(func(a, b error) bool { return a == nil && b == nil || a != nil && b != nil && a.Error() == b.Error() }),
+			); diff != "" {
+				t.Fatal(diff)
+			}
+			for i := range rows {
+				if rows[i].Err != nil {
+					continue
+				}
+				if diff := cmp.Diff(tc.ExpectedRows[i].Question, rows[i].Question); diff != "" {
+					t.Fatalf("line %d: %s", rows[i].Line, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	questions := []Question{
+		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 2,
+			Tags: []string{"easy", "spelling"}, Category: "words"},
+		{ID: 2, Question: "First line\nsecond line", Answer: "a, b", Matching: []string{"strict-fraction", "strict-zeros"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, questions, DefaultCSVColumns); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "id,question,answer,tags,category,matching\n" +
+		"1,\"How many characters are there in \"\"Quipper\"\"?\",7,\"easy,spelling\",words,\n" +
+		"2,\"First line\nsecond line\",\"a, b\",,,\"strict-fraction,strict-zeros\"\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	rows, err := ReadCSV(&buf, DefaultCSVColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		expected := questions[i]
+		expected.Version = 0
+		if diff := cmp.Diff(expected, rows[i].Question); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	in := "id,question,answer\n" +
+		"1,\"He said \"\"hi\"\"\",\"\"\"yes\"\"\"\n" +
+		"2,\"\"\"Quipper\"\" has how many characters?\",7\n"
+
+	columns, err := ParseCSVColumns("id=id,question=question,answer=answer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadCSV(strings.NewReader(in), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	questions := make([]Question, len(rows))
+	for i := range rows {
+		questions[i] = rows[i].Question
+	}
+
+	ctx := context.Background()
+	qs := NewService(NewRepository())
+	if _, err := Import(ctx, qs, questions, ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if questions, err = qs.GetAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, questions, columns); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
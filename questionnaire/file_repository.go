@@ -0,0 +1,306 @@
+package questionnaire
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileContent is the on-disk layout of the JSON file used by fileRepository.
+type fileContent struct {
+	Questions []Question `json:"questions"`
+	Revisions []Revision `json:"revisions,omitempty"`
+	// Banks are the banks other than DefaultBank, whose questions are kept above.
+	Banks []bankState `json:"banks,omitempty"`
+}
+
+// NewFileRepository creates Repository that keeps the questions in a JSON file located in path.
+// The file is loaded when the repository is created and rewritten atomically on every change,
+// a missing file is treated as an empty question bank. The changes of the processes sharing the file
+// take turns through the lock file path + ".lock", a change starts from the file reloaded when another
+// process rewrote it.
+func NewFileRepository(path string) (Repository, error) {
+	r := &fileRepository{
+		path:  path,
+		inmem: newInmemRepository(make([]Question, 0)),
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// fileRepository serves reads from an in-memory copy of the questions and persists the copy
+// into the JSON file after every successful change.
+type fileRepository struct {
+	mu    sync.Mutex // serializes writers so the file always reflects the latest change
+	path  string
+	inmem *inmemRepository
+	file  fs.FileInfo // the file the copy was loaded from or saved to, nil when there is none
+}
+
+func (r *fileRepository) GetBanks(ctx context.Context) ([]Bank, error) {
+	return r.inmem.GetBanks(ctx)
+}
+
+func (r *fileRepository) CreateBank(ctx context.Context, bank *Bank) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.CreateBank(ctx, bank)
+	})
+}
+
+func (r *fileRepository) GetByID(ctx context.Context, id int) (*Question, error) {
+	return r.inmem.GetByID(ctx, id)
+}
+
+func (r *fileRepository) GetAll(ctx context.Context) ([]Question, error) {
+	return r.inmem.GetAll(ctx)
+}
+
+func (r *fileRepository) Find(ctx context.Context, query Query) (*Page, error) {
+	return r.inmem.Find(ctx, query)
+}
+
+func (r *fileRepository) GetMaxID(ctx context.Context) (int, error) {
+	return r.inmem.GetMaxID(ctx)
+}
+
+func (r *fileRepository) GetFirstFreeID(ctx context.Context) (int, error) {
+	return r.inmem.GetFirstFreeID(ctx)
+}
+
+func (r *fileRepository) Create(ctx context.Context, question *Question) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.Create(ctx, question)
+	})
+}
+
+func (r *fileRepository) Update(ctx context.Context, question *Question) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.Update(ctx, question)
+	})
+}
+
+func (r *fileRepository) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.UpdateIfVersion(ctx, question, version)
+	})
+}
+
+func (r *fileRepository) Delete(ctx context.Context, id int) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.Delete(ctx, id)
+	})
+}
+
+func (r *fileRepository) Trash(ctx context.Context, id int, at time.Time) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.Trash(ctx, id, at)
+	})
+}
+
+func (r *fileRepository) Restore(ctx context.Context, id int) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.Restore(ctx, id)
+	})
+}
+
+func (r *fileRepository) Purge(ctx context.Context, before time.Time) (n int, err error) {
+	err = r.mutate(func(inmem *inmemRepository) error {
+		n, err = inmem.Purge(ctx, before)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (r *fileRepository) AddRevision(ctx context.Context, revision *Revision) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.AddRevision(ctx, revision)
+	})
+}
+
+func (r *fileRepository) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	return r.inmem.GetRevisions(ctx, id)
+}
+
+// WithTx runs fn in a transaction of the in-memory copy, the file is written once when it commits.
+func (r *fileRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	return r.mutate(func(inmem *inmemRepository) error {
+		return inmem.WithTx(ctx, fn)
+	})
+}
+
+// mutate applies fn to the in-memory copy and saves the result, the in-memory copy is
+// reverted when the file could not be written so both never diverge.
+func (r *fileRepository) mutate(fn func(inmem *inmemRepository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := r.reload(); err != nil {
+		return err
+	}
+
+	previous := r.inmem.snapshot()
+	if err := fn(r.inmem); err != nil {
+		return err
+	}
+
+	if err := r.save(); err != nil {
+		r.inmem.restore(previous)
+		return err
+	}
+
+	return nil
+}
+
+// lock waits for the other processes to be done with the file, returns the function letting them in again.
+func (r *fileRepository) lock() (unlock func(), err error) {
+	f, err := os.OpenFile(r.path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+	if err := lockFile(f, true); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() { f.Close() }, nil
+}
+
+// reload loads the file again when it's not the one the in-memory copy was loaded from or saved to,
+// every save replaces the file.
+func (r *fileRepository) reload() error {
+	file, err := os.Stat(r.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not stat %q: %w", r.path, err)
+	}
+	if file == nil && r.file == nil {
+		return nil
+	}
+	if file != nil && r.file != nil && os.SameFile(file, r.file) && file.ModTime().Equal(r.file.ModTime()) &&
+		file.Size() == r.file.Size() {
+		return nil
+	}
+
+	return r.load()
+}
+
+func (r *fileRepository) load() error {
+	f, err := os.Open(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if r.file != nil {
+			r.inmem.restore(inmemState{Questions: make([]Question, 0)})
+			r.file = nil
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %q: %w", r.path, err)
+	}
+	defer f.Close()
+
+	file, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat %q: %w", r.path, err)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("could not read %q: %w", r.path, err)
+	}
+
+	var content fileContent
+	if err := json.Unmarshal(b, &content); err != nil {
+		return fmt.Errorf("could not decode %q: %w", r.path, err)
+	}
+
+	upgradeQuestions(content.Questions)
+	r.inmem.restore(inmemState{Questions: content.Questions, Revisions: content.Revisions, Banks: content.Banks})
+	r.file = file
+
+	return nil
+}
+
+func (r *fileRepository) save() error {
+	state := r.inmem.snapshot()
+	b, err := json.MarshalIndent(fileContent{Questions: state.Questions, Revisions: state.Revisions, Banks: state.Banks}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode questions: %w", err)
+	}
+
+	if err := WriteFileAtomic(r.path, b); err != nil {
+		return err
+	}
+	// The file is read again on the next change when it can't be told apart from another process's.
+	r.file, _ = os.Stat(r.path)
+
+	return nil
+}
+
+// WriteFileAtomic writes b into a temporary file in the same directory as path and renames it
+// to path once it is synced, so readers never observe a partially written file. The file keeps
+// the permissions of the one it replaces, a new file gets 0644.
+func WriteFileAtomic(path string, b []byte) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not stat %q: %w", path, err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		// The name of the temporary file is random, tell the directory instead.
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("could not create temporary file in %q: %w", filepath.Dir(path), err)
+	}
+	tmp := f.Name()
+
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("could not chmod %q: %w", tmp, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("could not write %q: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("could not sync %q: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not close %q: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not rename %q to %q: %w", tmp, path, err)
+	}
+
+	return nil
+}
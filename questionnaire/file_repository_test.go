@@ -0,0 +1,274 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileRepositoryPersistence(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
+
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7", Version: 2}
+	)
+
+	tt := []struct {
+		Name              string
+		Do                func(ctx context.Context, r Repository) error
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:              "create question 1",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question2) },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 1, failed duplicate",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       ErrQuestionIsAlreadyExist,
+		},
+		{
+			Name:              "update question 1",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &updatedQuestion1) },
+			ExpectedQuestions: []Question{updatedQuestion1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "update question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &question3) },
+			ExpectedQuestions: []Question{updatedQuestion1, question2},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "delete question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 2) },
+			ExpectedQuestions: []Question{updatedQuestion1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "delete question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 3) },
+			ExpectedQuestions: []Question{updatedQuestion1},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+	}
+
+	var (
+		ctx  = context.Background()
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "questions.json")
+	)
+
+	// Every case reopens the file, the order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			r, err := NewFileRepository(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.Do(ctx, r); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+
+			reopened, err := NewFileRepository(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			questions, err := reopened.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("expected only the question file and its lock file left in directory, got: %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestNewFileRepository(t *testing.T) {
+	tt := []struct {
+		Name              string
+		Content           *string
+		ExpectedQuestions []Question
+		ExpectErr         bool
+	}{
+		{
+			Name:              "file does not exist, empty bank",
+			Content:           nil,
+			ExpectedQuestions: []Question{},
+			ExpectErr:         false,
+		},
+		{
+			Name: "valid file",
+			Content: func() *string {
+				s := `{"questions":[{"id":1,"question":"1 + 1?","answer":"2","version":3}]}`
+				return &s
+			}(),
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3},
+			},
+			ExpectErr: false,
+		},
+		{
+			Name:    "file written before versioning, starts from version 1",
+			Content: func() *string { s := `{"questions":[{"id":1,"question":"1 + 1?","answer":"2"}]}`; return &s }(),
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+			},
+			ExpectErr: false,
+		},
+		{
+			Name:              "corrupted file",
+			Content:           func() *string { s := `{"questions":[`; return &s }(),
+			ExpectedQuestions: nil,
+			ExpectErr:         true,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "questions.json")
+			if tc.Content != nil {
+				if err := os.WriteFile(path, []byte(*tc.Content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r, err := NewFileRepository(path)
+			if tc.ExpectErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			questions, err := r.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicMode(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Mode         *os.FileMode
+		ExpectedMode os.FileMode
+	}{
+		{
+			Name:         "new file",
+			Mode:         nil,
+			ExpectedMode: 0o644,
+		},
+		{
+			Name:         "existing file keeps its mode",
+			Mode:         func() *os.FileMode { m := os.FileMode(0o640); return &m }(),
+			ExpectedMode: 0o640,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "questions.json")
+			if tc.Mode != nil {
+				if err := os.WriteFile(path, []byte("{}"), *tc.Mode); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(path, *tc.Mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := WriteFileAtomic(path, []byte(`{"questions":[]}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tc.ExpectedMode {
+				t.Fatalf("expected mode: %v, got: %v", tc.ExpectedMode, info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestFileRepositoryReload(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.json")
+
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"}
+	)
+
+	// Both repositories share the file as two processes would.
+	r1, err := NewFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := NewFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r1.Create(ctx, &question1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.Create(ctx, &question2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r1.Update(ctx, &Question{ID: 1, Question: question1.Question, Answer: "seven"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r3, err := NewFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	questions, err := r3.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Question{
+		{ID: 1, Question: question1.Question, Answer: "seven", Version: 2},
+		{ID: 2, Question: question2.Question, Answer: "4", Version: 1},
+	}
+	if diff := cmp.Diff(expected, questions); diff != "" {
+		t.Fatal(diff)
+	}
+}
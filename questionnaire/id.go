@@ -0,0 +1,36 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+)
+
+// IDAllocator picks the ID of a question created without one, see WithIDAllocator. It's called inside the
+// transaction creating the question and returns a positive ID not taken by any question of the bank of ctx in r,
+// trash included, or error if any.
+type IDAllocator func(ctx context.Context, r Repository) (int, error)
+
+// maxAllocationAttempts is the number of times a question created without an ID is tried with a new ID when
+// someone else took the allocated one first.
+const maxAllocationAttempts = 10
+
+// NextID is the IDAllocator following the highest ID taken, the IDs of purged questions are only reused when
+// they were the highest.
+func NextID(ctx context.Context, r Repository) (int, error) {
+	highest, err := r.GetMaxID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return highest + 1, nil
+}
+
+// FirstFreeID is the IDAllocator filling the gaps, it returns the lowest positive ID not taken.
+func FirstFreeID(ctx context.Context, r Repository) (int, error) {
+	return r.GetFirstFreeID(ctx)
+}
+
+// isAllocationConflict reports whether err is caused by someone else taking an allocated ID first.
+func isAllocationConflict(err error) bool {
+	return errors.Is(err, ErrQuestionIsAlreadyExist)
+}
@@ -0,0 +1,139 @@
+package questionnaire
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIDAllocators(t *testing.T) {
+	ctx := context.Background()
+
+	tt := []struct {
+		Name          string
+		IDs           []int
+		Trashed       []int
+		ExpectedNext  int
+		ExpectedFirst int
+	}{
+		{
+			Name:          "empty bank",
+			ExpectedNext:  1,
+			ExpectedFirst: 1,
+		},
+		{
+			Name:          "gaps",
+			IDs:           []int{1, 2, 4, 5},
+			ExpectedNext:  6,
+			ExpectedFirst: 3,
+		},
+		{
+			Name:          "trashed questions are taken",
+			IDs:           []int{2, 3},
+			Trashed:       []int{1, 9},
+			ExpectedNext:  10,
+			ExpectedFirst: 4,
+		},
+		{
+			Name:          "non positive IDs are ignored",
+			IDs:           []int{-1, 0},
+			ExpectedNext:  1,
+			ExpectedFirst: 1,
+		},
+	}
+
+	for name, openRepository := range repositoryOpeners() {
+		for _, tc := range tt {
+			tc := tc
+			t.Run(name+"/"+tc.Name, func(t *testing.T) {
+				r := openRepository(t, t.TempDir())
+				if name == "inmem" {
+					r = NewRepository() // the inmem opener shares a single repository
+				}
+
+				for _, id := range append(append([]int(nil), tc.IDs...), tc.Trashed...) {
+					if err := r.Create(ctx, &Question{ID: id, Question: "?", Answer: "!"}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, id := range tc.Trashed {
+					if err := r.Trash(ctx, id, time.Now()); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				next, err := NextID(ctx, r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next != tc.ExpectedNext {
+					t.Fatalf("expected next ID: %d, got: %d", tc.ExpectedNext, next)
+				}
+
+				first, err := FirstFreeID(ctx, r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if first != tc.ExpectedFirst {
+					t.Fatalf("expected first free ID: %d, got: %d", tc.ExpectedFirst, first)
+				}
+			})
+		}
+	}
+}
+
+func TestServiceCreateAllocatesIDConcurrently(t *testing.T) {
+	const creators = 20
+
+	for name, openRepository := range repositoryOpeners() {
+		for _, allocate := range []struct {
+			Name string
+			IDAllocator
+		}{
+			{Name: "next", IDAllocator: NextID},
+			{Name: "first free", IDAllocator: FirstFreeID},
+		} {
+			t.Run(name+"/"+allocate.Name, func(t *testing.T) {
+				r := openRepository(t, t.TempDir())
+				if name == "inmem" {
+					r = NewRepository() // the inmem opener shares a single repository
+				}
+				qs := NewService(r, WithIDAllocator(allocate.IDAllocator))
+
+				var (
+					wg  sync.WaitGroup
+					mu  sync.Mutex
+					ids []int
+				)
+				for i := 0; i < creators; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						question := &Question{Question: "1 + 1?", Answer: "2"}
+						if err := qs.Create(context.Background(), question); err != nil {
+							t.Error(err)
+							return
+						}
+						mu.Lock()
+						ids = append(ids, question.ID)
+						mu.Unlock()
+					}()
+				}
+				wg.Wait()
+
+				expected := make([]int, creators)
+				for i := range expected {
+					expected[i] = i + 1
+				}
+				sort.Ints(ids)
+				if diff := cmp.Diff(expected, ids); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		}
+	}
+}
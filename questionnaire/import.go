@@ -0,0 +1,158 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConflictPolicy tells Import what to do with a question whose ID is already taken.
+type ConflictPolicy int
+
+const (
+	ConflictSkip      ConflictPolicy = iota // keep the existing question
+	ConflictOverwrite                       // update the existing question
+	ConflictFail                            // import nothing
+)
+
+var conflictPolicyNames = map[ConflictPolicy]string{
+	ConflictSkip:      "skip",
+	ConflictOverwrite: "overwrite",
+	ConflictFail:      "fail",
+}
+
+func (p ConflictPolicy) String() string {
+	if name, ok := conflictPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("ConflictPolicy(%d)", int(p))
+}
+
+// ParseConflictPolicy parses conflict policy written as "skip", "overwrite" or "fail".
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for policy, name := range conflictPolicyNames {
+		if strings.EqualFold(s, name) {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown conflict policy %q, should be one of: skip, overwrite, fail", s)
+}
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	Conflict ConflictPolicy
+	// DryRun reports what would be imported without importing anything.
+	DryRun bool
+}
+
+// ImportOutcome is what Import did with a question.
+type ImportOutcome string
+
+const (
+	ImportCreated ImportOutcome = "created"
+	ImportUpdated ImportOutcome = "updated"
+	ImportSkipped ImportOutcome = "skipped"
+	ImportFailed  ImportOutcome = "failed"
+)
+
+// ImportResult is the outcome of importing a question.
+type ImportResult struct {
+	// ID is the ID of the question, the allocated one when it had none.
+	ID      int
+	Outcome ImportOutcome
+	// Err tells why the question failed to import.
+	Err error
+}
+
+// ImportReport is the outcome of Import, Results[i] is the outcome of questions[i].
+type ImportReport struct {
+	Results []ImportResult
+}
+
+// Count returns the number of questions with given outcome.
+func (r *ImportReport) Count(outcome ImportOutcome) (n int) {
+	for i := range r.Results {
+		if r.Results[i].Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// ErrDryRun rolls back the transaction of a dry run, the import functions return nil in its place.
+var ErrDryRun = errors.New("dry run")
+
+// Import creates questions in the bank of ctx in a single transaction, a question without ID is given one and
+// a question in trash is moved to trash once created. A question failing to import is reported and the others are
+// imported anyway, except when its ID is taken and opts.Conflict is ConflictFail: nothing is imported then and the
+// error matches ErrQuestionIsAlreadyExist. Returns the report and error if any.
+func Import(ctx context.Context, qs Service, questions []Question, opts ImportOptions) (*ImportReport, error) {
+	var report *ImportReport
+	err := withImportTx(ctx, qs, opts, func(tx Service) (err error) {
+		report, err = importQuestions(ctx, tx, questions, opts.Conflict)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// withImportTx runs fn in a transaction, rolled back when opts.DryRun is set.
+func withImportTx(ctx context.Context, qs Service, opts ImportOptions, fn func(tx Service) error) error {
+	err := qs.WithTx(ctx, func(tx Service) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return ErrDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, ErrDryRun) {
+		return nil
+	}
+
+	return err
+}
+
+// importQuestions imports questions in the bank of ctx through tx, see Import.
+func importQuestions(ctx context.Context, tx Service, questions []Question, conflict ConflictPolicy) (*ImportReport, error) {
+	report := &ImportReport{Results: make([]ImportResult, len(questions))}
+
+	for i := range questions {
+		var (
+			question = questions[i].clone()
+			result   = &report.Results[i]
+		)
+
+		err := tx.Create(ctx, &question)
+		switch {
+		case err == nil:
+			result.Outcome = ImportCreated
+		case errors.Is(err, ErrQuestionIsAlreadyExist) && conflict == ConflictFail:
+			return nil, fmt.Errorf("question [%d]: %w", question.ID, err)
+		case errors.Is(err, ErrQuestionIsAlreadyExist) && conflict == ConflictSkip:
+			result.Outcome = ImportSkipped
+		case errors.Is(err, ErrQuestionIsAlreadyExist) && conflict == ConflictOverwrite:
+			if err := tx.Update(ctx, &question); err != nil {
+				result.Outcome, result.Err = ImportFailed, err
+			} else {
+				result.Outcome = ImportUpdated
+			}
+		default:
+			result.Outcome, result.Err = ImportFailed, err
+		}
+		result.ID = question.ID
+
+		if questions[i].Trashed() && (result.Outcome == ImportCreated || result.Outcome == ImportUpdated) {
+			if err := tx.Delete(ctx, question.ID); err != nil {
+				result.Outcome, result.Err = ImportFailed, err
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,112 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImport(t *testing.T) {
+	var (
+		existing = Question{ID: 1, Question: "1 + 1?", Answer: "2"}
+		imported = []Question{
+			{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
+			{Question: "2 + 3?", Answer: "5"},
+			{ID: 5, Question: "2 * 3?", Answer: "6", Tags: []string{"AND"}},
+		}
+	)
+
+	tt := []struct {
+		Name              string
+		Options           ImportOptions
+		ExpectedResults   []ImportResult
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:    "skip",
+			Options: ImportOptions{Conflict: ConflictSkip},
+			ExpectedResults: []ImportResult{
+				{ID: 1, Outcome: ImportSkipped},
+				{ID: 2, Outcome: ImportCreated},
+				{ID: 5, Outcome: ImportFailed, Err: ErrInvalidTag},
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+				{ID: 2, Question: "2 + 3?", Answer: "5", Version: 1},
+			},
+		},
+		{
+			Name:    "overwrite",
+			Options: ImportOptions{Conflict: ConflictOverwrite},
+			ExpectedResults: []ImportResult{
+				{ID: 1, Outcome: ImportUpdated},
+				{ID: 2, Outcome: ImportCreated},
+				{ID: 5, Outcome: ImportFailed, Err: ErrInvalidTag},
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 2},
+				{ID: 2, Question: "2 + 3?", Answer: "5", Version: 1},
+			},
+		},
+		{
+			Name:        "fail",
+			Options:     ImportOptions{Conflict: ConflictFail},
+			ExpectedErr: ErrQuestionIsAlreadyExist,
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+			},
+		},
+		{
+			Name:    "dry run",
+			Options: ImportOptions{Conflict: ConflictOverwrite, DryRun: true},
+			ExpectedResults: []ImportResult{
+				{ID: 1, Outcome: ImportUpdated},
+				{ID: 2, Outcome: ImportCreated},
+				{ID: 5, Outcome: ImportFailed, Err: ErrInvalidTag},
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			qs := NewService(NewRepository())
+			question := existing
+			if err := qs.Create(ctx, &question); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := Import(ctx, qs, imported, tc.Options)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectedErr, err)
+			}
+			if err == nil {
+				if diff := cmp.Diff(tc.ExpectedResults, report.Results, cmp.Comparer(func(a, b error) bool {
+					return errors.Is(a, b) || errors.Is(b, a)
+				})); diff != "" {
+					t.Fatal(diff)
+				}
+			}
+
+			questions, err := qs.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	// The questions given are left untouched.
+	if imported[1].ID != 0 {
+		t.Fatalf("expected question ID: 0, got: %d", imported[1].ID)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package questionnaire
+
+import "os"
+
+// lockFile is a no-op where flock is not available, the stores must not be opened by two processes there.
+func lockFile(f *os.File, wait bool) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package questionnaire
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on f, waiting for it to be released by another open file when wait is set.
+// errFileLocked is returned when it's held and wait is not set. The lock is released when f is closed.
+func lockFile(f *os.File, wait bool) error {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+
+	err := syscall.Flock(int(f.Fd()), how)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errFileLocked
+	}
+	if err != nil {
+		return fmt.Errorf("could not lock %q: %w", f.Name(), err)
+	}
+
+	return nil
+}
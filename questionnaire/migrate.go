@@ -0,0 +1,119 @@
+package questionnaire
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFS holds the schema migrations of the SQL repository, every file is named
+// "<version>_<name>.sql" and is applied exactly once, in ascending version order.
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFS.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %q is not named \"<version>_<name>.sql\"", entry.Name())
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %q has invalid version: %w", entry.Name(), err)
+		}
+
+		b, err := migrationFS.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{Version: version, Name: name, SQL: string(b)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// migrate brings the schema of db up to the latest migration, returns the resulting schema version.
+func migrate(ctx context.Context, db *sql.DB) (int, error) {
+	return migrateTo(ctx, db, math.MaxInt)
+}
+
+// migrateTo brings the schema of db up to the target version, returns the resulting schema version.
+// Each migration runs in its own transaction together with its bookkeeping row, so a failing migration
+// leaves the schema at the previous version.
+func migrateTo(ctx context.Context, db *sql.DB, target int) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("could not load migrations: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER   PRIMARY KEY,
+		name       TEXT      NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return 0, fmt.Errorf("could not create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return 0, fmt.Errorf("could not get schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, m); err != nil {
+			return current, fmt.Errorf("could not apply migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		current = m.Version
+	}
+
+	return current, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,91 @@
+package questionnaire
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	_ "modernc.org/sqlite"
+)
+
+func TestMigrate(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i-1].Version == migrations[i].Version {
+			t.Fatalf("migration version %d is used twice", migrations[i].Version)
+		}
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "questions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Migrating twice must be a no-op the second time.
+	for i := 0; i < 2; i++ {
+		version, err := migrate(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != latest {
+			t.Fatalf("expected schema version: %d, got: %d", latest, version)
+		}
+
+		var applied int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+			t.Fatal(err)
+		}
+		if applied != len(migrations) {
+			t.Fatalf("expected %d applied migrations, got: %d", len(migrations), applied)
+		}
+	}
+}
+
+func TestMigrateKeepsExistingBank(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.db")
+	)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bank created by the first release, before any column was added.
+	if _, err := migrateTo(ctx, db, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO questions (id, question, answer) VALUES (1, '1 + 1?', '2')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	r := openSQL(t, path)
+	question, err := r.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1}, question); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Its question is folded once opened, so that it's found.
+	page, err := r.Find(ctx, Query{Contains: "1 + 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 {
+		t.Fatalf("expected question 1 to be found, got: %v", page.Questions)
+	}
+}
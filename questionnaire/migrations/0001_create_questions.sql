@@ -0,0 +1,9 @@
+-- seq keeps the insertion order of the questions, id is the number chosen by the author.
+CREATE TABLE questions (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	id       INTEGER NOT NULL,
+	question TEXT    NOT NULL,
+	answer   TEXT    NOT NULL
+);
+
+CREATE UNIQUE INDEX questions_id ON questions (id);
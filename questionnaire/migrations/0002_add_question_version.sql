@@ -0,0 +1,2 @@
+-- Questions created before versioning start from version 1.
+ALTER TABLE questions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
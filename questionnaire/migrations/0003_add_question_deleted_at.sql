@@ -0,0 +1,4 @@
+-- deleted_at is the time, in unix nanoseconds, the question was moved to trash, NULL when it's not in trash.
+ALTER TABLE questions ADD COLUMN deleted_at INTEGER;
+
+CREATE INDEX questions_deleted_at ON questions (deleted_at);
@@ -0,0 +1,12 @@
+-- Revisions are never updated or deleted, old and new are the question before and after the change encoded
+-- as JSON, NULL when there is none. at is the time of the change in unix nanoseconds.
+CREATE TABLE question_revisions (
+	question_id INTEGER NOT NULL,
+	number      INTEGER NOT NULL,
+	change      TEXT    NOT NULL,
+	author      TEXT    NOT NULL,
+	at          INTEGER NOT NULL,
+	old         TEXT,
+	new         TEXT,
+	PRIMARY KEY (question_id, number)
+);
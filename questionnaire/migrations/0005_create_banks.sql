@@ -0,0 +1,32 @@
+-- Questions and their revisions belong to a bank, the questions created before banks belong to the default one.
+-- created_at is the time, in unix nanoseconds, the bank was created.
+CREATE TABLE banks (
+	name       TEXT    PRIMARY KEY,
+	created_at INTEGER NOT NULL
+);
+
+INSERT INTO banks (name, created_at) VALUES ('default', 0);
+
+ALTER TABLE questions ADD COLUMN bank TEXT NOT NULL DEFAULT 'default' REFERENCES banks (name);
+
+-- Question IDs are unique within a bank.
+DROP INDEX questions_id;
+CREATE UNIQUE INDEX questions_bank_id ON questions (bank, id);
+
+CREATE TABLE question_revisions_by_bank (
+	bank        TEXT    NOT NULL DEFAULT 'default' REFERENCES banks (name),
+	question_id INTEGER NOT NULL,
+	number      INTEGER NOT NULL,
+	change      TEXT    NOT NULL,
+	author      TEXT    NOT NULL,
+	at          INTEGER NOT NULL,
+	old         TEXT,
+	new         TEXT,
+	PRIMARY KEY (bank, question_id, number)
+);
+
+INSERT INTO question_revisions_by_bank (question_id, number, change, author, at, old, new)
+	SELECT question_id, number, change, author, at, old, new FROM question_revisions;
+
+DROP TABLE question_revisions;
+ALTER TABLE question_revisions_by_bank RENAME TO question_revisions;
@@ -0,0 +1,7 @@
+-- tags are the sorted tags of the question joined and surrounded by commas, e.g. ",hard,math,", so a tag is matched
+-- with instr(tags, ',<tag>,'). Empty when the question has no tag.
+ALTER TABLE questions ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+-- category is the category path of the question, e.g. "science/physics", empty when it has none.
+ALTER TABLE questions ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX questions_bank_category ON questions (bank, category);
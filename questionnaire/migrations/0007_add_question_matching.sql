@@ -0,0 +1,3 @@
+-- matching are the sorted matching options of the answer of the question joined and surrounded by commas like the
+-- tags, e.g. ",strict-percent,strict-zeros,". Empty when the question has none.
+ALTER TABLE questions ADD COLUMN matching TEXT NOT NULL DEFAULT '';
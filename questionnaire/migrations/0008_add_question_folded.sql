@@ -0,0 +1,3 @@
+-- question_folded is the question in lower case, folded in Go as SQLite lower() only folds ASCII letters. Questions
+-- written before it existed are NULL until the repository is opened, it folds them then.
+ALTER TABLE questions ADD COLUMN question_folded TEXT;
@@ -1,7 +1,35 @@
 package questionnaire
 
+import "time"
+
 type Question struct {
-	ID       int
-	Question string
-	Answer   string
+	ID       int    `json:"id"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	// Version starts from 1 when the question is created and is incremented by every update.
+	Version int `json:"version"`
+	// DeletedAt is the time the question was moved to trash, nil when it's not in trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Tags are sorted and unique, see NormalizeTags.
+	Tags []string `json:"tags,omitempty"`
+	// Category is a path of category names from the broadest one, e.g. "science/physics", see NormalizeCategory.
+	Category string `json:"category,omitempty"`
+	// Matching are the matching options of the answer, sorted and unique, see NormalizeMatching.
+	Matching []string `json:"matching,omitempty"`
+}
+
+// Trashed reports whether the question is in trash.
+func (q *Question) Trashed() bool {
+	return q.DeletedAt != nil
+}
+
+// clone returns a copy of the question not sharing its tags and its matching options.
+func (q Question) clone() Question {
+	if q.Tags != nil {
+		q.Tags = append([]string(nil), q.Tags...)
+	}
+	if q.Matching != nil {
+		q.Matching = append([]string(nil), q.Matching...)
+	}
+	return q
 }
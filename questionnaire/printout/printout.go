@@ -0,0 +1,167 @@
+// Package printout renders questions as printable quiz sheets, a numbered question sheet and its answer key,
+// written in Markdown or in self-contained HTML.
+//
+// A sheet is rendered through a Layout, a set of templates holding the template "sheet" rendering the question
+// sheet and the template "key" rendering the answer key, both executed with a *Sheet. Markdown layouts are
+// text/template templates with the function md escaping Markdown, HTML layouts are html/template templates. The
+// default layouts are in the layouts directory, a good start to write one.
+package printout
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown printout format, should be .md, .markdown, .html or .htm")
+	ErrInvalidLayout = errors.New("invalid layout, should define the templates \"sheet\" and \"key\"")
+)
+
+// Format is the language a sheet is written in.
+type Format int
+
+const (
+	Markdown Format = iota
+	HTML
+)
+
+// FormatOf returns the format of the file at path, told by its extension.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return Markdown, nil
+	case ".html", ".htm":
+		return HTML, nil
+	}
+	return 0, fmt.Errorf("%q: %w", path, ErrUnknownFormat)
+}
+
+// Sheet is the data the templates of a layout are executed with.
+type Sheet struct {
+	Title string
+	Items []Item
+}
+
+// Item is a question of a sheet.
+type Item struct {
+	// No is the number of the question on the sheet, starting from 1.
+	No       int
+	Question questionnaire.Question
+	// PageBreak hints a page break before the question.
+	PageBreak bool
+}
+
+// Options configures NewSheet.
+type Options struct {
+	Title string
+	// Shuffle shuffles the questions from Seed, the same seed gives the same order.
+	Shuffle bool
+	Seed    int64
+	// PageBreak hints a page break every PageBreak questions, 0 for none.
+	PageBreak int
+}
+
+// NewSheet returns the sheet of questions, numbered in the order given or shuffled as opts tells.
+func NewSheet(questions []questionnaire.Question, opts Options) *Sheet {
+	questions = append([]questionnaire.Question(nil), questions...)
+	if opts.Shuffle {
+		rnd := rand.New(rand.NewSource(opts.Seed))
+		rnd.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	}
+
+	sheet := &Sheet{Title: opts.Title, Items: make([]Item, len(questions))}
+	for i, question := range questions {
+		sheet.Items[i] = Item{
+			No:        i + 1,
+			Question:  question,
+			PageBreak: opts.PageBreak > 0 && i > 0 && i%opts.PageBreak == 0,
+		}
+	}
+
+	return sheet
+}
+
+// executor is what text/template and html/template templates have in common.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// Layout renders sheets in a format.
+type Layout struct {
+	templates executor
+}
+
+//go:embed layouts
+var layouts embed.FS
+
+// layoutFiles are the default layouts by format.
+var layoutFiles = map[Format]string{Markdown: "layouts/markdown.tmpl", HTML: "layouts/html.tmpl"}
+
+// DefaultLayout returns the default layout of format, returns error if any.
+func DefaultLayout(format Format) (*Layout, error) {
+	name, ok := layoutFiles[format]
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	b, err := layouts.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLayout(format, string(b))
+}
+
+// ParseLayout parses the templates of a layout written in format, returns error if any.
+func ParseLayout(format Format, text string) (*Layout, error) {
+	switch format {
+	case Markdown:
+		t, err := texttemplate.New("layout").Funcs(texttemplate.FuncMap{"md": EscapeMarkdown}).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		if t.Lookup("sheet") == nil || t.Lookup("key") == nil {
+			return nil, ErrInvalidLayout
+		}
+		return &Layout{templates: t}, nil
+	case HTML:
+		t, err := htmltemplate.New("layout").Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		if t.Lookup("sheet") == nil || t.Lookup("key") == nil {
+			return nil, ErrInvalidLayout
+		}
+		return &Layout{templates: t}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// RenderSheet renders the question sheet of sheet, returns error if any.
+func (l *Layout) RenderSheet(w io.Writer, sheet *Sheet) error {
+	return l.templates.ExecuteTemplate(w, "sheet", sheet)
+}
+
+// RenderKey renders the answer key of sheet, returns error if any.
+func (l *Layout) RenderKey(w io.Writer, sheet *Sheet) error {
+	return l.templates.ExecuteTemplate(w, "key", sheet)
+}
+
+// markdownEscaper escapes the characters of s read as Markdown, a line break is written as an HTML line break
+// so it breaks lines in table cells as well.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+	"\r\n", "<br>", "\n", "<br>",
+)
+
+// EscapeMarkdown escapes s to be written as text in Markdown.
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
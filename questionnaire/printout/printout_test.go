@@ -0,0 +1,163 @@
+package printout
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestNewSheet(t *testing.T) {
+	questions := make([]questionnaire.Question, 10)
+	for i := range questions {
+		questions[i] = questionnaire.Question{ID: i + 1}
+	}
+	ids := func(sheet *Sheet) (ids []int) {
+		for i, item := range sheet.Items {
+			if item.No != i+1 {
+				t.Fatalf("expected item %d numbered %d, got: %d", i, i+1, item.No)
+			}
+			ids = append(ids, item.Question.ID)
+		}
+		return ids
+	}
+
+	sheet := NewSheet(questions, Options{PageBreak: 4})
+	if diff := cmp.Diff([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, ids(sheet)); diff != "" {
+		t.Fatal(diff)
+	}
+	var breaks []int
+	for _, item := range sheet.Items {
+		if item.PageBreak {
+			breaks = append(breaks, item.No)
+		}
+	}
+	if diff := cmp.Diff([]int{5, 9}, breaks); diff != "" {
+		t.Fatal(diff)
+	}
+
+	shuffled := ids(NewSheet(questions, Options{Shuffle: true, Seed: 42}))
+	if diff := cmp.Diff(shuffled, ids(NewSheet(questions, Options{Shuffle: true, Seed: 42}))); diff != "" {
+		t.Fatalf("expected the same order from the same seed: %s", diff)
+	}
+	if cmp.Equal(shuffled, ids(NewSheet(questions, Options{Shuffle: true, Seed: 43}))) {
+		t.Fatalf("expected another order from another seed, got: %v", shuffled)
+	}
+	sort.Ints(shuffled)
+	if diff := cmp.Diff(ids(sheet), shuffled); diff != "" {
+		t.Fatalf("expected every question once: %s", diff)
+	}
+	if questions[0].ID != 1 {
+		t.Fatalf("expected questions left as they are, got: %v", questions)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	sheet := NewSheet([]questionnaire.Question{
+		{ID: 4, Question: "Is 1 < 2?\nSay *yes*", Answer: "yes | no"},
+		{ID: 7, Question: "What is H2O?", Answer: "water"},
+	}, Options{Title: "Trivia <night>", PageBreak: 1})
+
+	tt := []struct {
+		Name        string
+		Format      Format
+		ExpectedOut []string // parts of the sheet and of the key, in order
+	}{
+		{
+			Name:   "markdown",
+			Format: Markdown,
+			ExpectedOut: []string{
+				"# Trivia \\<night\\>\n", "1. Is 1 \\< 2?<br>Say \\*yes\\*\n", "<div style=\"break-before: page\"></div>\n\n2. What is H2O?\n",
+				"# Trivia \\<night\\>: answer key\n", "| 1 | yes \\| no | 4 |\n| 2 | water | 7 |\n",
+			},
+		},
+		{
+			Name:   "html",
+			Format: HTML,
+			ExpectedOut: []string{
+				"<title>Trivia &lt;night&gt;</title>", "<span class=\"question\">Is 1 &lt; 2?\nSay *yes*</span>",
+				"<li value=\"2\" class=\"page-break\"><span class=\"question\">What is H2O?</span>",
+				"<title>Trivia &lt;night&gt;: answer key</title>", "<tr><td>1</td><td>yes | no</td><td>4</td></tr>",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			layout, err := DefaultLayout(tc.Format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			if err := layout.RenderSheet(&out, sheet); err != nil {
+				t.Fatal(err)
+			}
+			if err := layout.RenderKey(&out, sheet); err != nil {
+				t.Fatal(err)
+			}
+
+			rest := out.String()
+			for _, part := range tc.ExpectedOut {
+				i := strings.Index(rest, part)
+				if i < 0 {
+					t.Fatalf("expected %q in:\n%s", part, out.String())
+				}
+				rest = rest[i+len(part):]
+			}
+		})
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	tt := []struct {
+		Name        string
+		Format      Format
+		Text        string
+		ExpectedOut string
+		ExpectedErr error
+	}{
+		{
+			Name:        "markdown",
+			Format:      Markdown,
+			Text:        `{{define "sheet"}}{{range .Items}}{{.No}}) {{md .Question.Question}}{{end}}{{end}}{{define "key"}}{{end}}`,
+			ExpectedOut: `1) 1 \* 1?`,
+		},
+		{
+			Name:        "html",
+			Format:      HTML,
+			Text:        `{{define "sheet"}}{{range .Items}}<p>{{.Question.Question}}</p>{{end}}{{end}}{{define "key"}}{{end}}`,
+			ExpectedOut: `<p>1 * 1?</p>`,
+		},
+		{
+			Name:        "missing key",
+			Format:      HTML,
+			Text:        `{{define "sheet"}}{{end}}`,
+			ExpectedErr: ErrInvalidLayout,
+		},
+	}
+
+	sheet := NewSheet([]questionnaire.Question{{ID: 1, Question: "1 * 1?", Answer: "1"}}, Options{})
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			layout, err := ParseLayout(tc.Format, tc.Text)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			var out strings.Builder
+			if err := layout.RenderSheet(&out, sheet); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.ExpectedOut {
+				t.Fatalf("expected: %s, got: %s", tc.ExpectedOut, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package questionnaire
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortOrder is the order of questions returned by a Query.
+type SortOrder int
+
+const (
+	SortByIDAsc SortOrder = iota
+	SortByIDDesc
+	SortByQuestionAsc
+	SortByQuestionDesc
+)
+
+var sortOrderNames = map[SortOrder]string{
+	SortByIDAsc:        "id-asc",
+	SortByIDDesc:       "id-desc",
+	SortByQuestionAsc:  "question-asc",
+	SortByQuestionDesc: "question-desc",
+}
+
+func (o SortOrder) String() string {
+	if name, ok := sortOrderNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("SortOrder(%d)", int(o))
+}
+
+// ParseSortOrder parses sort order written as "<field>[-asc|-desc]", field is "id" or "question".
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch strings.ToLower(s) {
+	case "id", "id-asc":
+		return SortByIDAsc, nil
+	case "id-desc":
+		return SortByIDDesc, nil
+	case "question", "question-asc":
+		return SortByQuestionAsc, nil
+	case "question-desc":
+		return SortByQuestionDesc, nil
+	}
+	return 0, fmt.Errorf("unknown sort order %q, should be one of: id, id-desc, question, question-desc", s)
+}
+
+// Query selects a page of questions. The zero value selects all questions sorted by ID.
+type Query struct {
+	MinID    *int      // inclusive lower bound of question ID, nil means unbounded
+	MaxID    *int      // inclusive upper bound of question ID, nil means unbounded
+	Contains string    // case-insensitive substring of the question text
+	Tags     *TagExpr  // expression the tags of the question must satisfy, nil means any tags
+	Category string    // normalized category of the question, its subcategories included, empty means any category
+	Sort     SortOrder // order of the questions, ascending ID by default
+	Cursor   string    // continue right after the question the cursor was issued for, see Page.NextCursor
+	Offset   int       // number of questions to skip, applied after Cursor
+	Limit    int       // maximum number of questions in the page, 0 means no limit
+	Trashed  bool      // select the questions in trash instead of the ones not in trash
+}
+
+// Page is the result of a Query.
+type Page struct {
+	Questions []Question
+	// Total is the number of questions matching the filters of the query, regardless of the paging.
+	Total int
+	// NextCursor continues the query right after the last question of this page,
+	// it's empty when there are no more questions.
+	NextCursor string
+}
+
+// cursor is the position of a question in a sort order, it's handed out base64 encoded.
+type cursor struct {
+	Sort     SortOrder `json:"s"`
+	ID       int       `json:"i"`
+	Question string    `json:"q,omitempty"`
+}
+
+func newCursor(sort SortOrder, question *Question) string {
+	c := cursor{Sort: sort, ID: question.ID}
+	if sort == SortByQuestionAsc || sort == SortByQuestionDesc {
+		c.Question = question.Question
+	}
+
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes s issued for a query sorted by sort.
+func decodeCursor(s string, sort SortOrder) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("cursor was issued for sort order %s: %w", c.Sort, ErrInvalidCursor)
+	}
+
+	return &c, nil
+}
+
+// match reports whether question passes the filters of q.
+func (q *Query) match(question *Question) bool {
+	if q.Trashed != question.Trashed() {
+		return false
+	}
+	if q.MinID != nil && question.ID < *q.MinID {
+		return false
+	}
+	if q.MaxID != nil && question.ID > *q.MaxID {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(strings.ToLower(question.Question), strings.ToLower(q.Contains)) {
+		return false
+	}
+	if q.Tags != nil && !q.Tags.Match(question.Tags) {
+		return false
+	}
+	if q.Category != "" && !inCategory(question.Category, q.Category) {
+		return false
+	}
+	return true
+}
+
+// less reports whether question a is sorted before question b.
+func (q *Query) less(a, b *Question) bool {
+	switch q.Sort {
+	case SortByIDDesc:
+		return a.ID > b.ID
+	case SortByQuestionAsc:
+		if a.Question != b.Question {
+			return a.Question < b.Question
+		}
+		return a.ID < b.ID
+	case SortByQuestionDesc:
+		if a.Question != b.Question {
+			return a.Question > b.Question
+		}
+		return a.ID > b.ID
+	default:
+		return a.ID < b.ID
+	}
+}
+
+// findQuestions runs q against questions, questions is not modified.
+func findQuestions(questions []Question, q Query) (*Page, error) {
+	var after *Question
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		after = &Question{ID: c.ID, Question: c.Question}
+	}
+
+	matches := make([]Question, 0)
+	for i := range questions {
+		if q.match(&questions[i]) {
+			matches = append(matches, questions[i].clone())
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return q.less(&matches[i], &matches[j]) })
+
+	page := &Page{Total: len(matches)}
+
+	if after != nil {
+		start := sort.Search(len(matches), func(i int) bool { return q.less(after, &matches[i]) })
+		matches = matches[start:]
+	}
+	if q.Offset > 0 {
+		matches = matches[min(q.Offset, len(matches)):]
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+		page.NextCursor = newCursor(q.Sort, &matches[len(matches)-1])
+	}
+	page.Questions = matches
+
+	return page, nil
+}
@@ -0,0 +1,188 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	tt := []struct {
+		Input     string
+		Expected  SortOrder
+		ExpectErr bool
+	}{
+		{Input: "id", Expected: SortByIDAsc},
+		{Input: "id-asc", Expected: SortByIDAsc},
+		{Input: "ID-DESC", Expected: SortByIDDesc},
+		{Input: "question", Expected: SortByQuestionAsc},
+		{Input: "question-desc", Expected: SortByQuestionDesc},
+		{Input: "answer", ExpectErr: true},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Input, func(t *testing.T) {
+			sort, err := ParseSortOrder(tc.Input)
+			if tc.ExpectErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.ExpectErr, err)
+			}
+			if sort != tc.Expected {
+				t.Fatalf("expected: %s, got: %s", tc.Expected, sort)
+			}
+		})
+	}
+}
+
+func TestRepositoryFind(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1,
+			Tags: []string{"count", "easy"}, Category: "language"}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1,
+			Tags: []string{"hard", "math"}, Category: "math"}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
+		question4 = Question{ID: 4, Question: "How many legs does a spider have?", Answer: "8", Version: 1,
+			Tags: []string{"count", "math"}, Category: "science/biology"}
+		question5 = Question{ID: 5, Question: "How many characters are there in \"Ingénieur\"?", Answer: "8", Version: 1,
+			Tags: []string{"count"}, Category: "language/english"}
+
+		// Created out of ID order to make sure results are sorted rather than in insertion order.
+		questions = []Question{question3, question1, question5, question2, question4}
+	)
+
+	intPtr := func(v int) *int { return &v }
+
+	tt := []struct {
+		Name          string
+		Query         Query
+		ExpectedPages []Page // every next page is fetched with the NextCursor of the previous one
+		ExpectedErr   error
+	}{
+		{
+			Name:          "zero query, all questions sorted by ID",
+			Query:         Query{},
+			ExpectedPages: []Page{{Questions: []Question{question1, question2, question3, question4, question5}, Total: 5}},
+		},
+		{
+			Name:          "ID range",
+			Query:         Query{MinID: intPtr(2), MaxID: intPtr(4)},
+			ExpectedPages: []Page{{Questions: []Question{question2, question3, question4}, Total: 3}},
+		},
+		{
+			Name:          "contains, case-insensitive",
+			Query:         Query{Contains: "QUIPPER"},
+			ExpectedPages: []Page{{Questions: []Question{question1, question3}, Total: 2}},
+		},
+		{
+			Name:          "contains, case-insensitive beyond ASCII",
+			Query:         Query{Contains: "INGÉNIEUR"},
+			ExpectedPages: []Page{{Questions: []Question{question5}, Total: 1}},
+		},
+		{
+			Name:          "tag expression",
+			Query:         Query{Tags: mustParseTagExpr(t, "(math OR count) AND NOT (hard OR easy)")},
+			ExpectedPages: []Page{{Questions: []Question{question4, question5}, Total: 2}},
+		},
+		{
+			Name:          "not tagged",
+			Query:         Query{Tags: mustParseTagExpr(t, "NOT count")},
+			ExpectedPages: []Page{{Questions: []Question{question2, question3}, Total: 2}},
+		},
+		{
+			Name:          "category and its subcategories",
+			Query:         Query{Category: "language"},
+			ExpectedPages: []Page{{Questions: []Question{question1, question5}, Total: 2}},
+		},
+		{
+			Name:          "category prefix of another category",
+			Query:         Query{Category: "science/bio"},
+			ExpectedPages: []Page{{Questions: []Question{}, Total: 0}},
+		},
+		{
+			Name:          "sort by ID descending with offset and limit",
+			Query:         Query{Sort: SortByIDDesc, Offset: 2, Limit: 3},
+			ExpectedPages: []Page{{Questions: []Question{question3, question2, question1}, Total: 5}},
+		},
+		{
+			Name:          "offset past the end",
+			Query:         Query{Offset: 10},
+			ExpectedPages: []Page{{Questions: []Question{}, Total: 5}},
+		},
+		{
+			Name:  "cursor through all pages sorted by ID",
+			Query: Query{Limit: 2},
+			ExpectedPages: []Page{
+				{Questions: []Question{question1, question2}, Total: 5},
+				{Questions: []Question{question3, question4}, Total: 5},
+				{Questions: []Question{question5}, Total: 5},
+			},
+		},
+		{
+			Name:  "cursor through all pages sorted by question descending",
+			Query: Query{Sort: SortByQuestionDesc, Limit: 2, Contains: "how many"},
+			ExpectedPages: []Page{
+				{Questions: []Question{question4, question1}, Total: 3},
+				{Questions: []Question{question5}, Total: 3},
+			},
+		},
+		{
+			Name:        "invalid cursor",
+			Query:       Query{Cursor: "not a cursor"},
+			ExpectedErr: ErrInvalidCursor,
+		},
+		{
+			Name:        "cursor of other sort order",
+			Query:       Query{Sort: SortByIDDesc, Cursor: newCursor(SortByIDAsc, &question1)},
+			ExpectedErr: ErrInvalidCursor,
+		},
+	}
+
+	ctx := context.Background()
+
+	repositories := map[string]Repository{
+		"inmem": NewRepository(),
+		"sql":   openSQL(t, filepath.Join(t.TempDir(), "questions.db")),
+	}
+
+	for name, r := range repositories {
+		for i := range questions {
+			if err := r.Create(ctx, &questions[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, tc := range tt {
+			tc, r := tc, r
+			t.Run(name+"/"+tc.Name, func(t *testing.T) {
+				query := tc.Query
+				for i, expected := range tc.ExpectedPages {
+					page, err := r.Find(ctx, query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if diff := cmp.Diff(expected.Questions, page.Questions); diff != "" {
+						t.Fatalf("page %d: %s", i+1, diff)
+					}
+					if page.Total != expected.Total {
+						t.Fatalf("page %d: expected total: %d, got: %d", i+1, expected.Total, page.Total)
+					}
+
+					isLast := i == len(tc.ExpectedPages)-1
+					if isLast != (page.NextCursor == "") {
+						t.Fatalf("page %d: expected next cursor: %v, got: %q", i+1, !isLast, page.NextCursor)
+					}
+					query.Cursor = page.NextCursor
+				}
+
+				if tc.ExpectedErr != nil {
+					if _, err := r.Find(ctx, query); !errors.Is(err, tc.ExpectedErr) {
+						t.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -3,101 +3,770 @@ package questionnaire
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 var (
 	ErrQuestionNotFound       = errors.New("question not found")
 	ErrQuestionIsAlreadyExist = errors.New("question is already exist")
+	ErrQuestionInTrash        = errors.New("question is in trash")
+	ErrCompactionNotSupported = errors.New("store does not support compaction")
+	ErrVersionConflict        = errors.New("question version conflict")
 )
 
+// VersionConflictError is returned by conditional update when the question is no longer
+// at the expected version, it matches ErrVersionConflict with errors.Is.
+type VersionConflictError struct {
+	ID       int
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("question %d is at version %d, expected version %d", e.ID, e.Current, e.Expected)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// Repository keeps questions in banks, every method working on questions and revisions works on the bank
+// carried by ctx, see WithBank. A bank that doesn't exist is read as an empty one.
+// The questions in trash are hidden from every method but Find with Query.Trashed, Create, Delete, Restore and Purge.
 type Repository interface {
+	// GetBanks gets all banks ordered by name, returns error if any
+	GetBanks(ctx context.Context) ([]Bank, error)
+	// CreateBank creates an empty bank, returns error if any
+	CreateBank(ctx context.Context, bank *Bank) error
 	// GetByID gets question by id, returns error if any
 	GetByID(ctx context.Context, id int) (*Question, error)
 	// GetAll gets questions, returns error if any
 	GetAll(ctx context.Context) ([]Question, error)
-	// Create creates question, returns error if any
+	// Find gets a page of questions selected by query, returns error if any
+	Find(ctx context.Context, query Query) (*Page, error)
+	// GetMaxID gets the highest positive ID taken, trash included, 0 when there is none, returns error if any
+	GetMaxID(ctx context.Context) (int, error)
+	// GetFirstFreeID gets the lowest positive ID not taken, trash included, returns error if any
+	GetFirstFreeID(ctx context.Context) (int, error)
+	// Create creates question at version 1, returns ErrBankNotFound if the bank doesn't exist, error if any
 	Create(ctx context.Context, question *Question) error
-	// Update updates existing question, return error if any
+	// Update updates existing question and increments its version, return error if any
 	Update(ctx context.Context, question *Question) error
-	// Delete deletes existing question, return error if any
+	// UpdateIfVersion updates existing question only if it's still at given version, return error if any
+	UpdateIfVersion(ctx context.Context, question *Question, version int) error
+	// Delete deletes existing question for good, wherever it is, return error if any
 	Delete(ctx context.Context, id int) error
+	// Trash moves existing question to trash, deleted at given time, return error if any
+	Trash(ctx context.Context, id int, at time.Time) error
+	// Restore moves question in trash back, return error if any
+	Restore(ctx context.Context, id int) error
+	// Purge deletes for good the questions moved to trash at or before given time, returns the number of
+	// questions deleted and error if any
+	Purge(ctx context.Context, before time.Time) (int, error)
+	// AddRevision appends revision to the history of its question and sets its number, returns error if any
+	AddRevision(ctx context.Context, revision *Revision) error
+	// GetRevisions gets the history of question id, oldest first, returns error if any
+	GetRevisions(ctx context.Context, id int) ([]Revision, error)
+	// WithTx runs fn in a transaction, the changes made through tx are committed when fn returns nil
+	// and rolled back when fn returns error or the commit fails, returns error if any
+	WithTx(ctx context.Context, fn func(tx Repository) error) error
+}
+
+// Compactor is implemented by Repository whose storage grows with every change and can be shrunk.
+type Compactor interface {
+	// Compact rewrites the storage into its smallest form, returns error if any
+	Compact(ctx context.Context) error
 }
 
 func NewRepository() Repository {
-	return &inmemRepository{
-		questions: make([]Question, 0),
-	}
+	return newInmemRepository(make([]Question, 0))
 }
 
+// newInmemRepository creates inmemRepository holding given questions in DefaultBank, it takes ownership of questions.
+func newInmemRepository(questions []Question) *inmemRepository {
+	r := &inmemRepository{}
+	r.reset(inmemState{Questions: questions})
+
+	return r
+}
+
+// inmemRepository keeps the questions of every bank apart, a bank keeps its questions in insertion order.
+// Every method checks and mutates under a single lock acquisition and never hands out its internal storage,
+// so it's safe for concurrent use.
 type inmemRepository struct {
-	mu        sync.RWMutex
-	questions []Question
+	mu    sync.RWMutex
+	banks map[string]*inmemBank // bank name -> bank, DefaultBank is always there
 }
 
-func (r *inmemRepository) GetByID(ctx context.Context, id int) (*Question, error) {
+// inmemBank keeps questions in insertion order, the tags of the questions are never modified in place so they
+// can be shared by copies of the bank. Every question is tagged with an ever increasing
+// insertion sequence, the index maps question ID to that sequence and the position is found by binary search,
+// so deleting a question only shifts the slices and never invalidates the index.
+type inmemBank struct {
+	Bank
+	questions []Question     // in insertion order
+	seqs      []uint64       // insertion sequence of questions[i], ascending
+	index     map[int]uint64 // question ID -> insertion sequence
+	nextSeq   uint64
+	revisions map[int][]Revision // question ID -> history, oldest first
+}
+
+func newInmemBank(bank Bank, questions []Question) *inmemBank {
+	b := &inmemBank{
+		Bank:      bank,
+		questions: questions,
+		seqs:      make([]uint64, len(questions)),
+		index:     make(map[int]uint64, len(questions)),
+		nextSeq:   uint64(len(questions)),
+		revisions: make(map[int][]Revision),
+	}
+	for i := range questions {
+		b.seqs[i] = uint64(i)
+		b.index[questions[i].ID] = uint64(i)
+	}
+
+	return b
+}
+
+// emptyBank stands for a bank that doesn't exist when reading, it must never be changed.
+var emptyBank = newInmemBank(Bank{}, nil)
+
+// bank returns the bank ctx is scoped to, emptyBank when it doesn't exist, the caller must hold the lock.
+func (r *inmemRepository) bank(ctx context.Context) *inmemBank {
+	if b, ok := r.banks[BankFromContext(ctx)]; ok {
+		return b
+	}
+	return emptyBank
+}
+
+func (r *inmemRepository) GetBanks(ctx context.Context) ([]Bank, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, question := range r.questions {
-		if id == question.ID {
-			return &question, nil
-		}
-	}
+	return r.getBanks(), nil
+}
+
+func (r *inmemRepository) CreateBank(ctx context.Context, bank *Bank) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return nil, ErrQuestionNotFound
+	_, err := r.createBank(bank)
+	return err
+}
+
+func (r *inmemRepository) GetByID(ctx context.Context, id int) (*Question, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getByID(ctx, id)
 }
 
 func (r *inmemRepository) GetAll(ctx context.Context) ([]Question, error) {
-	return r.questions, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getAll(ctx), nil
 }
 
-func (r *inmemRepository) Create(ctx context.Context, question *Question) error {
-	_, err := r.GetByID(ctx, question.ID)
-	if err == nil {
-		return ErrQuestionIsAlreadyExist
-	}
+func (r *inmemRepository) Find(ctx context.Context, query Query) (*Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
+	return findQuestions(r.bank(ctx).questions, query)
+}
+
+func (r *inmemRepository) GetMaxID(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.bank(ctx).maxID(), nil
+}
+
+func (r *inmemRepository) GetFirstFreeID(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.bank(ctx).firstFreeID(), nil
+}
+
+func (r *inmemRepository) Create(ctx context.Context, question *Question) error {
 	r.mu.Lock()
-	r.questions = append(r.questions, *question)
-	r.mu.Unlock()
+	defer r.mu.Unlock()
 
-	return nil
+	_, err := r.create(ctx, question)
+	return err
 }
 
 func (r *inmemRepository) Update(ctx context.Context, question *Question) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	for i := range r.questions {
-		if question.ID == r.questions[i].ID {
-			r.questions[i] = *question
-			return nil
+	_, err := r.update(ctx, question, nil)
+	return err
+}
+
+func (r *inmemRepository) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.update(ctx, question, &version)
+	return err
+}
+
+func (r *inmemRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.delete(ctx, id)
+	return err
+}
+
+func (r *inmemRepository) Trash(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.setDeletedAt(ctx, id, &at)
+	return err
+}
+
+func (r *inmemRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.setDeletedAt(ctx, id, nil)
+	return err
+}
+
+func (r *inmemRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, _ := r.purge(ctx, before)
+	return n, nil
+}
+
+func (r *inmemRepository) AddRevision(ctx context.Context, revision *Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.addRevision(ctx, revision)
+	return err
+}
+
+func (r *inmemRepository) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getRevisions(ctx, id), nil
+}
+
+// WithTx runs fn while holding the write lock, so transactions are serialized and never conflict. The changes
+// are made in place and undone in reverse order when fn returns error, only the questions they touched are copied.
+func (r *inmemRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	_, err := r.applyTx(fn)
+	return err
+}
+
+// applyTx runs fn in a transaction like WithTx and returns the function undoing its changes once committed. Undoing
+// them is only right as long as nothing else was changed since, the caller serializes the changes for that.
+func (r *inmemRepository) applyTx(fn func(tx Repository) error) (undo func(), err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &inmemTx{r: r}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.rollback(0)
 		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return nil, err
 	}
+	committed = true
 
-	return ErrQuestionNotFound
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		tx.rollback(0)
+	}, nil
 }
 
-func (r *inmemRepository) Delete(ctx context.Context, id int) error {
-	var index *int
+// The methods below read and change the repository, the caller must hold the lock: the read lock for reads and
+// the write lock for changes. Every change returns the function undoing it, it must be called before any later
+// change is undone.
+
+func (r *inmemRepository) getBanks() []Bank {
+	banks := make([]Bank, 0, len(r.banks))
+	for _, b := range r.banks {
+		banks = append(banks, b.Bank)
+	}
+	sort.Slice(banks, func(i, j int) bool { return banks[i].Name < banks[j].Name })
+
+	return banks
+}
+
+func (r *inmemRepository) createBank(bank *Bank) (undo func(), err error) {
+	if _, ok := r.banks[bank.Name]; ok {
+		return nil, ErrBankIsAlreadyExist
+	}
+
+	r.banks[bank.Name] = newInmemBank(*bank, make([]Question, 0))
+
+	return func() { delete(r.banks, bank.Name) }, nil
+}
+
+func (r *inmemRepository) getByID(ctx context.Context, id int) (*Question, error) {
+	b := r.bank(ctx)
+	i, ok := b.position(id)
+	if !ok || b.questions[i].Trashed() {
+		return nil, ErrQuestionNotFound
+	}
+	question := b.questions[i].clone()
+
+	return &question, nil
+}
+
+func (r *inmemRepository) getAll(ctx context.Context) []Question {
+	b := r.bank(ctx)
+	questions := make([]Question, 0, len(b.questions))
+	for i := range b.questions {
+		if !b.questions[i].Trashed() {
+			questions = append(questions, b.questions[i].clone())
+		}
+	}
+
+	return questions
+}
+
+func (r *inmemRepository) create(ctx context.Context, question *Question) (undo func(), err error) {
+	b, ok := r.banks[BankFromContext(ctx)]
+	if !ok {
+		return nil, ErrBankNotFound
+	}
+	if i, ok := b.position(question.ID); ok {
+		if b.questions[i].Trashed() {
+			return nil, ErrQuestionInTrash
+		}
+		return nil, ErrQuestionIsAlreadyExist
+	}
+
+	question.Version = 1
+	question.DeletedAt = nil
+	b.questions = append(b.questions, question.clone())
+	b.seqs = append(b.seqs, b.nextSeq)
+	b.index[question.ID] = b.nextSeq
+	b.nextSeq++
+
+	id := question.ID
+	return func() {
+		// Nothing added after it is left, it's the last question.
+		last := len(b.questions) - 1
+		b.questions[last] = Question{}
+		b.questions, b.seqs = b.questions[:last], b.seqs[:last]
+		delete(b.index, id)
+		b.nextSeq--
+	}, nil
+}
+
+// update replaces question, only when it's at version when version isn't nil.
+func (r *inmemRepository) update(ctx context.Context, question *Question, version *int) (undo func(), err error) {
+	b := r.bank(ctx)
+	i, ok := b.position(question.ID)
+	if !ok || b.questions[i].Trashed() {
+		return nil, ErrQuestionNotFound
+	}
+	if current := b.questions[i].Version; version != nil && current != *version {
+		return nil, &VersionConflictError{ID: question.ID, Expected: *version, Current: current}
+	}
+
+	previous := b.questions[i]
+	question.DeletedAt = nil
+	question.Version = previous.Version + 1
+	b.questions[i] = question.clone()
+
+	return func() { b.replace(previous) }, nil
+}
+
+func (r *inmemRepository) delete(ctx context.Context, id int) (undo func(), err error) {
+	b := r.bank(ctx)
+	i, ok := b.position(id)
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+	previous, seq := b.questions[i], b.seqs[i]
+
+	last := len(b.questions) - 1
+	copy(b.questions[i:], b.questions[i+1:])
+	b.questions[last] = Question{}
+	b.questions = b.questions[:last]
+
+	copy(b.seqs[i:], b.seqs[i+1:])
+	b.seqs = b.seqs[:last]
+
+	delete(b.index, id)
+
+	return func() {
+		// Everything after it is back where it was, so is its position.
+		b.questions = append(b.questions, Question{})
+		copy(b.questions[i+1:], b.questions[i:])
+		b.questions[i] = previous
+
+		b.seqs = append(b.seqs, 0)
+		copy(b.seqs[i+1:], b.seqs[i:])
+		b.seqs[i] = seq
+
+		b.index[id] = seq
+	}, nil
+}
+
+// setDeletedAt moves question id to trash at given time, or back from trash when at is nil.
+func (r *inmemRepository) setDeletedAt(ctx context.Context, id int, at *time.Time) (undo func(), err error) {
+	b := r.bank(ctx)
+	i, ok := b.position(id)
+	if !ok || b.questions[i].Trashed() != (at == nil) {
+		return nil, ErrQuestionNotFound
+	}
+
+	previous := b.questions[i]
+	b.questions[i].DeletedAt = at
+
+	return func() { b.replace(previous) }, nil
+}
+
+func (r *inmemRepository) purge(ctx context.Context, before time.Time) (int, func()) {
+	var (
+		b         = r.bank(ctx)
+		questions = append([]Question(nil), b.questions...)
+		seqs      = append([]uint64(nil), b.seqs...)
+	)
+
+	// Filter in place, questions and seqs stay aligned and ascending.
+	n := 0
+	for i := range b.questions {
+		if b.questions[i].Trashed() && !b.questions[i].DeletedAt.After(before) {
+			delete(b.index, b.questions[i].ID)
+			continue
+		}
+		b.questions[n], b.seqs[n] = b.questions[i], b.seqs[i]
+		n++
+	}
+
+	purged := len(b.questions) - n
+	if purged == 0 {
+		return 0, func() {}
+	}
+	for i := n; i < len(b.questions); i++ {
+		b.questions[i] = Question{}
+	}
+	b.questions, b.seqs = b.questions[:n], b.seqs[:n]
+
+	return purged, func() {
+		b.questions, b.seqs = questions, seqs
+		for i := range questions {
+			b.index[questions[i].ID] = seqs[i]
+		}
+	}
+}
+
+func (r *inmemRepository) addRevision(ctx context.Context, revision *Revision) (undo func(), err error) {
+	b, ok := r.banks[BankFromContext(ctx)]
+	if !ok {
+		return nil, ErrBankNotFound
+	}
+
+	id, history := revision.QuestionID, b.revisions[revision.QuestionID]
+	revision.Number = len(history) + 1
+	b.revisions[id] = append(history, revision.clone())
+
+	return func() {
+		if len(history) == 0 {
+			delete(b.revisions, id)
+			return
+		}
+		b.revisions[id] = history
+	}, nil
+}
+
+func (r *inmemRepository) getRevisions(ctx context.Context, id int) []Revision {
+	history := r.bank(ctx).revisions[id]
+	revisions := make([]Revision, len(history))
+	for i := range history {
+		revisions[i] = history[i].clone()
+	}
+
+	return revisions
+}
+
+// position returns the position of question id in questions, the caller must hold the lock.
+func (b *inmemBank) position(id int) (int, bool) {
+	seq, ok := b.index[id]
+	if !ok {
+		return 0, false
+	}
+
+	return sort.Search(len(b.seqs), func(i int) bool { return b.seqs[i] >= seq }), true
+}
+
+// replace puts question back where the question with the same ID is, the caller must hold the write lock.
+// maxID returns the highest positive ID taken, trash included, 0 when there is none.
+func (b *inmemBank) maxID() int {
+	highest := 0
+	for id := range b.index {
+		if id > highest {
+			highest = id
+		}
+	}
+
+	return highest
+}
+
+// firstFreeID returns the lowest positive ID not taken, trash included.
+func (b *inmemBank) firstFreeID() int {
+	id := 1
+	for {
+		if _, ok := b.index[id]; !ok {
+			return id
+		}
+		id++
+	}
+}
+
+func (b *inmemBank) replace(question Question) {
+	i, _ := b.position(question.ID)
+	b.questions[i] = question
+}
+
+// inmemTx is a transaction of inmemRepository, it's only used while WithTx holds the write lock. It keeps the
+// functions undoing its changes, oldest first. It's safe for concurrent use by the goroutines of the transaction.
+type inmemTx struct {
+	r    *inmemRepository
+	mu   sync.Mutex
+	undo []func()
+}
+
+func (t *inmemTx) GetBanks(ctx context.Context) ([]Bank, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.getBanks(), nil
+}
+
+func (t *inmemTx) CreateBank(ctx context.Context, bank *Bank) error {
+	return t.change(func() (func(), error) { return t.r.createBank(bank) })
+}
+
+func (t *inmemTx) GetByID(ctx context.Context, id int) (*Question, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.getByID(ctx, id)
+}
+
+func (t *inmemTx) GetAll(ctx context.Context) ([]Question, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.getAll(ctx), nil
+}
+
+func (t *inmemTx) Find(ctx context.Context, query Query) (*Page, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return findQuestions(t.r.bank(ctx).questions, query)
+}
 
+func (t *inmemTx) GetMaxID(ctx context.Context) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.bank(ctx).maxID(), nil
+}
+
+func (t *inmemTx) GetFirstFreeID(ctx context.Context) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.bank(ctx).firstFreeID(), nil
+}
+
+func (t *inmemTx) Create(ctx context.Context, question *Question) error {
+	return t.change(func() (func(), error) { return t.r.create(ctx, question) })
+}
+
+func (t *inmemTx) Update(ctx context.Context, question *Question) error {
+	return t.change(func() (func(), error) { return t.r.update(ctx, question, nil) })
+}
+
+func (t *inmemTx) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	return t.change(func() (func(), error) { return t.r.update(ctx, question, &version) })
+}
+
+func (t *inmemTx) Delete(ctx context.Context, id int) error {
+	return t.change(func() (func(), error) { return t.r.delete(ctx, id) })
+}
+
+func (t *inmemTx) Trash(ctx context.Context, id int, at time.Time) error {
+	return t.change(func() (func(), error) { return t.r.setDeletedAt(ctx, id, &at) })
+}
+
+func (t *inmemTx) Restore(ctx context.Context, id int) error {
+	return t.change(func() (func(), error) { return t.r.setDeletedAt(ctx, id, nil) })
+}
+
+func (t *inmemTx) Purge(ctx context.Context, before time.Time) (n int, err error) {
+	err = t.change(func() (undo func(), err error) {
+		n, undo = t.r.purge(ctx, before)
+		return undo, nil
+	})
+
+	return n, err
+}
+
+func (t *inmemTx) AddRevision(ctx context.Context, revision *Revision) error {
+	return t.change(func() (func(), error) { return t.r.addRevision(ctx, revision) })
+}
+
+func (t *inmemTx) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.r.getRevisions(ctx, id), nil
+}
+
+// WithTx runs fn as a part of the transaction, the changes made by fn are undone when it returns error.
+func (t *inmemTx) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	t.mu.Lock()
+	savepoint := len(t.undo)
+	t.mu.Unlock()
+
+	if err := fn(t); err != nil {
+		t.rollback(savepoint)
+		return err
+	}
+
+	return nil
+}
+
+// change makes a change of the transaction and keeps the function undoing it.
+func (t *inmemTx) change(fn func() (undo func(), err error)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	undo, err := fn()
+	if err != nil {
+		return err
+	}
+	t.undo = append(t.undo, undo)
+
+	return nil
+}
+
+// rollback undoes the changes of the transaction made after the first n ones, latest first.
+func (t *inmemTx) rollback(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := len(t.undo) - 1; i >= n; i-- {
+		t.undo[i]()
+	}
+	t.undo = t.undo[:n]
+}
+
+// inmemState is a copy of everything held by inmemRepository, the questions and revisions of DefaultBank
+// are kept apart from the other banks so the layout written by older releases is still read as is.
+type inmemState struct {
+	Questions []Question
+	Revisions []Revision // ordered by question ID, then by number
+	Banks     []bankState
+}
+
+// bankState is a copy of everything held by a bank other than DefaultBank.
+type bankState struct {
+	Bank
+	Questions []Question `json:"questions"`
+	Revisions []Revision `json:"revisions,omitempty"` // ordered by question ID, then by number
+}
+
+// snapshot returns a copy of everything currently held by the repository, banks are ordered by name.
+func (r *inmemRepository) snapshot() inmemState {
 	r.mu.RLock()
-	for i := range r.questions {
-		if id == r.questions[i].ID {
-			index = &i
-			break
+	defer r.mu.RUnlock()
+
+	var state inmemState
+	state.Questions, state.Revisions = r.banks[DefaultBank].snapshot()
+
+	names := make([]string, 0, len(r.banks))
+	for name := range r.banks {
+		if name != DefaultBank {
+			names = append(names, name)
 		}
 	}
-	r.mu.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		b := bankState{Bank: r.banks[name].Bank}
+		b.Questions, b.Revisions = r.banks[name].snapshot()
+		state.Banks = append(state.Banks, b)
+	}
 
-	if index == nil {
-		return ErrQuestionNotFound
+	return state
+}
+
+// snapshot returns a copy of the questions and revisions of the bank.
+func (b *inmemBank) snapshot() ([]Question, []Revision) {
+	questions := make([]Question, len(b.questions))
+	copy(questions, b.questions)
+
+	ids := make([]int, 0, len(b.revisions))
+	for id := range b.revisions {
+		ids = append(ids, id)
 	}
+	sort.Ints(ids)
+	var revisions []Revision
+	for _, id := range ids {
+		revisions = append(revisions, b.revisions[id]...)
+	}
+
+	return questions, revisions
+}
 
+// restore replaces everything held by the repository with given state, it takes ownership of state.
+func (r *inmemRepository) restore(state inmemState) {
 	r.mu.Lock()
-	r.questions = append(r.questions[:*index], r.questions[*index+1:]...)
+	r.reset(state)
 	r.mu.Unlock()
+}
 
-	return nil
+// upgradeQuestions fills the fields missing from questions written by older releases.
+func upgradeQuestions(questions []Question) {
+	for i := range questions {
+		if questions[i].Version == 0 {
+			questions[i].Version = 1
+		}
+	}
+}
+
+// reset replaces the banks with given state and rebuilds their index, the caller must hold the write lock.
+func (r *inmemRepository) reset(state inmemState) {
+	r.banks = make(map[string]*inmemBank, len(state.Banks)+1)
+
+	add := func(bank Bank, questions []Question, revisions []Revision) {
+		if questions == nil {
+			questions = make([]Question, 0)
+		}
+		b := newInmemBank(bank, questions)
+		for _, revision := range revisions {
+			b.revisions[revision.QuestionID] = append(b.revisions[revision.QuestionID], revision)
+		}
+		r.banks[bank.Name] = b
+	}
+	add(Bank{Name: DefaultBank}, state.Questions, state.Revisions)
+	for _, b := range state.Banks {
+		add(b.Bank, b.Questions, b.Revisions)
+	}
 }
@@ -3,7 +3,13 @@ package questionnaire
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -35,7 +41,7 @@ func TestInmemRepositoryGetByID(t *testing.T) {
 
 	var (
 		ctx = context.Background()
-		r   = (Repository)(&inmemRepository{questions: questions})
+		r   = (Repository)(newInmemRepository(questions))
 	)
 
 	for _, tc := range tt {
@@ -72,7 +78,7 @@ func TestInmemRepositoryGetAll(t *testing.T) {
 
 	var (
 		ctx = context.Background()
-		r   = (Repository)(&inmemRepository{questions: expectedQuestions})
+		r   = (Repository)(newInmemRepository(expectedQuestions))
 	)
 
 	for _, tc := range tt {
@@ -91,9 +97,9 @@ func TestInmemRepositoryGetAll(t *testing.T) {
 
 func TestInmemRepositoryCreate(t *testing.T) {
 	var (
-		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
-		question2 = Question{ID: 2, Question: "Guess random number: 1, 2, 3 or 4?", Answer: "4"}
-		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper"}
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number: 1, 2, 3 or 4?", Answer: "4", Version: 1}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
 	)
 
 	tt := []struct {
@@ -125,15 +131,14 @@ func TestInmemRepositoryCreate(t *testing.T) {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
 			var (
-				r         = NewRepository()
-				inmemRepo = r.(*inmemRepository)
+				inmemRepo = newInmemRepository(tc.QuestionInmemDB)
+				r         = (Repository)(inmemRepo)
 			)
-			inmemRepo.questions = tc.QuestionInmemDB
 
 			if err := r.Create(ctx, tc.Question); !errors.Is(tc.ExpectedErr, err) {
 				t.Fatal(err)
 			}
-			if diff := cmp.Diff(tc.ExpectedQuestionsAfterCreated, inmemRepo.questions); diff != "" {
+			if diff := cmp.Diff(tc.ExpectedQuestionsAfterCreated, inmemRepo.banks[DefaultBank].questions); diff != "" {
 				t.Fatal(diff)
 			}
 		})
@@ -142,11 +147,11 @@ func TestInmemRepositoryCreate(t *testing.T) {
 
 func TestInmemRepositoryUpdate(t *testing.T) {
 	var (
-		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
-		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"}
-		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper"}
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
 
-		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7"}
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7", Version: 2}
 	)
 
 	tt := []struct {
@@ -178,21 +183,565 @@ func TestInmemRepositoryUpdate(t *testing.T) {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
 			var (
-				r         = NewRepository()
-				inmemRepo = r.(*inmemRepository)
+				inmemRepo = newInmemRepository(tc.QuestionInmemDB)
+				r         = (Repository)(inmemRepo)
 			)
-			inmemRepo.questions = tc.QuestionInmemDB
 
 			if err := r.Update(ctx, tc.Question); !errors.Is(tc.ExpectedErr, err) {
 				t.Fatal(err)
 			}
-			if diff := cmp.Diff(tc.ExpectedQuestionsAfterUpdated, inmemRepo.questions); diff != "" {
+			if diff := cmp.Diff(tc.ExpectedQuestionsAfterUpdated, inmemRepo.banks[DefaultBank].questions); diff != "" {
 				t.Fatal(diff)
 			}
 		})
 	}
 }
 
+func TestRepositoryUpdateIfVersion(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper"}
+
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7",
+			Matching: []string{"strict-zeros"}}
+	)
+
+	tt := []struct {
+		Name             string
+		Question         Question
+		Version          int
+		ExpectedQuestion *Question
+		ExpectedErr      error
+		ExpectedConflict *VersionConflictError
+	}{
+		{
+			Name:             "update question 1 at version 1",
+			Question:         updatedQuestion1,
+			Version:          1,
+			ExpectedQuestion: &Question{ID: 1, Question: updatedQuestion1.Question, Answer: "7", Version: 2,
+				Matching: []string{"strict-zeros"}},
+			ExpectedErr:      nil,
+		},
+		{
+			Name:             "update question 1 at version 1 again, failed conflict",
+			Question:         question1,
+			Version:          1,
+			ExpectedQuestion: &Question{ID: 1, Question: updatedQuestion1.Question, Answer: "7", Version: 2,
+				Matching: []string{"strict-zeros"}},
+			ExpectedErr:      ErrVersionConflict,
+			ExpectedConflict: &VersionConflictError{ID: 1, Expected: 1, Current: 2},
+		},
+		{
+			Name:             "update question 3, failed not found",
+			Question:         question3,
+			Version:          1,
+			ExpectedQuestion: nil,
+			ExpectedErr:      ErrQuestionNotFound,
+		},
+	}
+
+	ctx := context.Background()
+
+	newRepositories := map[string]func(t *testing.T) Repository{
+		"inmem": func(t *testing.T) Repository { return NewRepository() },
+		"file": func(t *testing.T) Repository {
+			r, err := NewFileRepository(filepath.Join(t.TempDir(), "questions.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return r
+		},
+		"wal": func(t *testing.T) Repository { return openWAL(t, filepath.Join(t.TempDir(), "questions.wal")) },
+		"sql": func(t *testing.T) Repository { return openSQL(t, filepath.Join(t.TempDir(), "questions.db")) },
+	}
+
+	for name, newRepository := range newRepositories {
+		r := newRepository(t)
+		created := question1
+		if err := r.Create(ctx, &created); err != nil {
+			t.Fatal(err)
+		}
+
+		// The order in table test is important, can't be parallelized.
+		for _, tc := range tt {
+			tc := tc
+			t.Run(name+"/"+tc.Name, func(t *testing.T) {
+				question := tc.Question
+				err := r.UpdateIfVersion(ctx, &question, tc.Version)
+				if !errors.Is(err, tc.ExpectedErr) {
+					t.Fatal(err)
+				}
+				var conflict *VersionConflictError
+				errors.As(err, &conflict)
+				if diff := cmp.Diff(tc.ExpectedConflict, conflict); diff != "" {
+					t.Fatal(diff)
+				}
+
+				stored, _ := r.GetByID(ctx, tc.Question.ID)
+				if diff := cmp.Diff(tc.ExpectedQuestion, stored); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestRepositoryWithTx(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper"}
+
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7"}
+
+		errAbort = errors.New("abort")
+	)
+
+	tt := []struct {
+		Name              string
+		Fn                func(ctx context.Context, tx Repository) error
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name: "create question 1 and 2, committed",
+			Fn: func(ctx context.Context, tx Repository) error {
+				q1, q2 := question1, question2
+				if err := tx.Create(ctx, &q1); err != nil {
+					return err
+				}
+				return tx.Create(ctx, &q2)
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: question1.Question, Answer: "7", Version: 1},
+				{ID: 2, Question: question2.Question, Answer: "4", Version: 1},
+			},
+			ExpectedErr: nil,
+		},
+		{
+			Name: "update question 1 and create duplicate question 2, rolled back",
+			Fn: func(ctx context.Context, tx Repository) error {
+				q1, q2 := updatedQuestion1, question2
+				if err := tx.Update(ctx, &q1); err != nil {
+					return err
+				}
+				return tx.Create(ctx, &q2)
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: question1.Question, Answer: "7", Version: 1},
+				{ID: 2, Question: question2.Question, Answer: "4", Version: 1},
+			},
+			ExpectedErr: ErrQuestionIsAlreadyExist,
+		},
+		{
+			Name: "delete question 2 then abort, rolled back",
+			Fn: func(ctx context.Context, tx Repository) error {
+				if err := tx.Delete(ctx, 2); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: question1.Question, Answer: "7", Version: 1},
+				{ID: 2, Question: question2.Question, Answer: "4", Version: 1},
+			},
+			ExpectedErr: errAbort,
+		},
+		{
+			Name: "update question 1 with nested transaction rolled back, committed",
+			Fn: func(ctx context.Context, tx Repository) error {
+				q1 := updatedQuestion1
+				if err := tx.Update(ctx, &q1); err != nil {
+					return err
+				}
+				err := tx.WithTx(ctx, func(nested Repository) error {
+					q3 := question3
+					if err := nested.Create(ctx, &q3); err != nil {
+						return err
+					}
+					return errAbort
+				})
+				if !errors.Is(err, errAbort) {
+					return fmt.Errorf("expected nested transaction aborted, got: %v", err)
+				}
+				return tx.Delete(ctx, 2)
+			},
+			ExpectedQuestions: []Question{
+				{ID: 1, Question: updatedQuestion1.Question, Answer: "7", Version: 2},
+			},
+			ExpectedErr: nil,
+		},
+	}
+
+	ctx := context.Background()
+
+	for name, openRepository := range repositoryOpeners() {
+		openRepository := openRepository
+		dir := t.TempDir()
+		r := openRepository(t, dir)
+
+		// The order in table test is important, can't be parallelized.
+		for _, tc := range tt {
+			tc := tc
+			t.Run(name+"/"+tc.Name, func(t *testing.T) {
+				err := r.WithTx(ctx, func(tx Repository) error { return tc.Fn(ctx, tx) })
+				if !errors.Is(err, tc.ExpectedErr) {
+					t.Fatal(err)
+				}
+
+				questions, err := r.GetAll(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+					t.Fatal(diff)
+				}
+
+				questions, err = openRepository(t, dir).GetAll(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+					t.Fatalf("after reopen: %s", diff)
+				}
+			})
+		}
+	}
+}
+
+// repositoryOpeners returns a function opening each kind of Repository persisted in dir,
+// every open of the same dir sees the same questions. The inmem repository is created only once.
+// The WAL is locked by the first open, the next ones open a copy of it.
+func repositoryOpeners() map[string]func(t *testing.T, dir string) Repository {
+	return map[string]func(t *testing.T, dir string) Repository{
+		"inmem": func() func(t *testing.T, dir string) Repository {
+			r := NewRepository()
+			return func(t *testing.T, dir string) Repository { return r }
+		}(),
+		"file": func(t *testing.T, dir string) Repository {
+			r, err := NewFileRepository(filepath.Join(dir, "questions.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return r
+		},
+		"wal": func() func(t *testing.T, dir string) Repository {
+			opened := make(map[string]bool)
+			return func(t *testing.T, dir string) Repository {
+				if !opened[dir] {
+					opened[dir] = true
+					return openWAL(t, filepath.Join(dir, "questions.wal"))
+				}
+				copied := t.TempDir()
+				for _, name := range []string{"questions.wal", "questions.wal.snapshot"} {
+					b, err := os.ReadFile(filepath.Join(dir, name))
+					if errors.Is(err, fs.ErrNotExist) {
+						continue
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(filepath.Join(copied, name), b, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return openWAL(t, filepath.Join(copied, "questions.wal"))
+			}
+		}(),
+		"sql": func(t *testing.T, dir string) Repository { return openSQL(t, filepath.Join(dir, "questions.db")) },
+	}
+}
+
+func TestRepositoryTrash(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"}
+
+		day1 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		day2 = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		stored1 = Question{ID: 1, Question: question1.Question, Answer: "7", Version: 1}
+		stored2 = Question{ID: 2, Question: question2.Question, Answer: "4", Version: 1}
+	)
+
+	trashed := func(q Question, at time.Time) Question { q.DeletedAt = &at; return q }
+
+	tt := []struct {
+		Name              string
+		Do                func(ctx context.Context, r Repository) error
+		ExpectedQuestions []Question
+		ExpectedTrash     []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:              "trash question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Trash(ctx, 2, day1) },
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day1)},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "trash question 2 again, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Trash(ctx, 2, day2) },
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day1)},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name: "get question 2 in trash, failed not found",
+			Do: func(ctx context.Context, r Repository) error {
+				_, err := r.GetByID(ctx, 2)
+				return err
+			},
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day1)},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "update question 2 in trash, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { q := question2; return r.Update(ctx, &q) },
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day1)},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "create question 2 in trash, failed in trash",
+			Do:                func(ctx context.Context, r Repository) error { q := question2; return r.Create(ctx, &q) },
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day1)},
+			ExpectedErr:       ErrQuestionInTrash,
+		},
+		{
+			Name:              "restore question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Restore(ctx, 2) },
+			ExpectedQuestions: []Question{stored1, stored2},
+			ExpectedTrash:     []Question{},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "restore question 1 not in trash, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Restore(ctx, 1) },
+			ExpectedQuestions: []Question{stored1, stored2},
+			ExpectedTrash:     []Question{},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name: "trash question 1 and 2 on different days",
+			Do: func(ctx context.Context, r Repository) error {
+				if err := r.Trash(ctx, 1, day1); err != nil {
+					return err
+				}
+				return r.Trash(ctx, 2, day2)
+			},
+			ExpectedQuestions: []Question{},
+			ExpectedTrash:     []Question{trashed(stored1, day1), trashed(stored2, day2)},
+			ExpectedErr:       nil,
+		},
+		{
+			Name: "purge questions deleted up to day 1",
+			Do: func(ctx context.Context, r Repository) error {
+				n, err := r.Purge(ctx, day1)
+				if err == nil && n != 1 {
+					return fmt.Errorf("expected 1 question purged, got: %d", n)
+				}
+				return err
+			},
+			ExpectedQuestions: []Question{},
+			ExpectedTrash:     []Question{trashed(stored2, day2)},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 1 after purged",
+			Do:                func(ctx context.Context, r Repository) error { q := question1; return r.Create(ctx, &q) },
+			ExpectedQuestions: []Question{stored1},
+			ExpectedTrash:     []Question{trashed(stored2, day2)},
+			ExpectedErr:       nil,
+		},
+	}
+
+	ctx := context.Background()
+
+	for name, openRepository := range repositoryOpeners() {
+		openRepository := openRepository
+		dir := t.TempDir()
+		r := openRepository(t, dir)
+		for _, q := range []Question{question1, question2} {
+			if err := r.Create(ctx, &q); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The order in table test is important, can't be parallelized.
+		for _, tc := range tt {
+			tc := tc
+			t.Run(name+"/"+tc.Name, func(t *testing.T) {
+				if err := tc.Do(ctx, r); !errors.Is(err, tc.ExpectedErr) {
+					t.Fatal(err)
+				}
+
+				// Check both the repository and the one reopened from its storage.
+				for _, r := range []Repository{r, openRepository(t, dir)} {
+					questions, err := r.GetAll(ctx)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+						t.Fatal(diff)
+					}
+
+					page, err := r.Find(ctx, Query{Trashed: true})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if diff := cmp.Diff(tc.ExpectedTrash, page.Questions); diff != "" {
+						t.Fatal(diff)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInmemRepositoryWithTxRollback(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		scienceCtx = WithBank(ctx, "science")
+		at         = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r          = newInmemRepository([]Question{
+			{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+			{ID: 2, Question: "1 + 2?", Answer: "3", Version: 1},
+			{ID: 3, Question: "1 + 3?", Answer: "4", Version: 1, DeletedAt: &at},
+			{ID: 4, Question: "1 + 4?", Answer: "5", Version: 1},
+		})
+		errAbort = errors.New("abort")
+	)
+	if err := r.CreateBank(ctx, &Bank{Name: "science"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddRevision(ctx, &Revision{QuestionID: 1, Change: ChangeCreate}); err != nil {
+		t.Fatal(err)
+	}
+	before := r.snapshot()
+
+	err := r.WithTx(ctx, func(tx Repository) error {
+		changes := []func() error{
+			func() error { return tx.Delete(ctx, 2) },
+			func() error { return tx.Create(ctx, &Question{ID: 5, Question: "1 + 5?", Answer: "6"}) },
+			func() error { return tx.Update(ctx, &Question{ID: 1, Question: "2 + 2?", Answer: "4"}) },
+			func() error { return tx.Trash(ctx, 4, at) },
+			func() error { return tx.Restore(ctx, 4) },
+			func() error {
+				if n, err := tx.Purge(ctx, at); err != nil || n != 1 {
+					return fmt.Errorf("expected question 3 to be purged, got: %d, %v", n, err)
+				}
+				return nil
+			},
+			func() error { return tx.AddRevision(ctx, &Revision{QuestionID: 1, Change: ChangeUpdate}) },
+			func() error { return tx.AddRevision(ctx, &Revision{QuestionID: 5, Change: ChangeCreate}) },
+			func() error { return tx.Create(scienceCtx, &Question{ID: 1, Question: "H2O?", Answer: "water"}) },
+			func() error { return tx.CreateBank(ctx, &Bank{Name: "history"}) },
+			func() error {
+				return tx.WithTx(ctx, func(nested Repository) error { return nested.Delete(ctx, 1) })
+			},
+		}
+		for _, change := range changes {
+			if err := change(); err != nil {
+				return err
+			}
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected error: %v, got: %v", errAbort, err)
+	}
+
+	if diff := cmp.Diff(before, r.snapshot()); diff != "" {
+		t.Fatal(diff)
+	}
+	for _, b := range r.banks {
+		checkInmemRepositoryIndex(t, b)
+	}
+
+	// The questions created afterward take their insertion sequence as if nothing happened.
+	if err := r.Create(ctx, &Question{ID: 6, Question: "1 + 6?", Answer: "7"}); err != nil {
+		t.Fatal(err)
+	}
+	checkInmemRepositoryIndex(t, r.banks[DefaultBank])
+}
+
+func TestInmemRepositoryWithTxNestedRollback(t *testing.T) {
+	ctx := context.Background()
+	r := newInmemRepository([]Question{{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1}})
+
+	errAbort := errors.New("abort")
+	err := r.WithTx(ctx, func(tx Repository) error {
+		if err := tx.Create(ctx, &Question{ID: 2, Question: "2 + 2?", Answer: "4"}); err != nil {
+			return err
+		}
+		err := tx.WithTx(ctx, func(nested Repository) error {
+			if err := nested.Delete(ctx, 1); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			return fmt.Errorf("expected error: %v, got: %v", errAbort, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Question{
+		{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1},
+		{ID: 2, Question: "2 + 2?", Answer: "4", Version: 1},
+	}
+	if diff := cmp.Diff(expected, r.snapshot().Questions); diff != "" {
+		t.Fatal(diff)
+	}
+	checkInmemRepositoryIndex(t, r.banks[DefaultBank])
+}
+
+func TestInmemRepositoryConcurrentTx(t *testing.T) {
+	const workers = 200
+
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(workers)
+		wg  sync.WaitGroup
+	)
+
+	// Transactions are serialized, none of them fails because of another one.
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			err := r.WithTx(ctx, func(tx Repository) error {
+				question, err := tx.GetByID(ctx, id)
+				if err != nil {
+					return err
+				}
+				question.Answer = "8"
+				if err := tx.Update(ctx, question); err != nil {
+					return err
+				}
+				return tx.AddRevision(ctx, &Revision{QuestionID: id, Change: ChangeUpdate, New: question})
+			})
+			if err != nil {
+				t.Errorf("update question ID %d: %v", id, err)
+			}
+		}(w + 1)
+	}
+	wg.Wait()
+
+	for _, question := range r.snapshot().Questions {
+		if question.Answer != "8" || question.Version != 1 {
+			t.Fatalf("question ID %d is not updated once: %+v", question.ID, question)
+		}
+	}
+}
+
 func TestInmemRepositoryDelete(t *testing.T) {
 	var (
 		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
@@ -229,17 +778,266 @@ func TestInmemRepositoryDelete(t *testing.T) {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
 			var (
-				r         = NewRepository()
-				inmemRepo = r.(*inmemRepository)
+				inmemRepo = newInmemRepository(tc.QuestionInmemDB)
+				r         = (Repository)(inmemRepo)
 			)
-			inmemRepo.questions = tc.QuestionInmemDB
 
 			if err := r.Delete(ctx, tc.QuestionID); !errors.Is(tc.ExpectedErr, err) {
 				t.Fatal(err)
 			}
-			if diff := cmp.Diff(tc.ExpectedQuestionsAfterDeleted, inmemRepo.questions); diff != "" {
+			if diff := cmp.Diff(tc.ExpectedQuestionsAfterDeleted, inmemRepo.banks[DefaultBank].questions); diff != "" {
 				t.Fatal(diff)
 			}
 		})
 	}
 }
+
+func TestInmemRepositoryDefensiveCopy(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		r         = NewRepository()
+	)
+
+	created := question1
+	if err := r.Create(ctx, &created); err != nil {
+		t.Fatal(err)
+	}
+	created.Answer = "8"
+
+	question, err := r.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	question.Answer = "9"
+
+	questions, err := r.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	questions[0].Answer = "10"
+
+	questions, err = r.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Question{question1}, questions); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// numberOfStressQuestions is the size of the question bank used in stress tests and benchmarks.
+const numberOfStressQuestions = 100_000
+
+// newStressRepository creates inmemRepository holding n questions with ID 1 to n.
+func newStressRepository(n int) *inmemRepository {
+	questions := make([]Question, n)
+	for i := range questions {
+		questions[i] = Question{ID: i + 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}
+	}
+
+	return newInmemRepository(questions)
+}
+
+// checkInmemRepositoryIndex checks that every question is reachable through the index at its position.
+func checkInmemRepositoryIndex(t *testing.T, b *inmemBank) {
+	t.Helper()
+
+	if len(b.index) != len(b.questions) || len(b.seqs) != len(b.questions) {
+		t.Fatalf("index has %d entries, seqs has %d, questions has %d", len(b.index), len(b.seqs), len(b.questions))
+	}
+	for i, question := range b.questions {
+		if pos, _ := b.position(question.ID); pos != i {
+			t.Fatalf("question ID %d is at %d, index points to %d", question.ID, i, pos)
+		}
+	}
+}
+
+func TestInmemRepositoryConcurrentDelete(t *testing.T) {
+	const workers, deletesPerWorker = 8, 50
+
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+		wg  sync.WaitGroup
+	)
+
+	// Every worker deletes its own IDs, spread across the whole bank so positions shift under each other.
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < deletesPerWorker; i++ {
+				id := (i*workers+w)*(numberOfStressQuestions/(workers*deletesPerWorker)) + 1
+				if err := r.Delete(ctx, id); err != nil {
+					t.Errorf("delete question ID %d: %v", id, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	b := r.banks[DefaultBank]
+	checkInmemRepositoryIndex(t, b)
+	if expected := numberOfStressQuestions - workers*deletesPerWorker; len(b.questions) != expected {
+		t.Fatalf("expected %d questions, got: %d", expected, len(b.questions))
+	}
+	for i, question := range b.questions {
+		if i > 0 && b.questions[i-1].ID >= question.ID {
+			t.Fatalf("insertion order is broken at position %d", i)
+		}
+		if (question.ID-1)%(numberOfStressQuestions/(workers*deletesPerWorker)) == 0 {
+			t.Fatalf("question ID %d should have been deleted", question.ID)
+		}
+	}
+}
+
+func TestInmemRepositoryConcurrentMixed(t *testing.T) {
+	const workers, opsPerWorker = 8, 200
+
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+		wg  sync.WaitGroup
+	)
+
+	// All workers fight over the same small set of IDs, exactly one create or delete of an ID may win at a time.
+	var created, deleted [workers]int
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < opsPerWorker; i++ {
+				id := numberOfStressQuestions + 1 + i%10
+				switch i % 4 {
+				case 0:
+					if err := r.Create(ctx, &Question{ID: id, Question: "1 + 1?", Answer: "2"}); err == nil {
+						created[w]++
+					} else if !errors.Is(err, ErrQuestionIsAlreadyExist) {
+						t.Error(err)
+					}
+				case 1:
+					if err := r.Update(ctx, &Question{ID: id, Question: "1 + 2?", Answer: "3"}); err != nil && !errors.Is(err, ErrQuestionNotFound) {
+						t.Error(err)
+					}
+				case 2:
+					if err := r.Delete(ctx, id); err == nil {
+						deleted[w]++
+					} else if !errors.Is(err, ErrQuestionNotFound) {
+						t.Error(err)
+					}
+				case 3:
+					if _, err := r.GetByID(ctx, id); err != nil && !errors.Is(err, ErrQuestionNotFound) {
+						t.Error(err)
+					}
+					if i%40 != 3 {
+						continue
+					}
+					if _, err := r.GetAll(ctx); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	b := r.banks[DefaultBank]
+	checkInmemRepositoryIndex(t, b)
+
+	var balance int
+	for w := 0; w < workers; w++ {
+		balance += created[w] - deleted[w]
+	}
+	if expected := numberOfStressQuestions + balance; len(b.questions) != expected {
+		t.Fatalf("expected %d questions, got: %d", expected, len(b.questions))
+	}
+}
+
+func BenchmarkInmemRepositoryGetByID(b *testing.B) {
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetByID(ctx, i%numberOfStressQuestions+1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInmemRepositoryGetByIDParallel(b *testing.B) {
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+	)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, err := r.GetByID(ctx, i%numberOfStressQuestions+1); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkInmemRepositoryGetAll(b *testing.B) {
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetAll(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInmemRepositoryCreate(b *testing.B) {
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.Create(ctx, &Question{ID: numberOfStressQuestions + 1 + i, Question: "1 + 1?", Answer: "2"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInmemRepositoryUpdate(b *testing.B) {
+	var (
+		ctx = context.Background()
+		r   = newStressRepository(numberOfStressQuestions)
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.Update(ctx, &Question{ID: i%numberOfStressQuestions + 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInmemRepositoryDelete(b *testing.B) {
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		r := newStressRepository(numberOfStressQuestions)
+		b.StartTimer()
+
+		// Deleting from the middle shifts half of the bank.
+		if err := r.Delete(ctx, numberOfStressQuestions/2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound      = errors.New("revision not found")
+	ErrRevisionNotRevertible = errors.New("revision deleted the question, there is nothing to revert to")
+)
+
+// Change is the kind of change recorded by a Revision.
+type Change string
+
+const (
+	ChangeCreate  Change = "create"
+	ChangeUpdate  Change = "update"
+	ChangeDelete  Change = "delete"
+	ChangeRestore Change = "restore"
+	ChangeRevert  Change = "revert"
+	ChangeCopy    Change = "copy"
+	ChangeDiscard Change = "discard"
+)
+
+// Revision is an immutable record of a change made to a question.
+type Revision struct {
+	QuestionID int `json:"question_id"`
+	// Number starts from 1 for the first change of the question and is incremented by every change after that.
+	Number int       `json:"number"`
+	Change Change    `json:"change"`
+	Author string    `json:"author,omitempty"`
+	At     time.Time `json:"at"`
+	// Old is the question before the change, nil when the change brought the question into existence.
+	Old *Question `json:"old,omitempty"`
+	// New is the question after the change, nil when the change moved the question to trash or discarded it.
+	New *Question `json:"new,omitempty"`
+}
+
+// clone returns a copy of the revision not sharing the questions.
+func (r Revision) clone() Revision {
+	if r.Old != nil {
+		before := r.Old.clone()
+		r.Old = &before
+	}
+	if r.New != nil {
+		after := r.New.clone()
+		r.New = &after
+	}
+
+	return r
+}
+
+type authorKey struct{}
+
+// WithAuthor returns a copy of ctx carrying the author recorded in the revisions of the changes made with it.
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+// AuthorFromContext returns the author carried by ctx, empty when there is none.
+func AuthorFromContext(ctx context.Context) string {
+	author, _ := ctx.Value(authorKey{}).(string)
+	return author
+}
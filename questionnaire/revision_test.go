@@ -0,0 +1,67 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepositoryRevisions(t *testing.T) {
+	var (
+		at       = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		question = Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1}
+		updated  = Question{ID: 1, Question: "1 + 1 =", Answer: "2", Version: 2}
+
+		created   = Revision{QuestionID: 1, Number: 1, Change: ChangeCreate, Author: "alice", At: at, New: &question}
+		changed   = Revision{QuestionID: 1, Number: 2, Change: ChangeUpdate, Author: "bob", At: at, Old: &question, New: &updated}
+		other     = Revision{QuestionID: 2, Number: 1, Change: ChangeCreate, At: at, New: &Question{ID: 2, Version: 1}}
+		discarded = Revision{QuestionID: 1, Change: ChangeDelete, At: at, Old: &updated}
+	)
+
+	ctx := context.Background()
+
+	for name, openRepository := range repositoryOpeners() {
+		dir := t.TempDir()
+		r := openRepository(t, dir)
+
+		for _, revision := range []Revision{created, other, changed} {
+			revision.Number = 0
+			if err := r.AddRevision(ctx, &revision); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		errAbort := errors.New("abort")
+		err := r.WithTx(ctx, func(tx Repository) error {
+			revision := discarded
+			if err := tx.AddRevision(ctx, &revision); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("%s: expected error: %v, got: %v", name, errAbort, err)
+		}
+
+		for _, r := range []Repository{r, openRepository(t, dir)} {
+			revisions, err := r.GetRevisions(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]Revision{created, changed}, revisions); diff != "" {
+				t.Fatalf("%s: %s", name, diff)
+			}
+
+			revisions, err = r.GetRevisions(ctx, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]Revision{}, revisions); diff != "" {
+				t.Fatalf("%s: %s", name, diff)
+			}
+		}
+	}
+}
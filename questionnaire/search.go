@@ -0,0 +1,277 @@
+package questionnaire
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/muktihari/quiz_master/pkg/textinput"
+)
+
+// searchSeparators split the text of questions and search terms into tokens.
+var searchSeparators = []rune{' ', '\t', '\n', ',', '.', '?', '!', '(', ')', '"', '\'', ':', ';'}
+
+// BM25 parameters, the usual defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchField is a field of the questions the index tokenizes, the occurrences of a token in every field are
+// weighted and summed up, as BM25F does.
+type searchField int
+
+const (
+	searchQuestion searchField = iota
+	searchAnswer
+	searchFields // number of fields
+)
+
+// searchFieldWeights weighs an occurrence by the field it's in, a term of the question tells more than one of the
+// answer.
+var searchFieldWeights = [searchFields]float64{searchQuestion: 1, searchAnswer: 0.5}
+
+// searchFieldTexts returns the text of every field of question.
+func searchFieldTexts(question *Question) [searchFields]string {
+	return [searchFields]string{searchQuestion: question.Question, searchAnswer: question.Answer}
+}
+
+// SearchResult is a question matching a search, with the search terms it contains.
+type SearchResult struct {
+	Question Question
+	// Score is the BM25F relevance of the question, its question and answer weighted, the higher the more relevant.
+	Score float64
+	// Terms are the search terms found in the question or its answer, in the order of the search.
+	Terms []string
+}
+
+// Tokenize splits s into lower case search tokens, the same way the text of questions is indexed.
+func Tokenize(s string) []string {
+	parts := textinput.SplitWithOptions(strings.ToLower(s), searchSeparators, false, false)
+
+	tokens := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			tokens = append(tokens, part)
+		}
+	}
+
+	return tokens
+}
+
+// Highlight wraps every token of text that is one of terms between open and close.
+func Highlight(text string, terms []string, open, close string) string {
+	match := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		match[strings.ToLower(term)] = true
+	}
+
+	var sb strings.Builder
+	for _, part := range textinput.SplitWithOptions(text, searchSeparators, false, true) {
+		if match[strings.ToLower(part)] {
+			sb.WriteString(open + part + close)
+			continue
+		}
+		sb.WriteString(part)
+	}
+
+	return sb.String()
+}
+
+// searchIndex is an inverted index of the text of the questions of every bank. The index of a bank follows the
+// changes made through the service, and is brought up to date with the repository on every search of the bank
+// for the changes made by others.
+type searchIndex struct {
+	mu    sync.Mutex
+	banks map[string]*bankIndex
+}
+
+// bankIndex maps every token to the questions containing it.
+type bankIndex struct {
+	questions   map[int]indexedQuestion
+	postings    map[string]map[int][searchFields]int // token -> question ID -> number of occurrences by field
+	totalLength [searchFields]int                    // sum of the number of tokens of every question by field
+}
+
+type indexedQuestion struct {
+	question Question
+	length   [searchFields]int // number of tokens by field
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{banks: make(map[string]*bankIndex)}
+}
+
+func newBankIndex() *bankIndex {
+	return &bankIndex{questions: make(map[int]indexedQuestion), postings: make(map[string]map[int][searchFields]int)}
+}
+
+// indexChange is a question of bank after a change, nil when it's no longer live.
+type indexChange struct {
+	bank     string
+	id       int
+	question *Question
+}
+
+// apply reflects changes into the index of the banks already built.
+func (x *searchIndex) apply(changes ...indexChange) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, change := range changes {
+		b, ok := x.banks[change.bank]
+		if !ok {
+			continue
+		}
+		if change.question != nil {
+			b.add(change.question.clone())
+		} else {
+			b.remove(change.id)
+		}
+	}
+}
+
+// search ranks the questions of the bank of ctx containing any of the tokens of terms, load gets the questions
+// of the bank the index is synced with first. At most limit results are returned, all of them when limit is 0.
+func (x *searchIndex) search(ctx context.Context, terms string, limit int,
+	load func(ctx context.Context) ([]Question, error)) ([]SearchResult, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	// The lock is held while loading, the changes made in the meantime wait for the index to be synced.
+	questions, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := BankFromContext(ctx)
+	b, ok := x.banks[name]
+	if !ok {
+		b = newBankIndex()
+		x.banks[name] = b
+	}
+	b.sync(questions)
+
+	return b.search(Tokenize(terms), limit), nil
+}
+
+// sync makes the index hold questions, only the questions changed since they were indexed are tokenized again.
+func (b *bankIndex) sync(questions []Question) {
+	live := make(map[int]bool, len(questions))
+	for i := range questions {
+		live[questions[i].ID] = true
+		if indexed, ok := b.questions[questions[i].ID]; ok && reflect.DeepEqual(indexed.question, questions[i]) {
+			continue
+		}
+		b.add(questions[i].clone())
+	}
+	for id := range b.questions {
+		if !live[id] {
+			b.remove(id)
+		}
+	}
+}
+
+// add indexes question, replacing the question with the same ID if any.
+func (b *bankIndex) add(question Question) {
+	b.remove(question.ID)
+
+	indexed := indexedQuestion{question: question}
+	for field, text := range searchFieldTexts(&question) {
+		tokens := Tokenize(text)
+		for _, token := range tokens {
+			if b.postings[token] == nil {
+				b.postings[token] = make(map[int][searchFields]int)
+			}
+			occurrences := b.postings[token][question.ID]
+			occurrences[field]++
+			b.postings[token][question.ID] = occurrences
+		}
+		indexed.length[field] = len(tokens)
+		b.totalLength[field] += len(tokens)
+	}
+	b.questions[question.ID] = indexed
+}
+
+func (b *bankIndex) remove(id int) {
+	indexed, ok := b.questions[id]
+	if !ok {
+		return
+	}
+	for field, text := range searchFieldTexts(&indexed.question) {
+		for _, token := range Tokenize(text) {
+			delete(b.postings[token], id)
+			if len(b.postings[token]) == 0 {
+				delete(b.postings, token)
+			}
+		}
+		b.totalLength[field] -= indexed.length[field]
+	}
+	delete(b.questions, id)
+}
+
+func (b *bankIndex) search(terms []string, limit int) []SearchResult {
+	if len(b.questions) == 0 {
+		return []SearchResult{}
+	}
+
+	var (
+		n         = float64(len(b.questions))
+		avgLength [searchFields]float64
+		results   = make(map[int]*SearchResult)
+		seen      = make(map[string]bool, len(terms))
+	)
+	for field := range avgLength {
+		avgLength[field] = float64(b.totalLength[field]) / n
+	}
+	for _, term := range terms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := b.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, occurrences := range postings {
+			// The occurrences of every field are normalized by the length of the field and weighted, the
+			// saturation applies to their sum.
+			var tf float64
+			for field, count := range occurrences {
+				if count == 0 {
+					continue
+				}
+				length := float64(b.questions[id].length[field])
+				tf += searchFieldWeights[field] * float64(count) / (1 - bm25B + bm25B*length/avgLength[field])
+			}
+			score := idf * tf * (bm25K1 + 1) / (tf + bm25K1)
+
+			result, ok := results[id]
+			if !ok {
+				result = &SearchResult{Question: b.questions[id].question.clone()}
+				results[id] = result
+			}
+			result.Score += score
+			result.Terms = append(result.Terms, term)
+		}
+	}
+
+	ranked := make([]SearchResult, 0, len(results))
+	for _, result := range results {
+		ranked = append(ranked, *result)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Question.ID < ranked[j].Question.ID
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return ranked
+}
@@ -0,0 +1,160 @@
+package questionnaire
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokenize(t *testing.T) {
+	tt := []struct {
+		Input    string
+		Expected []string
+	}{
+		{Input: "How many characters are there in \"Quipper\"?", Expected: []string{"how", "many", "characters", "are", "there", "in", "quipper"}},
+		{Input: "Guess random number, 1, 2, 3 or 4?", Expected: []string{"guess", "random", "number", "1", "2", "3", "or", "4"}},
+		{Input: " (  ) ", Expected: []string{}},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Input, func(t *testing.T) {
+			if diff := cmp.Diff(tc.Expected, Tokenize(tc.Input)); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	text := "How many characters are there in \"Quipper\"? Quippers!"
+	expected := "How many [characters] are there in \"[Quipper]\"? Quippers!"
+
+	if highlighted := Highlight(text, []string{"quipper", "characters"}, "[", "]"); highlighted != expected {
+		t.Fatalf("expected: %q, got: %q", expected, highlighted)
+	}
+}
+
+func TestBankIndexSearch(t *testing.T) {
+	b := newBankIndex()
+	for _, question := range []Question{
+		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
+		{ID: 2, Question: "Quipper vs Ruangguru, Quipper or Ruangguru?", Answer: "Quipper"},
+		{ID: 3, Question: "How many legs does a spider have?", Answer: "8"},
+		{ID: 4, Question: "How many characters are there in \"Engineer\"?", Answer: "8"},
+		{ID: 5, Question: "Which edtech company runs \"Study Supplement\"?", Answer: "Quipper"},
+	} {
+		b.add(question)
+	}
+	b.add(Question{ID: 4, Question: "How many characters are there in \"Engineer\"?", Answer: "8"})
+	b.remove(3)
+
+	ids := func(results []SearchResult) []int {
+		ids := make([]int, len(results))
+		for i := range results {
+			ids[i] = results[i].Question.ID
+		}
+		return ids
+	}
+
+	tt := []struct {
+		Name     string
+		Terms    string
+		Limit    int
+		Expected []int
+	}{
+		{
+			Name:     "the question with more occurrences ranks first, one of the answer last",
+			Terms:    "quipper",
+			Expected: []int{2, 1, 5},
+		},
+		{
+			Name:     "terms of the answer are found",
+			Terms:    "8",
+			Expected: []int{4},
+		},
+		{
+			Name:     "the question with more matched terms ranks first",
+			Terms:    "quipper characters",
+			Expected: []int{1, 4, 2, 5},
+		},
+		{
+			Name:     "limit",
+			Terms:    "Quipper, characters?",
+			Limit:    1,
+			Expected: []int{1},
+		},
+		{
+			Name:     "removed question is not found",
+			Terms:    "spider",
+			Expected: []int{},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.Expected, ids(b.search(Tokenize(tc.Terms), tc.Limit))); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	// Re-adding a question after removal leaves the index as it was.
+	expectedLength := [searchFields]int{
+		searchQuestion: len(Tokenize("How many characters are there in \"Quipper\"?")) +
+			len(Tokenize("Quipper vs Ruangguru, Quipper or Ruangguru?")) +
+			len(Tokenize("How many characters are there in \"Engineer\"?")) +
+			len(Tokenize("Which edtech company runs \"Study Supplement\"?")),
+		searchAnswer: 4,
+	}
+	if b.totalLength != expectedLength {
+		t.Fatalf("unexpected total length: %v", b.totalLength)
+	}
+}
+
+func TestSearchIndexSync(t *testing.T) {
+	var (
+		ctx = context.Background()
+		r   = NewRepository()
+		x   = newSearchIndex()
+	)
+
+	search := func(terms string) []int {
+		results, err := x.search(ctx, terms, 0, r.GetAll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int, len(results))
+		for i := range results {
+			ids[i] = results[i].Question.ID
+		}
+		return ids
+	}
+
+	if err := r.Create(ctx, &Question{ID: 1, Question: "How many legs does a spider have?", Answer: "8"}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1}, search("spider")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Changed in the repository behind the back of the index, as another process would.
+	if err := r.Update(ctx, &Question{ID: 1, Question: "How many legs does an ant have?", Answer: "6"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Create(ctx, &Question{ID: 2, Question: "How many eyes does a spider have?", Answer: "8"}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{2}, search("spider")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if err := r.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]int{1}, search("legs eyes")); diff != "" {
+		t.Fatal(diff)
+	}
+}
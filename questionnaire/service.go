@@ -2,32 +2,165 @@ package questionnaire
 
 import (
 	"context"
-	"strings"
+	"time"
 
-	"github.com/muktihari/quiz_master/pkg/textinput"
+	"github.com/muktihari/quiz_master/pkg/units"
 )
 
+// Service works on the questions of the bank carried by ctx, see WithBank.
 type Service interface {
+	// Banks gets all banks ordered by name, returns error if any
+	Banks(ctx context.Context) ([]Bank, error)
+	// Bank gets bank by name, returns ErrBankNotFound if there is none
+	Bank(ctx context.Context, name string) (*Bank, error)
+	// CreateBank creates an empty bank, returns error if any
+	CreateBank(ctx context.Context, bank *Bank) error
+	// CopyQuestion copies question id into bank under the same ID, returns the copy and error if any
+	CopyQuestion(ctx context.Context, id int, bank string) (*Question, error)
 	// GetByID gets question by id, returns error if any
 	GetByID(ctx context.Context, id int) (*Question, error)
 	// GetAll gets all questions, returns error if any
 	GetAll(ctx context.Context) ([]Question, error)
-	// Create creates question, returns error if any
+	// Find gets a page of questions selected by query, returns error if any
+	Find(ctx context.Context, query Query) (*Page, error)
+	// Create creates question, a question without ID is given the ID picked by the IDAllocator, see
+	// WithIDAllocator. Returns error if any
 	Create(ctx context.Context, question *Question) error
 	// Update updates existing question, return error if any
 	Update(ctx context.Context, question *Question) error
-	// Delete deletes existing question, return error if any
+	// UpdateIfVersion updates existing question only if it's still at given version, returns
+	// error matching ErrVersionConflict if it's not
+	UpdateIfVersion(ctx context.Context, question *Question, version int) error
+	// Tag adds tags to existing question, returns the question and error if any
+	Tag(ctx context.Context, id int, tags []string) (*Question, error)
+	// Untag removes tags from existing question, returns the question and error if any
+	Untag(ctx context.Context, id int, tags []string) (*Question, error)
+	// Delete moves existing question to trash, return error if any
 	Delete(ctx context.Context, id int) error
-	// Answer checks whether given answer to specific question is correct
+	// Discard deletes existing question for good without moving it to trash, return error if any
+	Discard(ctx context.Context, id int) error
+	// Trash gets the questions in trash, returns error if any
+	Trash(ctx context.Context) ([]Question, error)
+	// Restore moves question in trash back, return error if any
+	Restore(ctx context.Context, id int) error
+	// Purge deletes all questions in trash for good, returns the number of questions deleted and error if any
+	Purge(ctx context.Context) (int, error)
+	// PurgeExpired deletes for good the questions of every bank kept in trash longer than the retention given by
+	// WithTrashRetention, returns the number of questions deleted and error if any
+	PurgeExpired(ctx context.Context) (int, error)
+	// History gets the revisions of question id, oldest first, returns error if any
+	History(ctx context.Context, id int) ([]Revision, error)
+	// Revision gets revision number of question id, returns ErrRevisionNotFound if there is none
+	Revision(ctx context.Context, id, number int) (*Revision, error)
+	// Revert updates question id back to how it was right after revision number, returns the question and error if any
+	Revert(ctx context.Context, id, number int) (*Question, error)
+	// Search gets the questions containing any of the words of terms, the most relevant first, at most limit
+	// of them or all when limit is 0. The questions are read on every search, so it sees the changes made by
+	// someone else than the service too. Returns error if any
+	Search(ctx context.Context, terms string, limit int) ([]SearchResult, error)
+	// Answer checks whether given answer to specific question is correct, an answer in a unit of another dimension
+	// than the answer of the question is incorrect with error matching ErrWrongDimension
 	Answer(ctx context.Context, id int, answer string) (bool, error)
+	// Compact shrinks the underlying storage, returns ErrCompactionNotSupported if the repository can't
+	Compact(ctx context.Context) error
+	// WithTx runs fn in a transaction, the changes made through tx are committed when fn returns nil
+	// and rolled back otherwise, returns error if any
+	WithTx(ctx context.Context, fn func(tx Service) error) error
 }
 
-func NewService(repository Repository) Service {
-	return &service{repository: repository}
+// ServiceOption configures the Service created by NewService.
+type ServiceOption func(s *service)
+
+// WithClock makes the Service take the current time from now, e.g. the deletion time of questions.
+func WithClock(now func() time.Time) ServiceOption {
+	return func(s *service) { s.now = now }
+}
+
+// WithTrashRetention makes PurgeExpired delete the questions kept in trash longer than d,
+// questions are kept in trash until purged when d is 0.
+func WithTrashRetention(d time.Duration) ServiceOption {
+	return func(s *service) { s.trashRetention = d }
+}
+
+// WithIDAllocator makes Create pick the ID of the questions created without one with allocate, NextID by default.
+func WithIDAllocator(allocate IDAllocator) ServiceOption {
+	return func(s *service) { s.allocate = allocate }
+}
+
+// WithUnits makes Answer read the units of the answers from registry, units.Default by default.
+func WithUnits(registry *units.Registry) ServiceOption {
+	return func(s *service) { s.units = registry }
+}
+
+func NewService(repository Repository, opts ...ServiceOption) Service {
+	s := &service{repository: repository, now: time.Now, allocate: NextID, index: newSearchIndex(), units: units.Default}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 type service struct {
-	repository Repository
+	repository     Repository
+	now            func() time.Time
+	trashRetention time.Duration
+	allocate       IDAllocator
+	index          *searchIndex
+	units          *units.Registry
+	// pending is set inside a transaction, the changes are applied to the index once the transaction commits.
+	pending *[]indexChange
+}
+
+func (s *service) Banks(ctx context.Context) ([]Bank, error) {
+	return s.repository.GetBanks(ctx)
+}
+
+func (s *service) Bank(ctx context.Context, name string) (*Bank, error) {
+	banks, err := s.repository.GetBanks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range banks {
+		if banks[i].Name == name {
+			return &banks[i], nil
+		}
+	}
+
+	return nil, ErrBankNotFound
+}
+
+func (s *service) CreateBank(ctx context.Context, bank *Bank) error {
+	if err := ValidateBankName(bank.Name); err != nil {
+		return err
+	}
+	bank.CreatedAt = s.now()
+
+	return s.repository.CreateBank(ctx, bank)
+}
+
+func (s *service) CopyQuestion(ctx context.Context, id int, bank string) (*Question, error) {
+	var (
+		dst      = WithBank(ctx, bank)
+		question *Question
+	)
+	err := s.record(dst, id, ChangeCopy, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		source, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		question = &Question{ID: id, Question: source.Question, Answer: source.Answer, Tags: source.Tags,
+			Category: source.Category, Matching: source.Matching}
+		if err := tx.Create(dst, question); err != nil {
+			return nil, nil, err
+		}
+		return nil, question, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
 }
 
 func (s *service) GetByID(ctx context.Context, id int) (*Question, error) {
@@ -38,22 +171,308 @@ func (s *service) GetAll(ctx context.Context) ([]Question, error) {
 	return s.repository.GetAll(ctx)
 }
 
+func (s *service) Find(ctx context.Context, query Query) (*Page, error) {
+	return s.repository.Find(ctx, query)
+}
+
 func (s *service) Create(ctx context.Context, question *Question) error {
-	question.Question = strings.Trim(question.Question, "\"")
-	question.Answer = strings.Trim(question.Answer, "\"")
+	if err := normalizeQuestion(question); err != nil {
+		return err
+	}
+	if question.ID != 0 {
+		return s.create(ctx, question)
+	}
+
+	// Someone else may take the allocated ID before the question is created, allocate another one then.
+	var err error
+	for attempt := 0; attempt < maxAllocationAttempts; attempt++ {
+		question.ID = 0
+		if err = s.create(ctx, question); !isAllocationConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		question.ID = 0
+	}
+
+	return err
+}
 
-	return s.repository.Create(ctx, question)
+// create creates question, allocating its ID in the same transaction when it has none.
+func (s *service) create(ctx context.Context, question *Question) error {
+	return s.record(ctx, question.ID, ChangeCreate, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		if question.ID == 0 {
+			id, err := s.allocate(ctx, tx)
+			if err != nil {
+				return nil, nil, err
+			}
+			question.ID = id
+		}
+		if err := tx.Create(ctx, question); err != nil {
+			return nil, nil, err
+		}
+		return nil, question, nil
+	})
 }
 
 func (s *service) Update(ctx context.Context, question *Question) error {
-	question.Question = strings.Trim(question.Question, "\"")
-	question.Answer = strings.Trim(question.Answer, "\"")
+	if err := normalizeQuestion(question); err != nil {
+		return err
+	}
+
+	return s.record(ctx, question.ID, ChangeUpdate, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, question.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Update(ctx, question); err != nil {
+			return nil, nil, err
+		}
+		return old, question, nil
+	})
+}
+
+func (s *service) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	if err := normalizeQuestion(question); err != nil {
+		return err
+	}
+
+	return s.record(ctx, question.ID, ChangeUpdate, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, question.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.UpdateIfVersion(ctx, question, version); err != nil {
+			return nil, nil, err
+		}
+		return old, question, nil
+	})
+}
+
+func (s *service) Tag(ctx context.Context, id int, tags []string) (*Question, error) {
+	return s.retag(ctx, id, tags, func(current, tags []string) []string {
+		return append(append([]string(nil), current...), tags...)
+	})
+}
 
-	return s.repository.Update(ctx, question)
+func (s *service) Untag(ctx context.Context, id int, tags []string) (*Question, error) {
+	return s.retag(ctx, id, tags, func(current, tags []string) []string {
+		kept := make([]string, 0, len(current))
+		for _, tag := range current {
+			if !hasTag(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	})
+}
+
+// retag updates the tags of question id to the result of change, given the current tags and normalized tags.
+func (s *service) retag(ctx context.Context, id int, tags []string, change func(current, tags []string) []string) (*Question, error) {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var question *Question
+	err = s.record(ctx, id, ChangeUpdate, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		updated := old.clone()
+		if updated.Tags, err = NormalizeTags(change(old.Tags, tags)); err != nil {
+			return nil, nil, err
+		}
+		if err := tx.UpdateIfVersion(ctx, &updated, old.Version); err != nil {
+			return nil, nil, err
+		}
+		question = &updated
+		return old, question, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
 }
 
 func (s *service) Delete(ctx context.Context, id int) error {
-	return s.repository.Delete(ctx, id)
+	return s.record(ctx, id, ChangeDelete, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Trash(ctx, id, at); err != nil {
+			return nil, nil, err
+		}
+		return old, nil, nil
+	})
+}
+
+func (s *service) Discard(ctx context.Context, id int) error {
+	return s.record(ctx, id, ChangeDiscard, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Delete(ctx, id); err != nil {
+			return nil, nil, err
+		}
+		return old, nil, nil
+	})
+}
+
+func (s *service) Trash(ctx context.Context) ([]Question, error) {
+	page, err := s.repository.Find(ctx, Query{Trashed: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Questions, nil
+}
+
+func (s *service) Restore(ctx context.Context, id int) error {
+	return s.record(ctx, id, ChangeRestore, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		if err := tx.Restore(ctx, id); err != nil {
+			return nil, nil, err
+		}
+		restored, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, restored, nil
+	})
+}
+
+func (s *service) Purge(ctx context.Context) (int, error) {
+	return s.repository.Purge(ctx, s.now())
+}
+
+func (s *service) PurgeExpired(ctx context.Context) (int, error) {
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+
+	banks, err := s.repository.GetBanks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	before, purged := s.now().Add(-s.trashRetention), 0
+	for _, bank := range banks {
+		n, err := s.repository.Purge(WithBank(ctx, bank.Name), before)
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+	}
+
+	return purged, nil
+}
+
+func (s *service) History(ctx context.Context, id int) ([]Revision, error) {
+	revisions, err := s.repository.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		// Questions created before revisions were recorded have no history.
+		if _, err := s.repository.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return revisions, nil
+}
+
+func (s *service) Revision(ctx context.Context, id, number int) (*Revision, error) {
+	revisions, err := s.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > len(revisions) {
+		return nil, ErrRevisionNotFound
+	}
+
+	return &revisions[number-1], nil
+}
+
+func (s *service) Revert(ctx context.Context, id, number int) (*Question, error) {
+	revision, err := s.Revision(ctx, id, number)
+	if err != nil {
+		return nil, err
+	}
+	if revision.New == nil {
+		return nil, ErrRevisionNotRevertible
+	}
+
+	question := &Question{ID: id, Question: revision.New.Question, Answer: revision.New.Answer,
+		Tags: revision.New.Tags, Category: revision.New.Category, Matching: revision.New.Matching}
+	err = s.record(ctx, id, ChangeRevert, func(tx Repository, at time.Time) (*Question, *Question, error) {
+		old, err := tx.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Update(ctx, question); err != nil {
+			return nil, nil, err
+		}
+		return old, question, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// record applies change and appends its revision in a single transaction, change returns the question
+// before and after it was applied at given time. id is 0 when change allocates it, the ID of the question
+// after the change is recorded then.
+func (s *service) record(ctx context.Context, id int, kind Change,
+	change func(tx Repository, at time.Time) (before, after *Question, err error)) error {
+	var indexed indexChange
+	err := s.repository.WithTx(ctx, func(tx Repository) error {
+		at := s.now()
+		before, after, err := change(tx, at)
+		if err != nil {
+			return err
+		}
+		changed := id
+		if changed == 0 && after != nil {
+			changed = after.ID
+		}
+		indexed = indexChange{bank: BankFromContext(ctx), id: changed, question: after}
+
+		return tx.AddRevision(ctx, &Revision{
+			QuestionID: changed,
+			Change:     kind,
+			Author:     AuthorFromContext(ctx),
+			At:         at,
+			Old:        before,
+			New:        after,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.pending != nil {
+		*s.pending = append(*s.pending, indexed)
+	} else {
+		s.index.apply(indexed)
+	}
+
+	return nil
+}
+
+func (s *service) Search(ctx context.Context, terms string, limit int) ([]SearchResult, error) {
+	if s.pending != nil {
+		// The shared index doesn't see the changes of the transaction yet, rank a private one instead.
+		return newSearchIndex().search(ctx, terms, limit, s.repository.GetAll)
+	}
+
+	return s.index.search(ctx, terms, limit, s.repository.GetAll)
 }
 
 func (s *service) Answer(ctx context.Context, id int, answer string) (bool, error) {
@@ -62,8 +481,49 @@ func (s *service) Answer(ctx context.Context, id int, answer string) (bool, erro
 		return false, err
 	}
 
-	question.Answer = textinput.RecognizedAsNumber(question.Answer)
-	answer = textinput.RecognizedAsNumber(strings.Trim(answer, "\""))
+	return checkAnswer(s.units, question, answer)
+}
+
+func (s *service) Compact(ctx context.Context) error {
+	compactor, ok := s.repository.(Compactor)
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+
+	return compactor.Compact(ctx)
+}
+
+func (s *service) WithTx(ctx context.Context, fn func(tx Service) error) error {
+	var pending []indexChange
+	err := s.repository.WithTx(ctx, func(tx Repository) error {
+		pending = pending[:0]
+		txs := *s
+		txs.repository, txs.pending = tx, &pending
+		return fn(&txs)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.pending != nil {
+		*s.pending = append(*s.pending, pending...)
+	} else {
+		s.index.apply(pending...)
+	}
+
+	return nil
+}
+
+// normalizeQuestion normalizes the tags, the category and the matching options of question, the question and the
+// answer are kept as given.
+func normalizeQuestion(question *Question) (err error) {
+	if question.Tags, err = NormalizeTags(question.Tags); err != nil {
+		return err
+	}
+	if question.Category, err = NormalizeCategory(question.Category); err != nil {
+		return err
+	}
+	question.Matching, err = NormalizeMatching(question.Matching)
 
-	return question.Answer == answer, nil
+	return err
 }
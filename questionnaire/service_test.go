@@ -2,21 +2,49 @@ package questionnaire_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/muktihari/quiz_master/questionnaire"
+	_ "modernc.org/sqlite"
 )
 
 type mockRepository struct {
 	questionnaire.Repository
 	// injectable funcs to mock methods of questionnaire.Repository interface{}
-	getByIDFunc func(ctx context.Context, ID int) (*questionnaire.Question, error)
-	getAllFunc  func(ctx context.Context) ([]questionnaire.Question, error)
-	createFunc  func(ctx context.Context, question *questionnaire.Question) error
-	updateFunc  func(ctx context.Context, question *questionnaire.Question) error
-	deleteFunc  func(ctx context.Context, ID int) error
+	getBanksFunc        func(ctx context.Context) ([]questionnaire.Bank, error)
+	createBankFunc      func(ctx context.Context, bank *questionnaire.Bank) error
+	getByIDFunc         func(ctx context.Context, ID int) (*questionnaire.Question, error)
+	getAllFunc          func(ctx context.Context) ([]questionnaire.Question, error)
+	findFunc            func(ctx context.Context, query questionnaire.Query) (*questionnaire.Page, error)
+	createFunc          func(ctx context.Context, question *questionnaire.Question) error
+	updateFunc          func(ctx context.Context, question *questionnaire.Question) error
+	updateIfVersionFunc func(ctx context.Context, question *questionnaire.Question, version int) error
+	deleteFunc          func(ctx context.Context, ID int) error
+	trashFunc           func(ctx context.Context, ID int, at time.Time) error
+	restoreFunc         func(ctx context.Context, ID int) error
+	purgeFunc           func(ctx context.Context, before time.Time) (int, error)
+	addRevisionFunc     func(ctx context.Context, revision *questionnaire.Revision) error
+	getRevisionsFunc    func(ctx context.Context, ID int) ([]questionnaire.Revision, error)
+	withTxFunc          func(ctx context.Context, fn func(tx questionnaire.Repository) error) error
+}
+
+func (r *mockRepository) GetBanks(ctx context.Context) ([]questionnaire.Bank, error) {
+	if r.getBanksFunc == nil {
+		return []questionnaire.Bank{{Name: questionnaire.DefaultBank}}, nil // banks are not under test
+	}
+	return r.getBanksFunc(ctx)
+}
+
+func (r *mockRepository) CreateBank(ctx context.Context, bank *questionnaire.Bank) error {
+	return r.createBankFunc(ctx, bank)
 }
 
 func (r *mockRepository) GetByID(ctx context.Context, id int) (*questionnaire.Question, error) {
@@ -27,6 +55,10 @@ func (r *mockRepository) GetAll(ctx context.Context) ([]questionnaire.Question,
 	return r.getAllFunc(ctx)
 }
 
+func (r *mockRepository) Find(ctx context.Context, query questionnaire.Query) (*questionnaire.Page, error) {
+	return r.findFunc(ctx, query)
+}
+
 func (r *mockRepository) Create(ctx context.Context, question *questionnaire.Question) error {
 	return r.createFunc(ctx, question)
 }
@@ -35,10 +67,49 @@ func (r *mockRepository) Update(ctx context.Context, question *questionnaire.Que
 	return r.updateFunc(ctx, question)
 }
 
+func (r *mockRepository) UpdateIfVersion(ctx context.Context, question *questionnaire.Question, version int) error {
+	return r.updateIfVersionFunc(ctx, question, version)
+}
+
 func (r *mockRepository) Delete(ctx context.Context, id int) error {
 	return r.deleteFunc(ctx, id)
 }
 
+func (r *mockRepository) Trash(ctx context.Context, id int, at time.Time) error {
+	return r.trashFunc(ctx, id, at)
+}
+
+func (r *mockRepository) Restore(ctx context.Context, id int) error {
+	return r.restoreFunc(ctx, id)
+}
+
+func (r *mockRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return r.purgeFunc(ctx, before)
+}
+
+func (r *mockRepository) AddRevision(ctx context.Context, revision *questionnaire.Revision) error {
+	if r.addRevisionFunc == nil {
+		return nil // revisions are not under test
+	}
+	return r.addRevisionFunc(ctx, revision)
+}
+
+func (r *mockRepository) GetRevisions(ctx context.Context, id int) ([]questionnaire.Revision, error) {
+	return r.getRevisionsFunc(ctx, id)
+}
+
+func (r *mockRepository) WithTx(ctx context.Context, fn func(tx questionnaire.Repository) error) error {
+	if r.withTxFunc == nil {
+		return fn(r) // transactions are not under test
+	}
+	return r.withTxFunc(ctx, fn)
+}
+
+// getExistingQuestion mocks Repository.GetByID finding every question.
+func getExistingQuestion(ctx context.Context, ID int) (*questionnaire.Question, error) {
+	return &questionnaire.Question{ID: ID, Question: "1 + 1?", Answer: "2", Version: 1}, nil
+}
+
 func TestServiceGetByID(t *testing.T) {
 	var predefinedQuestions = []questionnaire.Question{
 		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
@@ -144,6 +215,60 @@ func TestServiceGetAll(t *testing.T) {
 	}
 }
 
+func TestServiceFind(t *testing.T) {
+	var predefinedQuestions = []questionnaire.Question{
+		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
+		{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"},
+	}
+
+	tt := []struct {
+		Name           string
+		Query          questionnaire.Query
+		MockRepository questionnaire.Repository
+		ExpectedPage   *questionnaire.Page
+		ExpectedErr    error
+	}{
+		{
+			Name:  "page retrieved",
+			Query: questionnaire.Query{Limit: 2},
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{findFunc: func(ctx context.Context, query questionnaire.Query) (*questionnaire.Page, error) {
+					return &questionnaire.Page{Questions: predefinedQuestions, Total: 2}, nil
+				}}
+			}(),
+			ExpectedPage: &questionnaire.Page{Questions: predefinedQuestions, Total: 2},
+			ExpectedErr:  nil,
+		},
+		{
+			Name:  "invalid cursor",
+			Query: questionnaire.Query{Cursor: "x"},
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{findFunc: func(ctx context.Context, query questionnaire.Query) (*questionnaire.Page, error) {
+					return nil, questionnaire.ErrInvalidCursor
+				}}
+			}(),
+			ExpectedPage: nil,
+			ExpectedErr:  questionnaire.ErrInvalidCursor,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			qs := questionnaire.NewService(tc.MockRepository)
+			page, err := qs.Find(ctx, tc.Query)
+			if !errors.Is(tc.ExpectedErr, err) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedPage, page); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestServiceCreate(t *testing.T) {
 	var predefinedQuestions = []questionnaire.Question{
 		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
@@ -207,7 +332,7 @@ func TestServiceUpdate(t *testing.T) {
 			Name:     "update question 1 success",
 			Question: &predefinedQuestions[0],
 			MockRepository: func() questionnaire.Repository {
-				return &mockRepository{updateFunc: func(ctx context.Context, question *questionnaire.Question) error {
+				return &mockRepository{getByIDFunc: getExistingQuestion, updateFunc: func(ctx context.Context, question *questionnaire.Question) error {
 					return nil
 				}}
 			}(),
@@ -217,8 +342,8 @@ func TestServiceUpdate(t *testing.T) {
 			Name:     "update question 1 failed not found",
 			Question: &predefinedQuestions[0],
 			MockRepository: func() questionnaire.Repository {
-				return &mockRepository{updateFunc: func(ctx context.Context, question *questionnaire.Question) error {
-					return questionnaire.ErrQuestionNotFound
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return nil, questionnaire.ErrQuestionNotFound
 				}}
 			}(),
 			ExpectedErr: questionnaire.ErrQuestionNotFound,
@@ -238,7 +363,61 @@ func TestServiceUpdate(t *testing.T) {
 	}
 }
 
+func TestServiceUpdateIfVersion(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Question         *questionnaire.Question
+		Version          int
+		MockRepository   questionnaire.Repository
+		ExpectedQuestion *questionnaire.Question
+		ExpectedErr      error
+	}{
+		{
+			Name:     "update question 1 at version 1 success, quotes kept",
+			Question: &questionnaire.Question{ID: 1, Question: "\"1 + 1?\"", Answer: "\"2\""},
+			Version:  1,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: getExistingQuestion, updateIfVersionFunc: func(ctx context.Context, question *questionnaire.Question, version int) error {
+					question.Version = version + 1
+					return nil
+				}}
+			}(),
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "\"1 + 1?\"", Answer: "\"2\"", Version: 2},
+			ExpectedErr:      nil,
+		},
+		{
+			Name:     "update question 1 at version 1 failed conflict",
+			Question: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"},
+			Version:  1,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: getExistingQuestion, updateIfVersionFunc: func(ctx context.Context, question *questionnaire.Question, version int) error {
+					return &questionnaire.VersionConflictError{ID: 1, Expected: version, Current: 3}
+				}}
+			}(),
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"},
+			ExpectedErr:      questionnaire.ErrVersionConflict,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			qs := questionnaire.NewService(tc.MockRepository)
+			if err := qs.UpdateIfVersion(ctx, tc.Question, tc.Version); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestion, tc.Question); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestServiceDelete(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
 	tt := []struct {
 		Name           string
 		QuestionID     int
@@ -246,10 +425,13 @@ func TestServiceDelete(t *testing.T) {
 		ExpectedErr    error
 	}{
 		{
-			Name:       "delete question 1 success",
+			Name:       "delete question 1 success, moved to trash",
 			QuestionID: 1,
 			MockRepository: func() questionnaire.Repository {
-				return &mockRepository{deleteFunc: func(ctx context.Context, ID int) error {
+				return &mockRepository{getByIDFunc: getExistingQuestion, trashFunc: func(ctx context.Context, ID int, at time.Time) error {
+					if !at.Equal(now) {
+						return fmt.Errorf("expected deleted at %v, got: %v", now, at)
+					}
 					return nil
 				}}
 			}(),
@@ -259,8 +441,8 @@ func TestServiceDelete(t *testing.T) {
 			Name:       "delete question 1 failed not found",
 			QuestionID: 1,
 			MockRepository: func() questionnaire.Repository {
-				return &mockRepository{deleteFunc: func(ctx context.Context, ID int) error {
-					return questionnaire.ErrQuestionNotFound
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return nil, questionnaire.ErrQuestionNotFound
 				}}
 			}(),
 			ExpectedErr: questionnaire.ErrQuestionNotFound,
@@ -272,7 +454,7 @@ func TestServiceDelete(t *testing.T) {
 	for _, tc := range tt {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
-			qs := questionnaire.NewService(tc.MockRepository)
+			qs := questionnaire.NewService(tc.MockRepository, questionnaire.WithClock(func() time.Time { return now }))
 			if err := qs.Delete(ctx, tc.QuestionID); !errors.Is(tc.ExpectedErr, err) {
 				t.Fatal(err)
 			}
@@ -280,10 +462,96 @@ func TestServiceDelete(t *testing.T) {
 	}
 }
 
+func TestServiceTrash(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	trashed := []questionnaire.Question{
+		{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1, DeletedAt: &deletedAt},
+	}
+
+	var query questionnaire.Query
+	mockRepository := &mockRepository{findFunc: func(ctx context.Context, q questionnaire.Query) (*questionnaire.Page, error) {
+		query = q
+		return &questionnaire.Page{Questions: trashed, Total: len(trashed)}, nil
+	}}
+
+	qs := questionnaire.NewService(mockRepository)
+	questions, err := qs.Trash(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !query.Trashed {
+		t.Fatalf("expected query for questions in trash, got: %+v", query)
+	}
+	if diff := cmp.Diff(trashed, questions); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestServicePurgeExpired(t *testing.T) {
+	now := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		Name           string
+		Retention      time.Duration
+		ExpectedBefore *time.Time
+		ExpectedBanks  []string
+	}{
+		{
+			Name:           "retention of 30 days, purge questions of every bank deleted up to 30 days ago",
+			Retention:      30 * 24 * time.Hour,
+			ExpectedBefore: func() *time.Time { t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+			ExpectedBanks:  []string{questionnaire.DefaultBank, "science"},
+		},
+		{
+			Name:           "no retention, nothing purged",
+			Retention:      0,
+			ExpectedBefore: nil,
+			ExpectedBanks:  nil,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				before *time.Time
+				banks  []string
+			)
+			mockRepository := &mockRepository{
+				getBanksFunc: func(ctx context.Context) ([]questionnaire.Bank, error) {
+					return []questionnaire.Bank{{Name: questionnaire.DefaultBank}, {Name: "science"}}, nil
+				},
+				purgeFunc: func(ctx context.Context, b time.Time) (int, error) {
+					before, banks = &b, append(banks, questionnaire.BankFromContext(ctx))
+					return 1, nil
+				},
+			}
+
+			qs := questionnaire.NewService(mockRepository,
+				questionnaire.WithClock(func() time.Time { return now }),
+				questionnaire.WithTrashRetention(tc.Retention),
+			)
+			if _, err := qs.PurgeExpired(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedBefore, before); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.ExpectedBanks, banks); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestServiceAnswer(t *testing.T) {
 	var predefinedQuestions = []questionnaire.Question{
 		{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"},
 		{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4"},
+		{ID: 3, Question: "How many days are there in a leap year?", Answer: "three hundred and sixty-six"},
+		{ID: 4, Question: "How far is a 5K run?", Answer: "5 km"},
 	}
 
 	tt := []struct {
@@ -330,6 +598,66 @@ func TestServiceAnswer(t *testing.T) {
 			ExpectedCorrectness: false,
 			ExpectedErr:         nil,
 		},
+		{
+			Name:       "answer question 3 with answer \"366\", correct",
+			QuestionID: 3,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return &predefinedQuestions[2], nil
+				}}
+			}(),
+			Answer:              "366",
+			ExpectedCorrectness: true,
+			ExpectedErr:         nil,
+		},
+		{
+			Name:       "answer question 3 with answer \"Three hundred sixty six\", correct",
+			QuestionID: 3,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return &predefinedQuestions[2], nil
+				}}
+			}(),
+			Answer:              "Three hundred sixty six",
+			ExpectedCorrectness: true,
+			ExpectedErr:         nil,
+		},
+		{
+			Name:       "answer question 4 with answer \"five kilometres\", correct",
+			QuestionID: 4,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return &predefinedQuestions[3], nil
+				}}
+			}(),
+			Answer:              "five kilometres",
+			ExpectedCorrectness: true,
+			ExpectedErr:         nil,
+		},
+		{
+			Name:       "answer question 4 with answer \"5000m\", correct",
+			QuestionID: 4,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return &predefinedQuestions[3], nil
+				}}
+			}(),
+			Answer:              "5000m",
+			ExpectedCorrectness: true,
+			ExpectedErr:         nil,
+		},
+		{
+			Name:       "answer question 4 with answer \"5 kg\", wrong dimension",
+			QuestionID: 4,
+			MockRepository: func() questionnaire.Repository {
+				return &mockRepository{getByIDFunc: func(ctx context.Context, ID int) (*questionnaire.Question, error) {
+					return &predefinedQuestions[3], nil
+				}}
+			}(),
+			Answer:              "5 kg",
+			ExpectedCorrectness: false,
+			ExpectedErr:         questionnaire.ErrWrongDimension,
+		},
 		{
 			Name:       "anwser question 3 not found",
 			QuestionID: 1,
@@ -351,7 +679,7 @@ func TestServiceAnswer(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			qs := questionnaire.NewService(tc.MockRepository)
 			correct, err := qs.Answer(ctx, tc.QuestionID, tc.Answer)
-			if !errors.Is(tc.ExpectedErr, err) {
+			if !errors.Is(err, tc.ExpectedErr) {
 				t.Fatal(err)
 			}
 			if tc.ExpectedCorrectness != correct {
@@ -360,3 +688,561 @@ func TestServiceAnswer(t *testing.T) {
 		})
 	}
 }
+
+type mockCompactorRepository struct {
+	mockRepository
+	compactFunc func(ctx context.Context) error
+}
+
+func (r *mockCompactorRepository) Compact(ctx context.Context) error {
+	return r.compactFunc(ctx)
+}
+
+func TestServiceCompact(t *testing.T) {
+	errDiskFull := errors.New("disk full")
+
+	tt := []struct {
+		Name           string
+		MockRepository questionnaire.Repository
+		ExpectedErr    error
+	}{
+		{
+			Name: "compact success",
+			MockRepository: &mockCompactorRepository{compactFunc: func(ctx context.Context) error {
+				return nil
+			}},
+			ExpectedErr: nil,
+		},
+		{
+			Name: "compact failed",
+			MockRepository: &mockCompactorRepository{compactFunc: func(ctx context.Context) error {
+				return errDiskFull
+			}},
+			ExpectedErr: errDiskFull,
+		},
+		{
+			Name:           "compact not supported",
+			MockRepository: &mockRepository{},
+			ExpectedErr:    questionnaire.ErrCompactionNotSupported,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			qs := questionnaire.NewService(tc.MockRepository)
+			if err := qs.Compact(ctx); !errors.Is(tc.ExpectedErr, err) {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestServiceWithTx(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tt := []struct {
+		Name             string
+		Fn               func(ctx context.Context, tx questionnaire.Service) error
+		ExpectedCommit   bool
+		ExpectedQuestion *questionnaire.Question
+		ExpectedErr      error
+	}{
+		{
+			Name: "fn succeed, committed",
+			Fn: func(ctx context.Context, tx questionnaire.Service) error {
+				return tx.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"})
+			},
+			ExpectedCommit:   true,
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"},
+			ExpectedErr:      nil,
+		},
+		{
+			Name: "fn failed, rolled back",
+			Fn: func(ctx context.Context, tx questionnaire.Service) error {
+				if err := tx.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			ExpectedCommit:   false,
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"},
+			ExpectedErr:      errAbort,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				created   *questionnaire.Question
+				committed bool
+			)
+			txRepository := &mockRepository{createFunc: func(ctx context.Context, question *questionnaire.Question) error {
+				created = question
+				return nil
+			}}
+			mockRepository := &mockRepository{withTxFunc: func(ctx context.Context, fn func(tx questionnaire.Repository) error) error {
+				if err := fn(txRepository); err != nil {
+					return err
+				}
+				committed = true
+				return nil
+			}}
+
+			qs := questionnaire.NewService(mockRepository)
+			err := qs.WithTx(ctx, func(tx questionnaire.Service) error { return tc.Fn(ctx, tx) })
+			if !errors.Is(tc.ExpectedErr, err) {
+				t.Fatal(err)
+			}
+			if tc.ExpectedCommit != committed {
+				t.Fatalf("expected committed: %v, got: %v", tc.ExpectedCommit, committed)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestion, created); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestServiceConcurrentUpdate(t *testing.T) {
+	const questions = 200
+
+	newRepositories := map[string]func(t *testing.T) questionnaire.Repository{
+		"inmem": func(t *testing.T) questionnaire.Repository { return questionnaire.NewRepository() },
+		"wal": func(t *testing.T) questionnaire.Repository {
+			r, err := questionnaire.NewWALRepository(filepath.Join(t.TempDir(), "questions.wal"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { r.(io.Closer).Close() })
+			return r
+		},
+		"sqlite": func(t *testing.T) questionnaire.Repository {
+			db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "questions.db")+
+				"?_pragma=busy_timeout(5000)&_txlock=immediate")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := questionnaire.NewSQLRepository(context.Background(), db)
+			if err != nil {
+				db.Close()
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { r.(io.Closer).Close() })
+			return r
+		},
+	}
+
+	for name, newRepository := range newRepositories {
+		newRepository := newRepository
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				qs  = questionnaire.NewService(newRepository(t))
+				wg  sync.WaitGroup
+			)
+			for id := 1; id <= questions; id++ {
+				if err := qs.Create(ctx, &questionnaire.Question{ID: id, Question: "1 + 1?", Answer: "2"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Every question is updated by its own goroutine, none of the updates fails because of another one.
+			for id := 1; id <= questions; id++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					if err := qs.Update(ctx, &questionnaire.Question{ID: id, Question: "1 + 2?", Answer: "3"}); err != nil {
+						t.Errorf("update question %d: %v", id, err)
+					}
+				}(id)
+			}
+			wg.Wait()
+
+			for id := 1; id <= questions; id++ {
+				revisions, err := qs.History(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(revisions) != 2 || revisions[1].New.Answer != "3" {
+					t.Fatalf("question %d: expected its creation and update, got: %v", id, revisions)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceHistory(t *testing.T) {
+	var (
+		now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		ctx = questionnaire.WithAuthor(context.Background(), "alice")
+		qs  = questionnaire.NewService(questionnaire.NewRepository(), questionnaire.WithClock(func() time.Time { return now }))
+
+		v1 = questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 1}
+		v2 = questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "3", Version: 2}
+		v3 = questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3}
+	)
+
+	steps := []func() error{
+		func() error {
+			return qs.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"})
+		},
+		func() error { return qs.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "3"}) },
+		func() error { return qs.Delete(ctx, 1) },
+		func() error { return qs.Restore(ctx, 1) },
+		func() error { _, err := qs.Revert(ctx, 1, 1); return err },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i+1, err)
+		}
+	}
+
+	expected := []questionnaire.Revision{
+		{QuestionID: 1, Number: 1, Change: questionnaire.ChangeCreate, Author: "alice", At: now, New: &v1},
+		{QuestionID: 1, Number: 2, Change: questionnaire.ChangeUpdate, Author: "alice", At: now, Old: &v1, New: &v2},
+		{QuestionID: 1, Number: 3, Change: questionnaire.ChangeDelete, Author: "alice", At: now, Old: &v2},
+		{QuestionID: 1, Number: 4, Change: questionnaire.ChangeRestore, Author: "alice", At: now, New: &v2},
+		{QuestionID: 1, Number: 5, Change: questionnaire.ChangeRevert, Author: "alice", At: now, Old: &v2, New: &v3},
+	}
+	revisions, err := qs.History(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected, revisions); diff != "" {
+		t.Fatal(diff)
+	}
+
+	tt := []struct {
+		Name        string
+		Do          func() error
+		ExpectedErr error
+	}{
+		{
+			Name:        "revert to revision 3 which deleted the question, failed not revertible",
+			Do:          func() error { _, err := qs.Revert(ctx, 1, 3); return err },
+			ExpectedErr: questionnaire.ErrRevisionNotRevertible,
+		},
+		{
+			Name:        "revert to revision 6, failed not found",
+			Do:          func() error { _, err := qs.Revert(ctx, 1, 6); return err },
+			ExpectedErr: questionnaire.ErrRevisionNotFound,
+		},
+		{
+			Name:        "history of question 2, failed not found",
+			Do:          func() error { _, err := qs.History(ctx, 2); return err },
+			ExpectedErr: questionnaire.ErrQuestionNotFound,
+		},
+		{
+			Name:        "update question 2, failed not found and no revision recorded",
+			Do:          func() error { return qs.Update(ctx, &questionnaire.Question{ID: 2, Question: "2 + 2?", Answer: "4"}) },
+			ExpectedErr: questionnaire.ErrQuestionNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Do(); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	if revisions, _ := qs.History(ctx, 1); len(revisions) != len(expected) {
+		t.Fatalf("expected %d revisions, got: %d", len(expected), len(revisions))
+	}
+}
+
+func TestServiceBanks(t *testing.T) {
+	var (
+		now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		ctx = context.Background()
+		qs  = questionnaire.NewService(questionnaire.NewRepository(), questionnaire.WithClock(func() time.Time { return now }))
+
+		scienceCtx = questionnaire.WithBank(ctx, "science")
+		question   = questionnaire.Question{ID: 1, Question: "How many planets are there in the solar system?", Answer: "8", Version: 1}
+	)
+
+	if err := qs.Create(ctx, &questionnaire.Question{ID: 1, Question: question.Question, Answer: question.Answer}); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name        string
+		Do          func() error
+		ExpectedErr error
+	}{
+		{
+			Name:        "create bank science",
+			Do:          func() error { return qs.CreateBank(ctx, &questionnaire.Bank{Name: "science"}) },
+			ExpectedErr: nil,
+		},
+		{
+			Name:        "create bank science again, failed already exist",
+			Do:          func() error { return qs.CreateBank(ctx, &questionnaire.Bank{Name: "science"}) },
+			ExpectedErr: questionnaire.ErrBankIsAlreadyExist,
+		},
+		{
+			Name:        "create bank with a space in its name, failed invalid name",
+			Do:          func() error { return qs.CreateBank(ctx, &questionnaire.Bank{Name: "social science"}) },
+			ExpectedErr: questionnaire.ErrInvalidBankName,
+		},
+		{
+			Name:        "copy question 1 to science",
+			Do:          func() error { _, err := qs.CopyQuestion(ctx, 1, "science"); return err },
+			ExpectedErr: nil,
+		},
+		{
+			Name:        "copy question 1 to science again, failed already exist",
+			Do:          func() error { _, err := qs.CopyQuestion(ctx, 1, "science"); return err },
+			ExpectedErr: questionnaire.ErrQuestionIsAlreadyExist,
+		},
+		{
+			Name:        "copy question 1 to history, failed bank not found",
+			Do:          func() error { _, err := qs.CopyQuestion(ctx, 1, "history"); return err },
+			ExpectedErr: questionnaire.ErrBankNotFound,
+		},
+		{
+			Name:        "copy question 2, failed not found",
+			Do:          func() error { _, err := qs.CopyQuestion(ctx, 2, "science"); return err },
+			ExpectedErr: questionnaire.ErrQuestionNotFound,
+		},
+		{
+			Name: "update the copy, the original is kept",
+			Do: func() error {
+				return qs.Update(scienceCtx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"})
+			},
+			ExpectedErr: nil,
+		},
+		{
+			Name:        "get bank history, failed not found",
+			Do:          func() error { _, err := qs.Bank(ctx, "history"); return err },
+			ExpectedErr: questionnaire.ErrBankNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Do(); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	banks, err := qs.Banks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]questionnaire.Bank{{Name: questionnaire.DefaultBank}, {Name: "science", CreatedAt: now}}, banks); diff != "" {
+		t.Fatal(diff)
+	}
+
+	original, err := qs.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&question, original); diff != "" {
+		t.Fatal(diff)
+	}
+
+	revisions, err := qs.History(scienceCtx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []questionnaire.Change{questionnaire.ChangeCopy, questionnaire.ChangeUpdate}
+	changes := make([]questionnaire.Change, len(revisions))
+	for i := range revisions {
+		changes[i] = revisions[i].Change
+	}
+	if diff := cmp.Diff(expected, changes); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestServiceTag(t *testing.T) {
+	var (
+		ctx = context.Background()
+		qs  = questionnaire.NewService(questionnaire.NewRepository())
+	)
+
+	err := qs.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Tags: []string{"Math"}, Category: "Math/Arithmetic/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name             string
+		Do               func() (*questionnaire.Question, error)
+		ExpectedQuestion *questionnaire.Question
+		ExpectedErr      error
+	}{
+		{
+			Name:             "tag question 1 with easy and math again",
+			Do:               func() (*questionnaire.Question, error) { return qs.Tag(ctx, 1, []string{"easy", "MATH"}) },
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 2, Tags: []string{"easy", "math"}, Category: "math/arithmetic"},
+		},
+		{
+			Name:             "untag question 1 math and hard",
+			Do:               func() (*questionnaire.Question, error) { return qs.Untag(ctx, 1, []string{"math", "hard"}) },
+			ExpectedQuestion: &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Version: 3, Tags: []string{"easy"}, Category: "math/arithmetic"},
+		},
+		{
+			Name:        "tag question 1 with invalid tag",
+			Do:          func() (*questionnaire.Question, error) { return qs.Tag(ctx, 1, []string{"very hard"}) },
+			ExpectedErr: questionnaire.ErrInvalidTag,
+		},
+		{
+			Name:        "tag question 2, failed not found",
+			Do:          func() (*questionnaire.Question, error) { return qs.Tag(ctx, 2, []string{"easy"}) },
+			ExpectedErr: questionnaire.ErrQuestionNotFound,
+		},
+		{
+			Name: "update question 1 with invalid category",
+			Do: func() (*questionnaire.Question, error) {
+				return nil, qs.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2", Category: "math//arithmetic"})
+			},
+			ExpectedErr: questionnaire.ErrInvalidCategory,
+		},
+	}
+
+	// The order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			question, err := tc.Do()
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestion, question); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestServiceSearch(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		scienceCtx = questionnaire.WithBank(ctx, "science")
+		r          = questionnaire.NewRepository()
+		qs         = questionnaire.NewService(r)
+	)
+
+	// Created before the first search, found once the index is built.
+	if err := qs.Create(ctx, &questionnaire.Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.CreateBank(ctx, &questionnaire.Bank{Name: "science"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.Create(scienceCtx, &questionnaire.Question{ID: 1, Question: "How many legs does a spider have?", Answer: "8"}); err != nil {
+		t.Fatal(err)
+	}
+
+	errAbort := errors.New("abort")
+
+	tt := []struct {
+		Name        string
+		Do          func() error
+		Ctx         context.Context
+		Terms       string
+		ExpectedIDs []int
+	}{
+		{
+			Name:        "build index",
+			Do:          func() error { return nil },
+			Ctx:         ctx,
+			Terms:       "how many",
+			ExpectedIDs: []int{1},
+		},
+		{
+			Name: "create question 2",
+			Do: func() error {
+				return qs.Create(ctx, &questionnaire.Question{ID: 2, Question: "How many characters are there in \"Engineer\"?", Answer: "8"})
+			},
+			Ctx:         ctx,
+			Terms:       "engineer",
+			ExpectedIDs: []int{2},
+		},
+		{
+			Name: "update question 1",
+			Do: func() error {
+				return qs.Update(ctx, &questionnaire.Question{ID: 1, Question: "Quipper vs Ruangguru?", Answer: "Quipper"})
+			},
+			Ctx:         ctx,
+			Terms:       "characters quipper",
+			ExpectedIDs: []int{1, 2},
+		},
+		{
+			Name:        "delete question 2",
+			Do:          func() error { return qs.Delete(ctx, 2) },
+			Ctx:         ctx,
+			Terms:       "engineer",
+			ExpectedIDs: []int{},
+		},
+		{
+			Name: "rolled back transaction creating question 3",
+			Do: func() error {
+				err := qs.WithTx(ctx, func(tx questionnaire.Service) error {
+					err := tx.Create(ctx, &questionnaire.Question{ID: 3, Question: "Who is the Quipper mascot?", Answer: "Q"})
+					if err != nil {
+						return err
+					}
+					if results, _ := tx.Search(ctx, "mascot", 0); len(results) != 1 {
+						return fmt.Errorf("expected the transaction to find question 3, got: %v", results)
+					}
+					return errAbort
+				})
+				if !errors.Is(err, errAbort) {
+					return err
+				}
+				return nil
+			},
+			Ctx:         ctx,
+			Terms:       "mascot",
+			ExpectedIDs: []int{},
+		},
+		{
+			Name:        "restore question 2",
+			Do:          func() error { return qs.Restore(ctx, 2) },
+			Ctx:         ctx,
+			Terms:       "engineer",
+			ExpectedIDs: []int{2},
+		},
+		{
+			Name:        "search other bank",
+			Do:          func() error { return nil },
+			Ctx:         scienceCtx,
+			Terms:       "how many",
+			ExpectedIDs: []int{1},
+		},
+	}
+
+	// The order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Do(); err != nil {
+				t.Fatal(err)
+			}
+			results, err := qs.Search(tc.Ctx, tc.Terms, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int, len(results))
+			for i := range results {
+				ids[i] = results[i].Question.ID
+			}
+			if diff := cmp.Diff(tc.ExpectedIDs, ids); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
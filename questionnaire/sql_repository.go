@@ -0,0 +1,599 @@
+package questionnaire
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewSQLRepository creates Repository that keeps the questions in the SQL database db, the schema
+// is migrated to the latest version before the repository is returned. The queries are written for
+// SQLite. The repository takes ownership of db, it is closed by the repository's Close.
+// Concurrent writers need db to wait for the lock and to begin transactions immediately, e.g. with
+// "_pragma=busy_timeout(5000)&_txlock=immediate", or a transaction may fail with SQLITE_BUSY. The transactions
+// of the repository wait for each other, so the busy timeout only has to cover the ones of other processes.
+// The returned Repository also implements Compactor and io.Closer.
+func NewSQLRepository(ctx context.Context, db *sql.DB) (Repository, error) {
+	if _, err := migrate(ctx, db); err != nil {
+		return nil, err
+	}
+	if err := foldQuestions(ctx, db); err != nil {
+		return nil, fmt.Errorf("could not fold questions: %w", err)
+	}
+
+	return &sqlRepository{db: db, q: db, txMu: new(sync.Mutex)}, nil
+}
+
+// foldCase returns s in lower case, as the question_folded column keeps the question, see migration 0008.
+func foldCase(s string) string {
+	return strings.ToLower(s)
+}
+
+// foldQuestions fills the question_folded column of the questions written before it existed.
+func foldQuestions(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT bank, id, question FROM questions WHERE question_folded IS NULL`)
+	if err != nil {
+		return err
+	}
+	type unfolded struct {
+		bank     string
+		id       int
+		question string
+	}
+	var questions []unfolded
+	for rows.Next() {
+		var q unfolded
+		if err := rows.Scan(&q.bank, &q.id, &q.question); err != nil {
+			rows.Close()
+			return err
+		}
+		questions = append(questions, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, q := range questions {
+		_, err := tx.ExecContext(ctx, `UPDATE questions SET question_folded = ? WHERE bank = ? AND id = ?`,
+			foldCase(q.question), q.bank, q.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+type sqlRepository struct {
+	db    *sql.DB
+	q     sqlQuerier  // db, or the transaction the repository is bound to
+	depth int         // nesting depth of the transaction, 0 when not in a transaction
+	txMu  *sync.Mutex // serializes the transactions of db
+}
+
+// sqlQuerier is implemented by both *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// questionColumns are the columns scanned by scanQuestion, in order.
+const questionColumns = `id, question, answer, version, deleted_at, tags, category, matching`
+
+func (r *sqlRepository) GetBanks(ctx context.Context) ([]Bank, error) {
+	rows, err := r.q.QueryContext(ctx, `SELECT name, created_at FROM banks ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	banks := make([]Bank, 0)
+	for rows.Next() {
+		var (
+			bank      Bank
+			createdAt int64
+		)
+		if err := rows.Scan(&bank.Name, &createdAt); err != nil {
+			return nil, err
+		}
+		if createdAt != 0 {
+			bank.CreatedAt = time.Unix(0, createdAt).UTC()
+		}
+		banks = append(banks, bank)
+	}
+
+	return banks, rows.Err()
+}
+
+func (r *sqlRepository) CreateBank(ctx context.Context, bank *Bank) error {
+	var createdAt int64
+	if !bank.CreatedAt.IsZero() {
+		createdAt = bank.CreatedAt.UnixNano()
+	}
+
+	res, err := r.q.ExecContext(ctx, `INSERT INTO banks (name, created_at) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`,
+		bank.Name, createdAt)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrBankIsAlreadyExist)
+}
+
+func (r *sqlRepository) GetByID(ctx context.Context, id int) (*Question, error) {
+	question, err := scanQuestion(r.q.QueryRowContext(ctx, `SELECT `+questionColumns+` FROM questions
+		WHERE bank = ? AND id = ? AND deleted_at IS NULL`, BankFromContext(ctx), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+func (r *sqlRepository) GetAll(ctx context.Context) ([]Question, error) {
+	rows, err := r.q.QueryContext(ctx, `SELECT `+questionColumns+` FROM questions WHERE bank = ? AND deleted_at IS NULL ORDER BY seq`,
+		BankFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQuestions(rows)
+}
+
+func (r *sqlRepository) Find(ctx context.Context, query Query) (*Page, error) {
+	var (
+		conds = []string{`bank = ?`, `deleted_at IS NULL`}
+		args  = []any{BankFromContext(ctx)}
+	)
+	if query.Trashed {
+		conds[1] = `deleted_at IS NOT NULL`
+	}
+	if query.MinID != nil {
+		conds, args = append(conds, `id >= ?`), append(args, *query.MinID)
+	}
+	if query.MaxID != nil {
+		conds, args = append(conds, `id <= ?`), append(args, *query.MaxID)
+	}
+	if query.Contains != "" {
+		conds, args = append(conds, `instr(question_folded, ?) > 0`), append(args, foldCase(query.Contains))
+	}
+	if query.Tags != nil {
+		cond, tagArgs := tagExprSQL(query.Tags)
+		conds, args = append(conds, cond), append(args, tagArgs...)
+	}
+	if query.Category != "" {
+		conds, args = append(conds, `(category = ? OR substr(category, 1, ?) = ?)`),
+			append(args, query.Category, len(query.Category)+1, query.Category+"/")
+	}
+
+	page := &Page{}
+	if err := r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM questions`+where(conds), args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	var orderBy string
+	switch query.Sort {
+	case SortByIDDesc:
+		orderBy = `id DESC`
+	case SortByQuestionAsc:
+		orderBy = `question, id`
+	case SortByQuestionDesc:
+		orderBy = `question DESC, id DESC`
+	default:
+		orderBy = `id`
+	}
+
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		switch query.Sort {
+		case SortByIDDesc:
+			conds, args = append(conds, `id < ?`), append(args, c.ID)
+		case SortByQuestionAsc:
+			conds, args = append(conds, `(question, id) > (?, ?)`), append(args, c.Question, c.ID)
+		case SortByQuestionDesc:
+			conds, args = append(conds, `(question, id) < (?, ?)`), append(args, c.Question, c.ID)
+		default:
+			conds, args = append(conds, `id > ?`), append(args, c.ID)
+		}
+	}
+
+	// Fetch one more than the limit to know whether there is a next page, -1 is no limit in SQLite.
+	limit := -1
+	if query.Limit > 0 {
+		limit = query.Limit + 1
+	}
+	args = append(args, limit, max(query.Offset, 0))
+
+	rows, err := r.q.QueryContext(ctx, `SELECT `+questionColumns+` FROM questions`+where(conds)+
+		` ORDER BY `+orderBy+` LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if page.Questions, err = scanQuestions(rows); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Questions) > query.Limit {
+		page.Questions = page.Questions[:query.Limit]
+		page.NextCursor = newCursor(query.Sort, &page.Questions[query.Limit-1])
+	}
+
+	return page, nil
+}
+
+func (r *sqlRepository) GetMaxID(ctx context.Context) (int, error) {
+	var id int
+	err := r.q.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM questions WHERE bank = ? AND id > 0`,
+		BankFromContext(ctx)).Scan(&id)
+
+	return id, err
+}
+
+// GetFirstFreeID finds the lowest ID whose next one is free, 1 when it's free itself, by index lookups.
+func (r *sqlRepository) GetFirstFreeID(ctx context.Context) (int, error) {
+	var id int
+	err := r.q.QueryRowContext(ctx, `SELECT CASE
+		WHEN NOT EXISTS (SELECT 1 FROM questions WHERE bank = ?1 AND id = 1) THEN 1
+		ELSE (SELECT MIN(q.id) + 1 FROM questions q WHERE q.bank = ?1 AND q.id > 0
+			AND NOT EXISTS (SELECT 1 FROM questions n WHERE n.bank = ?1 AND n.id = q.id + 1))
+		END`, BankFromContext(ctx)).Scan(&id)
+
+	return id, err
+}
+
+func (r *sqlRepository) Create(ctx context.Context, question *Question) error {
+	bank := BankFromContext(ctx)
+	res, err := r.q.ExecContext(ctx, `INSERT INTO questions (bank, id, question, question_folded, answer, version, tags,
+		category, matching) SELECT name, ?, ?, ?, ?, 1, ?, ?, ? FROM banks WHERE name = ? ON CONFLICT (bank, id) DO NOTHING`,
+		question.ID, question.Question, foldCase(question.Question), question.Answer, encodeTags(question.Tags),
+		question.Category, encodeTags(question.Matching), bank)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, ErrQuestionIsAlreadyExist); err != nil {
+		// Nothing inserted, tell apart a missing bank and a question in trash from an existing one.
+		var trashed bool
+		err := r.q.QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM questions WHERE bank = ? AND id = ?`,
+			bank, question.ID).Scan(&trashed)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrBankNotFound
+		case err != nil:
+			return err
+		case trashed:
+			return ErrQuestionInTrash
+		}
+		return ErrQuestionIsAlreadyExist
+	}
+
+	question.Version = 1
+	question.DeletedAt = nil
+
+	return nil
+}
+
+func (r *sqlRepository) Update(ctx context.Context, question *Question) error {
+	err := r.q.QueryRowContext(ctx, `UPDATE questions SET question = ?, question_folded = ?, answer = ?, tags = ?,
+		category = ?, matching = ?, version = version + 1 WHERE bank = ? AND id = ? AND deleted_at IS NULL
+		RETURNING version`, question.Question, foldCase(question.Question), question.Answer, encodeTags(question.Tags),
+		question.Category, encodeTags(question.Matching), BankFromContext(ctx), question.ID).Scan(&question.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	question.DeletedAt = nil
+
+	return nil
+}
+
+func (r *sqlRepository) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	err := r.q.QueryRowContext(ctx, `UPDATE questions SET question = ?, question_folded = ?, answer = ?, tags = ?,
+		category = ?, matching = ?, version = version + 1 WHERE bank = ? AND id = ? AND version = ? AND deleted_at IS NULL
+		RETURNING version`, question.Question, foldCase(question.Question), question.Answer, encodeTags(question.Tags),
+		question.Category, encodeTags(question.Matching), BankFromContext(ctx), question.ID, version).Scan(&question.Version)
+	if err == nil {
+		question.DeletedAt = nil
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Nothing updated, tell apart a missing question from a stale version.
+	current, err := r.GetByID(ctx, question.ID)
+	if err != nil {
+		return err
+	}
+
+	return &VersionConflictError{ID: question.ID, Expected: version, Current: current.Version}
+}
+
+func (r *sqlRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.q.ExecContext(ctx, `DELETE FROM questions WHERE bank = ? AND id = ?`, BankFromContext(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrQuestionNotFound)
+}
+
+func (r *sqlRepository) Trash(ctx context.Context, id int, at time.Time) error {
+	res, err := r.q.ExecContext(ctx, `UPDATE questions SET deleted_at = ?
+		WHERE bank = ? AND id = ? AND deleted_at IS NULL`, at.UnixNano(), BankFromContext(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrQuestionNotFound)
+}
+
+func (r *sqlRepository) Restore(ctx context.Context, id int) error {
+	res, err := r.q.ExecContext(ctx, `UPDATE questions SET deleted_at = NULL
+		WHERE bank = ? AND id = ? AND deleted_at IS NOT NULL`, BankFromContext(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrQuestionNotFound)
+}
+
+func (r *sqlRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.q.ExecContext(ctx, `DELETE FROM questions WHERE bank = ? AND deleted_at <= ?`,
+		BankFromContext(ctx), before.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not get affected rows: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func (r *sqlRepository) AddRevision(ctx context.Context, revision *Revision) error {
+	before, err := encodeRevisionQuestion(revision.Old)
+	if err != nil {
+		return err
+	}
+	after, err := encodeRevisionQuestion(revision.New)
+	if err != nil {
+		return err
+	}
+
+	bank := BankFromContext(ctx)
+	err = r.q.QueryRowContext(ctx, `INSERT INTO question_revisions (bank, question_id, number, change, author, at, old, new)
+		SELECT name, ?, (SELECT COALESCE(MAX(number), 0) + 1 FROM question_revisions WHERE bank = ? AND question_id = ?),
+		?, ?, ?, ?, ? FROM banks WHERE name = ? RETURNING number`, revision.QuestionID, bank, revision.QuestionID,
+		revision.Change, revision.Author, revision.At.UnixNano(), before, after, bank).Scan(&revision.Number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrBankNotFound
+	}
+
+	return err
+}
+
+func (r *sqlRepository) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	rows, err := r.q.QueryContext(ctx, `SELECT question_id, number, change, author, at, old, new
+		FROM question_revisions WHERE bank = ? AND question_id = ? ORDER BY number`, BankFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]Revision, 0)
+	for rows.Next() {
+		var (
+			revision Revision
+			at       int64
+			before   sql.NullString
+			after    sql.NullString
+		)
+		if err := rows.Scan(&revision.QuestionID, &revision.Number, &revision.Change, &revision.Author,
+			&at, &before, &after); err != nil {
+			return nil, err
+		}
+		revision.At = time.Unix(0, at).UTC()
+		if revision.Old, err = decodeRevisionQuestion(before); err != nil {
+			return nil, err
+		}
+		if revision.New, err = decodeRevisionQuestion(after); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// WithTx runs fn in a database transaction, a transaction opened inside a transaction is a savepoint.
+func (r *sqlRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	if r.depth > 0 {
+		return r.withSavepoint(ctx, fn)
+	}
+
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&sqlRepository{db: r.db, q: tx, depth: 1, txMu: r.txMu}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *sqlRepository) withSavepoint(ctx context.Context, fn func(tx Repository) error) error {
+	name := fmt.Sprintf("tx_%d", r.depth)
+	if _, err := r.q.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return err
+	}
+	if err := fn(&sqlRepository{db: r.db, q: r.q, depth: r.depth + 1, txMu: r.txMu}); err != nil {
+		if _, rerr := r.q.ExecContext(ctx, `ROLLBACK TO `+name); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		_, _ = r.q.ExecContext(ctx, `RELEASE `+name)
+		return err
+	}
+
+	_, err := r.q.ExecContext(ctx, `RELEASE `+name)
+	return err
+}
+
+// Compact rebuilds the database file, reclaiming the pages left by deleted questions.
+func (r *sqlRepository) Compact(ctx context.Context) error {
+	_, err := r.q.ExecContext(ctx, `VACUUM`)
+	return err
+}
+
+// Close closes the database.
+func (r *sqlRepository) Close() error {
+	return r.db.Close()
+}
+
+// scanQuestion scans a row selecting questionColumns.
+func scanQuestion(row interface{ Scan(dest ...any) error }) (*Question, error) {
+	var (
+		question  Question
+		deletedAt sql.NullInt64
+		tags      string
+		matching  string
+	)
+	if err := row.Scan(&question.ID, &question.Question, &question.Answer, &question.Version, &deletedAt,
+		&tags, &question.Category, &matching); err != nil {
+		return nil, err
+	}
+	question.Tags = decodeTags(tags)
+	question.Matching = decodeTags(matching)
+	if deletedAt.Valid {
+		t := time.Unix(0, deletedAt.Int64).UTC()
+		question.DeletedAt = &t
+	}
+
+	return &question, nil
+}
+
+// scanQuestions scans all rows selecting questionColumns.
+func scanQuestions(rows *sql.Rows) ([]Question, error) {
+	questions := make([]Question, 0)
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *question)
+	}
+
+	return questions, rows.Err()
+}
+
+func encodeRevisionQuestion(question *Question) (sql.NullString, error) {
+	if question == nil {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(question)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("could not encode revision: %w", err)
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func decodeRevisionQuestion(s sql.NullString) (*Question, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	var question Question
+	if err := json.Unmarshal([]byte(s.String), &question); err != nil {
+		return nil, fmt.Errorf("could not decode revision: %w", err)
+	}
+
+	return &question, nil
+}
+
+// encodeTags joins sorted tags as kept in the tags column, see migration 0006, and matching options as kept in the
+// matching column.
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "," + strings.Join(tags, ",") + ","
+}
+
+func decodeTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.Trim(s, ","), ",")
+}
+
+// tagExprSQL translates e into a condition on the tags column.
+func tagExprSQL(e *TagExpr) (string, []any) {
+	switch e.op {
+	case tagOpNot:
+		cond, args := tagExprSQL(e.left)
+		return `NOT ` + cond, args
+	case tagOpAnd, tagOpOr:
+		op := ` AND `
+		if e.op == tagOpOr {
+			op = ` OR `
+		}
+		left, leftArgs := tagExprSQL(e.left)
+		right, rightArgs := tagExprSQL(e.right)
+		return `(` + left + op + right + `)`, append(leftArgs, rightArgs...)
+	default:
+		return `instr(tags, ?) > 0`, []any{"," + e.tag + ","}
+	}
+}
+
+// where joins conds into a WHERE clause, empty when there is no condition.
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conds, ` AND `)
+}
+
+// checkAffected returns errNone when res did not affect any row.
+func checkAffected(res sql.Result, errNone error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if n == 0 {
+		return errNone
+	}
+
+	return nil
+}
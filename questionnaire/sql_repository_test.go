@@ -0,0 +1,176 @@
+package questionnaire
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	_ "modernc.org/sqlite"
+)
+
+// openSQL opens the SQLite database in path as Repository and registers its closing to t.
+func openSQL(t *testing.T, path string) Repository {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewSQLRepository(context.Background(), db)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.(io.Closer).Close() })
+
+	return r
+}
+
+func TestSQLRepositoryGetByID(t *testing.T) {
+	question1 := Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+
+	tt := []struct {
+		Name             string
+		QuestionID       int
+		ExpectedQuestion *Question
+		ExpectedErr      error
+	}{
+		{
+			Name:             "get question ID 1, found",
+			QuestionID:       1,
+			ExpectedQuestion: &question1,
+			ExpectedErr:      nil,
+		},
+		{
+			Name:             "get question ID 2, not found",
+			QuestionID:       2,
+			ExpectedQuestion: nil,
+			ExpectedErr:      ErrQuestionNotFound,
+		},
+	}
+
+	var (
+		ctx = context.Background()
+		r   = openSQL(t, filepath.Join(t.TempDir(), "questions.db"))
+	)
+
+	if err := r.Create(ctx, &question1); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			question, err := r.GetByID(ctx, tc.QuestionID)
+			if !errors.Is(tc.ExpectedErr, err) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestion, question); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSQLRepositoryPersistence(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
+		question9 = Question{ID: 9, Question: "9 x 9?", Answer: "81", Version: 1}
+
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7", Version: 2}
+	)
+
+	tt := []struct {
+		Name              string
+		Do                func(ctx context.Context, r Repository) error
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:              "create question 9",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question9) },
+			ExpectedQuestions: []Question{question9},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 1, kept after question 9",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question9, question1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question2) },
+			ExpectedQuestions: []Question{question9, question1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 1, failed duplicate",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question9, question1, question2},
+			ExpectedErr:       ErrQuestionIsAlreadyExist,
+		},
+		{
+			Name:              "update question 1",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &updatedQuestion1) },
+			ExpectedQuestions: []Question{question9, updatedQuestion1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "update question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &question3) },
+			ExpectedQuestions: []Question{question9, updatedQuestion1, question2},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "delete question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 2) },
+			ExpectedQuestions: []Question{question9, updatedQuestion1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "delete question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 3) },
+			ExpectedQuestions: []Question{question9, updatedQuestion1},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "compact",
+			Do:                func(ctx context.Context, r Repository) error { return r.(Compactor).Compact(ctx) },
+			ExpectedQuestions: []Question{question9, updatedQuestion1},
+			ExpectedErr:       nil,
+		},
+	}
+
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.db")
+	)
+
+	// Every case reopens the database, the order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			r := openSQL(t, path)
+			if err := tc.Do(ctx, r); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			r.(io.Closer).Close()
+
+			questions, err := openSQL(t, path).GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package questionnaire
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidTag      = errors.New("invalid tag, should be letters, digits, \"-\" or \"_\" and not AND, OR or NOT")
+	ErrInvalidCategory = errors.New("invalid category, should be names of letters, digits, \"-\" or \"_\" separated by \"/\"")
+	ErrInvalidTagExpr  = errors.New("invalid tag expression")
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// NormalizeTag returns tag in lower case, ErrInvalidTag when it can't be used as a tag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if len(tag) > 64 || !tagPattern.MatchString(tag) || isTagOperator(tag) {
+		return "", fmt.Errorf("%q: %w", tag, ErrInvalidTag)
+	}
+	return tag, nil
+}
+
+// NormalizeTags returns tags normalized by NormalizeTag, sorted and without duplicate, nil when there is no tag.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(normalized)
+	n := 1
+	for i := 1; i < len(normalized); i++ {
+		if normalized[i] != normalized[n-1] {
+			normalized[n] = normalized[i]
+			n++
+		}
+	}
+
+	return normalized[:n], nil
+}
+
+// NormalizeCategory returns category in lower case without leading and trailing "/",
+// ErrInvalidCategory when one of its names can't be used.
+func NormalizeCategory(category string) (string, error) {
+	category = strings.Trim(strings.ToLower(strings.TrimSpace(category)), "/")
+	if category == "" {
+		return "", nil
+	}
+
+	for _, name := range strings.Split(category, "/") {
+		if len(name) > 64 || !tagPattern.MatchString(name) {
+			return "", fmt.Errorf("%q: %w", category, ErrInvalidCategory)
+		}
+	}
+
+	return category, nil
+}
+
+// inCategory reports whether category is parent or one of its subcategories.
+func inCategory(category, parent string) bool {
+	return category == parent || strings.HasPrefix(category, parent+"/")
+}
+
+// hasTag reports whether sorted tags contain tag.
+func hasTag(tags []string, tag string) bool {
+	i := sort.SearchStrings(tags, tag)
+	return i < len(tags) && tags[i] == tag
+}
+
+// TagExpr is a boolean expression over the tags of a question, e.g. "math AND NOT (hard OR quiz-2)".
+// NOT binds tighter than AND, which binds tighter than OR, the operators are case-insensitive.
+type TagExpr struct {
+	op          tagOp
+	tag         string   // for tagOpTag
+	left, right *TagExpr // right is nil for tagOpNot
+}
+
+type tagOp int
+
+const (
+	tagOpTag tagOp = iota
+	tagOpNot
+	tagOpAnd
+	tagOpOr
+)
+
+func isTagOperator(s string) bool {
+	switch strings.ToUpper(s) {
+	case "AND", "OR", "NOT":
+		return true
+	}
+	return false
+}
+
+// ParseTagExpr parses a boolean expression over tags, see TagExpr.
+func ParseTagExpr(s string) (*TagExpr, error) {
+	p := &tagExprParser{tokens: tokenizeTagExpr(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty expression: %w", ErrInvalidTagExpr)
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q: %w", p.tokens[p.pos], ErrInvalidTagExpr)
+	}
+
+	return e, nil
+}
+
+// tokenizeTagExpr splits s into parentheses and words.
+func tokenizeTagExpr(s string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(s) {
+		for field != "" {
+			i := strings.IndexAny(field, "()")
+			switch {
+			case i < 0:
+				tokens, field = append(tokens, field), ""
+			case i == 0:
+				tokens, field = append(tokens, field[:1]), field[1:]
+			default:
+				tokens, field = append(tokens, field[:i]), field[i:]
+			}
+		}
+	}
+	return tokens
+}
+
+type tagExprParser struct {
+	tokens []string
+	pos    int
+}
+
+// accept consumes the next token if it's the operator or parenthesis op.
+func (p *tagExprParser) accept(op string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tagExprParser) parseOr() (*TagExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &TagExpr{op: tagOpOr, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *tagExprParser) parseAnd() (*TagExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &TagExpr{op: tagOpAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *tagExprParser) parseNot() (*TagExpr, error) {
+	if p.accept("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &TagExpr{op: tagOpNot, left: e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *tagExprParser) parsePrimary() (*TagExpr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression: %w", ErrInvalidTagExpr)
+	}
+
+	if p.accept("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing \")\": %w", ErrInvalidTagExpr)
+		}
+		return e, nil
+	}
+
+	token := p.tokens[p.pos]
+	if token == ")" || isTagOperator(token) {
+		return nil, fmt.Errorf("unexpected %q: %w", token, ErrInvalidTagExpr)
+	}
+	tag, err := NormalizeTag(token)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidTagExpr)
+	}
+	p.pos++
+
+	return &TagExpr{op: tagOpTag, tag: tag}, nil
+}
+
+// Match reports whether sorted tags satisfy the expression.
+func (e *TagExpr) Match(tags []string) bool {
+	switch e.op {
+	case tagOpNot:
+		return !e.left.Match(tags)
+	case tagOpAnd:
+		return e.left.Match(tags) && e.right.Match(tags)
+	case tagOpOr:
+		return e.left.Match(tags) || e.right.Match(tags)
+	default:
+		return hasTag(tags, e.tag)
+	}
+}
+
+// String returns the expression fully parenthesized, it's parsed back to the same expression.
+func (e *TagExpr) String() string {
+	switch e.op {
+	case tagOpNot:
+		return "NOT " + e.left.String()
+	case tagOpAnd:
+		return "(" + e.left.String() + " AND " + e.right.String() + ")"
+	case tagOpOr:
+		return "(" + e.left.String() + " OR " + e.right.String() + ")"
+	default:
+		return e.tag
+	}
+}
@@ -0,0 +1,147 @@
+package questionnaire
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mustParseTagExpr(t *testing.T, s string) *TagExpr {
+	t.Helper()
+
+	e, err := ParseTagExpr(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Tags         []string
+		ExpectedTags []string
+		ExpectedErr  error
+	}{
+		{
+			Name:         "lower case, sorted and unique",
+			Tags:         []string{"Math", " hard", "math", "grade-2"},
+			ExpectedTags: []string{"grade-2", "hard", "math"},
+		},
+		{
+			Name:         "no tag",
+			Tags:         []string{},
+			ExpectedTags: nil,
+		},
+		{
+			Name:        "space in tag",
+			Tags:        []string{"hard math"},
+			ExpectedErr: ErrInvalidTag,
+		},
+		{
+			Name:        "operator as tag",
+			Tags:        []string{"not"},
+			ExpectedErr: ErrInvalidTag,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			tags, err := NormalizeTags(tc.Tags)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedTags, tags); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	tt := []struct {
+		Category         string
+		ExpectedCategory string
+		ExpectedErr      error
+	}{
+		{Category: "Science/Physics", ExpectedCategory: "science/physics"},
+		{Category: "/science/", ExpectedCategory: "science"},
+		{Category: "", ExpectedCategory: ""},
+		{Category: "science//physics", ExpectedErr: ErrInvalidCategory},
+		{Category: "science/quantum physics", ExpectedErr: ErrInvalidCategory},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Category, func(t *testing.T) {
+			category, err := NormalizeCategory(tc.Category)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if category != tc.ExpectedCategory {
+				t.Fatalf("expected: %q, got: %q", tc.ExpectedCategory, category)
+			}
+		})
+	}
+}
+
+func TestParseTagExpr(t *testing.T) {
+	tt := []struct {
+		Expr        string
+		Expected    string
+		ExpectedErr error
+	}{
+		{Expr: "math", Expected: "math"},
+		{Expr: "math AND NOT hard", Expected: "(math AND NOT hard)"},
+		{Expr: "math or science and not hard", Expected: "(math OR (science AND NOT hard))"},
+		{Expr: "(math OR science) AND NOT NOT hard", Expected: "((math OR science) AND NOT NOT hard)"},
+		{Expr: "NOT(Math)", Expected: "NOT math"},
+		{Expr: "", ExpectedErr: ErrInvalidTagExpr},
+		{Expr: "math AND", ExpectedErr: ErrInvalidTagExpr},
+		{Expr: "math hard", ExpectedErr: ErrInvalidTagExpr},
+		{Expr: "(math OR hard", ExpectedErr: ErrInvalidTagExpr},
+		{Expr: "math)", ExpectedErr: ErrInvalidTagExpr},
+		{Expr: "math AND h@rd", ExpectedErr: ErrInvalidTagExpr},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Expr, func(t *testing.T) {
+			e, err := ParseTagExpr(tc.Expr)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if err != nil {
+				return
+			}
+			if e.String() != tc.Expected {
+				t.Fatalf("expected: %q, got: %q", tc.Expected, e.String())
+			}
+			if reparsed := mustParseTagExpr(t, e.String()); reparsed.String() != e.String() {
+				t.Fatalf("%q is parsed back as %q", e.String(), reparsed.String())
+			}
+		})
+	}
+}
+
+func TestTagExprMatch(t *testing.T) {
+	e := mustParseTagExpr(t, "math AND NOT hard OR easy")
+
+	tt := []struct {
+		Tags     []string
+		Expected bool
+	}{
+		{Tags: []string{"math"}, Expected: true},
+		{Tags: []string{"hard", "math"}, Expected: false},
+		{Tags: []string{"easy", "hard"}, Expected: true},
+		{Tags: nil, Expected: false},
+	}
+
+	for _, tc := range tt {
+		if e.Match(tc.Tags) != tc.Expected {
+			t.Fatalf("%v: expected: %v, got: %v", tc.Tags, tc.Expected, !tc.Expected)
+		}
+	}
+}
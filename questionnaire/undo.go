@@ -0,0 +1,378 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrUndoConflict  = errors.New("changed outside of this session, undo and redo history is cleared")
+)
+
+// UndoService is a Service remembering the changes made through it, so they can be undone and redone.
+type UndoService interface {
+	Service
+	// Undo reverts the last change made through the service or the last redone one, the changes made
+	// by a transaction are reverted together. Returns the IDs of the changed questions and error if any,
+	// ErrUndoConflict when a question has been changed by someone else since, all history is dropped then.
+	Undo(ctx context.Context) ([]int, error)
+	// Redo makes again the last undone change, see Undo.
+	Redo(ctx context.Context) ([]int, error)
+}
+
+// NewUndoService creates UndoService making the changes through s, it remembers up to depth changes,
+// nothing is remembered when depth is 0.
+func NewUndoService(s Service, depth int) UndoService {
+	u := &undoService{depth: depth}
+	u.undoRecorder = undoRecorder{Service: s, record: u.push}
+
+	return u
+}
+
+// undoChange is a question of Bank moving from Before to After, nil is a question that is not there:
+// in trash, or not created yet or discarded when Gone is set.
+type undoChange struct {
+	Bank   string
+	ID     int
+	Before *Question
+	After  *Question
+	Gone   bool
+}
+
+type undoService struct {
+	undoRecorder
+	depth int
+
+	mu   sync.Mutex
+	undo [][]undoChange // the last step is undone first
+	redo [][]undoChange // the last step is redone first
+}
+
+func (s *undoService) Undo(ctx context.Context) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.undo) == 0 {
+		return nil, ErrNothingToUndo
+	}
+	step := s.undo[len(s.undo)-1]
+
+	// Revert the changes backward, the step to redo them is made of where the questions are now.
+	redo := make([]undoChange, len(step))
+	err := s.Service.WithTx(ctx, func(tx Service) error {
+		for i := len(step) - 1; i >= 0; i-- {
+			now, err := moveQuestion(WithBank(ctx, step[i].Bank), tx, step[i].ID, step[i].After, step[i].Before,
+				step[i].Gone)
+			if err != nil {
+				return err
+			}
+			redo[i] = undoChange{Bank: step[i].Bank, ID: step[i].ID, Before: now, After: step[i].After, Gone: step[i].Gone}
+		}
+		return nil
+	})
+	if err != nil {
+		s.dropOnConflict(err)
+		return nil, err
+	}
+
+	s.undo = s.undo[:len(s.undo)-1]
+	for i := range step {
+		s.rebase(step[i], step[i].Before, redo[i].Before)
+	}
+	s.redo = append(s.redo, redo)
+
+	return stepIDs(step), nil
+}
+
+func (s *undoService) Redo(ctx context.Context) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.redo) == 0 {
+		return nil, ErrNothingToRedo
+	}
+	step := s.redo[len(s.redo)-1]
+
+	undo := make([]undoChange, len(step))
+	err := s.Service.WithTx(ctx, func(tx Service) error {
+		for i := range step {
+			now, err := moveQuestion(WithBank(ctx, step[i].Bank), tx, step[i].ID, step[i].Before, step[i].After,
+				step[i].Gone)
+			if err != nil {
+				return err
+			}
+			undo[i] = undoChange{Bank: step[i].Bank, ID: step[i].ID, Before: step[i].Before, After: now, Gone: step[i].Gone}
+		}
+		return nil
+	})
+	if err != nil {
+		s.dropOnConflict(err)
+		return nil, err
+	}
+
+	s.redo = s.redo[:len(s.redo)-1]
+	for i := range step {
+		s.rebase(step[i], step[i].After, undo[i].After)
+	}
+	s.undo = append(s.undo, undo)
+
+	return stepIDs(step), nil
+}
+
+// push remembers a step made of new changes, it forgets the undone ones.
+func (s *undoService) push(step []undoChange) {
+	if s.depth <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.undo = append(s.undo, step)
+	if len(s.undo) > s.depth {
+		s.undo = append(s.undo[:0], s.undo[len(s.undo)-s.depth:]...)
+	}
+	s.redo = nil
+}
+
+// rebase makes the steps left expect the question of change at the version now, when undo or redo moved it back
+// to where it was at to under a new version.
+func (s *undoService) rebase(change undoChange, to, now *Question) {
+	if to == nil || now == nil || to.Version == now.Version {
+		return
+	}
+
+	for _, step := range s.undo {
+		for i := range step {
+			if step[i].Bank == change.Bank && step[i].ID == change.ID && step[i].After != nil &&
+				step[i].After.Version == to.Version {
+				step[i].After = now
+			}
+		}
+	}
+	for _, step := range s.redo {
+		for i := range step {
+			if step[i].Bank == change.Bank && step[i].ID == change.ID && step[i].Before != nil &&
+				step[i].Before.Version == to.Version {
+				step[i].Before = now
+			}
+		}
+	}
+}
+
+// dropOnConflict forgets all steps when err is an ErrUndoConflict, they can't be trusted anymore.
+func (s *undoService) dropOnConflict(err error) {
+	if errors.Is(err, ErrUndoConflict) {
+		s.undo, s.redo = nil, nil
+	}
+}
+
+func stepIDs(step []undoChange) []int {
+	ids := make([]int, 0, len(step))
+	seen := make(map[int]bool, len(step))
+	for _, change := range step {
+		if !seen[change.ID] {
+			ids = append(ids, change.ID)
+			seen[change.ID] = true
+		}
+	}
+
+	return ids
+}
+
+// moveQuestion moves question id from where it's expected to be to given question, returns the question
+// where it's now. A nil from or to is a question in trash, or discarded when gone is set.
+// ErrUndoConflict is returned when question id is not where it's expected.
+func moveQuestion(ctx context.Context, tx Service, id int, from, to *Question, gone bool) (*Question, error) {
+	current, err := tx.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrQuestionNotFound) {
+		return nil, err
+	}
+	if (current == nil) != (from == nil) || (current != nil && current.Version != from.Version) {
+		return nil, fmt.Errorf("question %d %w", id, ErrUndoConflict)
+	}
+
+	switch {
+	case to == nil && gone:
+		return nil, tx.Discard(ctx, id)
+	case to == nil:
+		return nil, tx.Delete(ctx, id)
+	case from == nil && gone:
+		question := &Question{ID: id, Question: to.Question, Answer: to.Answer, Tags: to.Tags, Category: to.Category,
+			Matching: to.Matching}
+		err := tx.Create(ctx, question)
+		if errors.Is(err, ErrQuestionInTrash) || errors.Is(err, ErrQuestionIsAlreadyExist) {
+			return nil, fmt.Errorf("question %d %w", id, ErrUndoConflict)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return tx.GetByID(ctx, id)
+	case from == nil:
+		err := tx.Restore(ctx, id)
+		if errors.Is(err, ErrQuestionNotFound) {
+			// Purged from trash.
+			return nil, fmt.Errorf("question %d %w", id, ErrUndoConflict)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return tx.GetByID(ctx, id)
+	default:
+		question := &Question{ID: id, Question: to.Question, Answer: to.Answer, Tags: to.Tags, Category: to.Category,
+			Matching: to.Matching}
+		err := tx.UpdateIfVersion(ctx, question, from.Version)
+		if errors.Is(err, ErrVersionConflict) {
+			return nil, fmt.Errorf("question %d %w", id, ErrUndoConflict)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return question, nil
+	}
+}
+
+// undoRecorder passes every change made through it to record, a transaction is recorded as one step
+// when it's committed.
+type undoRecorder struct {
+	Service
+	record func(step []undoChange)
+}
+
+func (r *undoRecorder) Create(ctx context.Context, question *Question) error {
+	if err := r.Service.Create(ctx, question); err != nil {
+		return err
+	}
+	r.record([]undoChange{{Bank: BankFromContext(ctx), ID: question.ID, After: copyQuestion(question), Gone: true}})
+
+	return nil
+}
+
+func (r *undoRecorder) Update(ctx context.Context, question *Question) error {
+	_, err := r.change(ctx, question.ID, func(tx Service) (*Question, error) {
+		return question, tx.Update(ctx, question)
+	})
+
+	return err
+}
+
+func (r *undoRecorder) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	_, err := r.change(ctx, question.ID, func(tx Service) (*Question, error) {
+		return question, tx.UpdateIfVersion(ctx, question, version)
+	})
+
+	return err
+}
+
+func (r *undoRecorder) Delete(ctx context.Context, id int) error {
+	_, err := r.change(ctx, id, func(tx Service) (*Question, error) {
+		return nil, tx.Delete(ctx, id)
+	})
+
+	return err
+}
+
+func (r *undoRecorder) Discard(ctx context.Context, id int) error {
+	var before *Question
+	err := r.Service.WithTx(ctx, func(tx Service) (err error) {
+		if before, err = tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return tx.Discard(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	r.record([]undoChange{{Bank: BankFromContext(ctx), ID: id, Before: before, Gone: true}})
+
+	return nil
+}
+
+func (r *undoRecorder) Restore(ctx context.Context, id int) error {
+	var after *Question
+	err := r.Service.WithTx(ctx, func(tx Service) error {
+		if err := tx.Restore(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		after, err = tx.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	r.record([]undoChange{{Bank: BankFromContext(ctx), ID: id, After: after}})
+
+	return nil
+}
+
+func (r *undoRecorder) Revert(ctx context.Context, id, number int) (*Question, error) {
+	return r.change(ctx, id, func(tx Service) (*Question, error) { return tx.Revert(ctx, id, number) })
+}
+
+func (r *undoRecorder) Tag(ctx context.Context, id int, tags []string) (*Question, error) {
+	return r.change(ctx, id, func(tx Service) (*Question, error) { return tx.Tag(ctx, id, tags) })
+}
+
+func (r *undoRecorder) Untag(ctx context.Context, id int, tags []string) (*Question, error) {
+	return r.change(ctx, id, func(tx Service) (*Question, error) { return tx.Untag(ctx, id, tags) })
+}
+
+// change reads question id and changes it with fn in a single transaction, so that no other change comes in
+// between, then records the change from the question read to a copy of the one fn returns, nil when it's moved to
+// trash.
+func (r *undoRecorder) change(ctx context.Context, id int, fn func(tx Service) (*Question, error)) (*Question, error) {
+	var before, after *Question
+	err := r.Service.WithTx(ctx, func(tx Service) (err error) {
+		if before, err = tx.GetByID(ctx, id); err != nil {
+			return err
+		}
+		after, err = fn(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	change := undoChange{Bank: BankFromContext(ctx), ID: id, Before: before}
+	if after != nil {
+		change.After = copyQuestion(after)
+	}
+	r.record([]undoChange{change})
+
+	return after, nil
+}
+
+func (r *undoRecorder) CopyQuestion(ctx context.Context, id int, bank string) (*Question, error) {
+	question, err := r.Service.CopyQuestion(ctx, id, bank)
+	if err != nil {
+		return nil, err
+	}
+	r.record([]undoChange{{Bank: bank, ID: id, After: copyQuestion(question), Gone: true}})
+
+	return question, nil
+}
+
+func (r *undoRecorder) WithTx(ctx context.Context, fn func(tx Service) error) error {
+	var step []undoChange
+	err := r.Service.WithTx(ctx, func(tx Service) error {
+		step = step[:0]
+		return fn(&undoRecorder{Service: tx, record: func(changes []undoChange) { step = append(step, changes...) }})
+	})
+	if err != nil {
+		return err
+	}
+	if len(step) > 0 {
+		r.record(step)
+	}
+
+	return nil
+}
+
+func copyQuestion(question *Question) *Question {
+	c := question.clone()
+	return &c
+}
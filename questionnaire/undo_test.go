@@ -0,0 +1,384 @@
+package questionnaire_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/quiz_master/questionnaire"
+)
+
+func TestUndoService(t *testing.T) {
+	ctx := context.Background()
+
+	// answerOf returns the answer of question id, "-" when it's not there.
+	answerOf := func(qs questionnaire.Service, id int) string {
+		question, err := qs.GetByID(ctx, id)
+		if err != nil {
+			return "-"
+		}
+		return question.Answer
+	}
+
+	var (
+		r       = questionnaire.NewRepository()
+		session = questionnaire.NewUndoService(questionnaire.NewService(r), 3)
+		other   = questionnaire.NewService(r) // another author sharing the store
+	)
+
+	undo := func() error { _, err := session.Undo(ctx); return err }
+	redo := func() error { _, err := session.Redo(ctx); return err }
+
+	tt := []struct {
+		Name            string
+		Do              func() error
+		ExpectedAnswers []string // answers of question 1 and 2
+		ExpectedErr     error
+	}{
+		{
+			Name:            "nothing to undo",
+			Do:              undo,
+			ExpectedAnswers: []string{"-", "-"},
+			ExpectedErr:     questionnaire.ErrNothingToUndo,
+		},
+		{
+			Name: "create question 1, update it and create question 2",
+			Do: func() error {
+				if err := session.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "3"}); err != nil {
+					return err
+				}
+				if err := session.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+					return err
+				}
+				return session.Create(ctx, &questionnaire.Question{ID: 2, Question: "2 + 2?", Answer: "4"})
+			},
+			ExpectedAnswers: []string{"2", "4"},
+		},
+		{
+			Name:            "undo create question 2",
+			Do:              undo,
+			ExpectedAnswers: []string{"2", "-"},
+		},
+		{
+			Name:            "undo update question 1",
+			Do:              undo,
+			ExpectedAnswers: []string{"3", "-"},
+		},
+		{
+			Name:            "redo update question 1",
+			Do:              redo,
+			ExpectedAnswers: []string{"2", "-"},
+		},
+		{
+			Name:            "delete question 1, forgets the undone create of question 2",
+			Do:              func() error { return session.Delete(ctx, 1) },
+			ExpectedAnswers: []string{"-", "-"},
+		},
+		{
+			Name:            "nothing to redo",
+			Do:              redo,
+			ExpectedAnswers: []string{"-", "-"},
+			ExpectedErr:     questionnaire.ErrNothingToRedo,
+		},
+		{
+			Name:            "undo delete question 1",
+			Do:              undo,
+			ExpectedAnswers: []string{"2", "-"},
+		},
+		{
+			Name: "transaction creating question 2 again and updating question 1",
+			Do: func() error {
+				return session.WithTx(ctx, func(tx questionnaire.Service) error {
+					if err := tx.Create(ctx, &questionnaire.Question{ID: 2, Question: "2 + 2?", Answer: "4"}); err != nil {
+						return err
+					}
+					return tx.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "two"})
+				})
+			},
+			ExpectedAnswers: []string{"two", "4"},
+		},
+		{
+			Name:            "undo transaction at once",
+			Do:              undo,
+			ExpectedAnswers: []string{"2", "-"},
+		},
+		{
+			Name:            "redo transaction at once",
+			Do:              redo,
+			ExpectedAnswers: []string{"two", "4"},
+		},
+		{
+			Name: "question 2 changed by someone else, undo of question 1 is kept",
+			Do: func() error {
+				return other.Update(ctx, &questionnaire.Question{ID: 2, Question: "2 + 2?", Answer: "four"})
+			},
+			ExpectedAnswers: []string{"two", "four"},
+		},
+		{
+			Name: "undo transaction changing question 2, failed conflict",
+			Do:   undo,
+			// Nothing is undone, not even question 1.
+			ExpectedAnswers: []string{"two", "four"},
+			ExpectedErr:     questionnaire.ErrUndoConflict,
+		},
+		{
+			Name:            "nothing to undo after conflict",
+			Do:              undo,
+			ExpectedAnswers: []string{"two", "four"},
+			ExpectedErr:     questionnaire.ErrNothingToUndo,
+		},
+	}
+
+	// The order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Do(); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			answers := []string{answerOf(session, 1), answerOf(session, 2)}
+			if diff := cmp.Diff(tc.ExpectedAnswers, answers); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestUndoServiceCreate(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		session = questionnaire.NewUndoService(questionnaire.NewService(questionnaire.NewRepository()), 10)
+	)
+
+	steps := []struct {
+		Name           string
+		Do             func() error
+		ExpectedAnswer string // "-" when question 1 is not there
+	}{
+		{
+			Name: "create question 1",
+			Do: func() error {
+				return session.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "3"})
+			},
+			ExpectedAnswer: "3",
+		},
+		{
+			Name: "update question 1",
+			Do: func() error {
+				return session.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"})
+			},
+			ExpectedAnswer: "2",
+		},
+		{
+			Name:           "undo update",
+			Do:             func() error { _, err := session.Undo(ctx); return err },
+			ExpectedAnswer: "3",
+		},
+		{
+			Name:           "undo create, at the version undo made",
+			Do:             func() error { _, err := session.Undo(ctx); return err },
+			ExpectedAnswer: "-",
+		},
+		{
+			Name:           "redo create",
+			Do:             func() error { _, err := session.Redo(ctx); return err },
+			ExpectedAnswer: "3",
+		},
+		{
+			Name:           "redo update, at the version redo made",
+			Do:             func() error { _, err := session.Redo(ctx); return err },
+			ExpectedAnswer: "2",
+		},
+		{
+			Name: "undo update and create",
+			Do: func() error {
+				if _, err := session.Undo(ctx); err != nil {
+					return err
+				}
+				_, err := session.Undo(ctx)
+				return err
+			},
+			ExpectedAnswer: "-",
+		},
+		{
+			Name: "create question 1 again",
+			Do: func() error {
+				return session.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "two"})
+			},
+			ExpectedAnswer: "two",
+		},
+	}
+
+	// The order of the steps is important, can't be parallelized.
+	for _, step := range steps {
+		if err := step.Do(); err != nil {
+			t.Fatalf("%s: %v", step.Name, err)
+		}
+
+		answer := "-"
+		if question, err := session.GetByID(ctx, 1); err == nil {
+			answer = question.Answer
+		}
+		if answer != step.ExpectedAnswer {
+			t.Fatalf("%s: expected answer: %q, got: %q", step.Name, step.ExpectedAnswer, answer)
+		}
+
+		// An undone create leaves nothing in trash.
+		trash, err := session.Trash(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash) != 0 {
+			t.Fatalf("%s: expected empty trash, got: %v", step.Name, trash)
+		}
+	}
+}
+
+func TestUndoServiceChangedByAnotherProcess(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.json")
+	)
+
+	// open opens the JSON store as a new process would.
+	open := func() questionnaire.Service {
+		r, err := questionnaire.NewFileRepository(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return questionnaire.NewService(r)
+	}
+
+	session := questionnaire.NewUndoService(open(), 10)
+	if err := session.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := open().Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "two"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := session.Undo(ctx); !errors.Is(err, questionnaire.ErrUndoConflict) {
+		t.Fatalf("expected error: %v, got: %v", questionnaire.ErrUndoConflict, err)
+	}
+
+	question, err := open().GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if question.Answer != "two" {
+		t.Fatalf("expected the change of the other process kept, got answer: %q", question.Answer)
+	}
+}
+
+// outsideTxService fails the test when a question is read outside of a transaction.
+type outsideTxService struct {
+	questionnaire.Service
+	t *testing.T
+}
+
+func (s *outsideTxService) GetByID(ctx context.Context, id int) (*questionnaire.Question, error) {
+	s.t.Errorf("question %d read outside of a transaction", id)
+	return s.Service.GetByID(ctx, id)
+}
+
+func TestUndoServiceReadsInTx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		qs  = questionnaire.NewService(questionnaire.NewRepository())
+	)
+	if err := qs.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The question a change is undone to is read in the transaction making the change, so no other change
+	// comes in between and is silently undone with it.
+	session := questionnaire.NewUndoService(&outsideTxService{Service: qs, t: t}, 10)
+	changes := []func() error{
+		func() error {
+			return session.Update(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"})
+		},
+		func() error { _, err := session.Tag(ctx, 1, []string{"math"}); return err },
+		func() error { return session.Delete(ctx, 1) },
+		func() error { return session.Restore(ctx, 1) },
+	}
+	for _, change := range changes {
+		if err := change(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range changes {
+		if _, err := session.Undo(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	question, err := qs.GetByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if question.Answer != "3" || len(question.Tags) != 0 {
+		t.Fatalf("expected the question as created, got: %+v", question)
+	}
+}
+
+func TestUndoServiceDepth(t *testing.T) {
+	ctx := context.Background()
+	session := questionnaire.NewUndoService(questionnaire.NewService(questionnaire.NewRepository()), 2)
+
+	for id := 1; id <= 3; id++ {
+		if err := session.Create(ctx, &questionnaire.Question{ID: id, Question: "?", Answer: "!"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range [][]int{{3}, {2}} {
+		ids, err := session.Undo(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expected, ids); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+	if _, err := session.Undo(ctx); !errors.Is(err, questionnaire.ErrNothingToUndo) {
+		t.Fatalf("expected error: %v, got: %v", questionnaire.ErrNothingToUndo, err)
+	}
+}
+
+func TestUndoServiceBanks(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		scienceCtx = questionnaire.WithBank(ctx, "science")
+		session    = questionnaire.NewUndoService(questionnaire.NewService(questionnaire.NewRepository()), 10)
+	)
+
+	if err := session.CreateBank(ctx, &questionnaire.Bank{Name: "science"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Create(ctx, &questionnaire.Question{ID: 1, Question: "1 + 1?", Answer: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.CopyQuestion(ctx, 1, "science"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo is made in the bank of the change, not in the bank the undo is called with.
+	if _, err := session.Undo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.GetByID(scienceCtx, 1); !errors.Is(err, questionnaire.ErrQuestionNotFound) {
+		t.Fatalf("expected error: %v, got: %v", questionnaire.ErrQuestionNotFound, err)
+	}
+	if _, err := session.GetByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := session.Redo(scienceCtx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.GetByID(scienceCtx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
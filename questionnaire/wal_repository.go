@@ -0,0 +1,597 @@
+package questionnaire
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrWALCorrupted = errors.New("write-ahead log is corrupted")
+	ErrWALLocked    = errors.New("write-ahead log is opened by another process")
+
+	errFileLocked = errors.New("file is locked")
+)
+
+const (
+	walOpCreate   = "create"
+	walOpUpdate   = "update"
+	walOpDelete   = "delete"
+	walOpTrash    = "trash"
+	walOpRestore  = "restore"
+	walOpPurge    = "purge"
+	walOpRevision = "revision"
+	walOpTx       = "tx"
+	walOpBank     = "create_bank"
+
+	// walHeaderSize is the size of the record header: payload length (uint32) followed by
+	// the CRC-32C checksum of the payload (uint32), both little endian.
+	walHeaderSize = 8
+	// walMaxRecordSize guards replay against allocating a huge buffer from a torn header.
+	walMaxRecordSize = 64 << 20
+)
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walRecord is a single change appended to the log.
+type walRecord struct {
+	LSN uint64 `json:"lsn"`
+	Op  string `json:"op"`
+	// Bank is the bank the change is made in, empty for DefaultBank. It's the bank created by create_bank.
+	Bank     string    `json:"bank,omitempty"`
+	Question *Question `json:"question,omitempty"`
+	ID       int       `json:"id,omitempty"`
+	// IfVersion makes the update conditional, see Repository.UpdateIfVersion.
+	IfVersion *int `json:"if_version,omitempty"`
+	// At is the deletion time of trash, the time purge deletes up to and the creation time of a bank.
+	At       *time.Time `json:"at,omitempty"`
+	Revision *Revision  `json:"revision,omitempty"`
+	// Records are the changes of a transaction, applied all or nothing.
+	Records []walRecord `json:"records,omitempty"`
+}
+
+// walSnapshot is the state of the question bank up to and including the record LSN.
+type walSnapshot struct {
+	LSN       uint64     `json:"lsn"`
+	Questions []Question `json:"questions"`
+	Revisions []Revision `json:"revisions,omitempty"`
+	// Banks are the banks other than DefaultBank.
+	Banks []bankState `json:"banks,omitempty"`
+}
+
+// NewWALRepository creates Repository that appends every change to the log file in path and
+// fsyncs it before returning. The state is rebuilt on creation from the snapshot in path + ".snapshot"
+// and the log records written after it, a torn record at the end of the log is cut off.
+// The log is locked until the repository is closed, ErrWALLocked is returned when it's already open.
+// The returned Repository also implements Compactor and io.Closer.
+func NewWALRepository(path string) (Repository, error) {
+	r := &walRepository{
+		path:         path,
+		snapshotPath: path + ".snapshot",
+		inmem:        newInmemRepository(make([]Question, 0)),
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q: %w", path, err)
+	}
+
+	// Two writers would append records with the same LSNs, and replay would drop the ones of one of them.
+	if err := lockFile(f, false); err != nil {
+		f.Close()
+		if errors.Is(err, errFileLocked) {
+			return nil, fmt.Errorf("could not lock %q: %w", path, ErrWALLocked)
+		}
+		return nil, err
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := r.replay(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.f = f
+
+	return r, nil
+}
+
+// walRepository serves reads from an in-memory copy of the questions, every change is applied
+// to the copy and appended to the log before it is acknowledged.
+type walRepository struct {
+	mu           sync.Mutex // serializes writers so records are appended in the order they are applied
+	path         string
+	snapshotPath string
+	f            *os.File
+	size         int64  // size of the log, in bytes, after the last complete record
+	lsn          uint64 // LSN of the last applied record
+	inmem        *inmemRepository
+}
+
+func (r *walRepository) GetBanks(ctx context.Context) ([]Bank, error) {
+	return r.inmem.GetBanks(ctx)
+}
+
+func (r *walRepository) CreateBank(ctx context.Context, bank *Bank) error {
+	return r.mutate(walRecord{Op: walOpBank, Bank: bank.Name, At: &bank.CreatedAt})
+}
+
+func (r *walRepository) GetByID(ctx context.Context, id int) (*Question, error) {
+	return r.inmem.GetByID(ctx, id)
+}
+
+func (r *walRepository) GetAll(ctx context.Context) ([]Question, error) {
+	return r.inmem.GetAll(ctx)
+}
+
+func (r *walRepository) Find(ctx context.Context, query Query) (*Page, error) {
+	return r.inmem.Find(ctx, query)
+}
+
+func (r *walRepository) GetMaxID(ctx context.Context) (int, error) {
+	return r.inmem.GetMaxID(ctx)
+}
+
+func (r *walRepository) GetFirstFreeID(ctx context.Context) (int, error) {
+	return r.inmem.GetFirstFreeID(ctx)
+}
+
+func (r *walRepository) Create(ctx context.Context, question *Question) error {
+	return r.mutate(walRecord{Op: walOpCreate, Bank: walBank(ctx), Question: question})
+}
+
+func (r *walRepository) Update(ctx context.Context, question *Question) error {
+	return r.mutate(walRecord{Op: walOpUpdate, Bank: walBank(ctx), Question: question})
+}
+
+func (r *walRepository) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	return r.mutate(walRecord{Op: walOpUpdate, Bank: walBank(ctx), Question: question, IfVersion: &version})
+}
+
+func (r *walRepository) Delete(ctx context.Context, id int) error {
+	return r.mutate(walRecord{Op: walOpDelete, Bank: walBank(ctx), ID: id})
+}
+
+func (r *walRepository) Trash(ctx context.Context, id int, at time.Time) error {
+	return r.mutate(walRecord{Op: walOpTrash, Bank: walBank(ctx), ID: id, At: &at})
+}
+
+func (r *walRepository) Restore(ctx context.Context, id int) error {
+	return r.mutate(walRecord{Op: walOpRestore, Bank: walBank(ctx), ID: id})
+}
+
+func (r *walRepository) AddRevision(ctx context.Context, revision *Revision) error {
+	return r.mutate(walRecord{Op: walOpRevision, Bank: walBank(ctx), Revision: revision})
+}
+
+func (r *walRepository) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	return r.inmem.GetRevisions(ctx, id)
+}
+
+func (r *walRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Purge is replayed to the same result, only the count has to be taken here.
+	var n int
+	undo, err := r.inmem.applyTx(func(tx Repository) (err error) {
+		n, err = tx.Purge(ctx, before)
+		return err
+	})
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	record := walRecord{LSN: r.lsn + 1, Op: walOpPurge, Bank: walBank(ctx), At: &before}
+	if err := r.append(record); err != nil {
+		undo()
+		return 0, err
+	}
+	r.lsn = record.LSN
+
+	return n, nil
+}
+
+// WithTx runs fn in a transaction of the in-memory copy, the changes are appended to the log
+// as a single record once fn returns nil so a crash never leaves half of a transaction behind.
+func (r *walRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var wtx *walTx
+	undo, err := r.inmem.applyTx(func(tx Repository) error {
+		wtx = &walTx{Repository: tx}
+		return fn(wtx)
+	})
+	if err != nil {
+		return err
+	}
+	if len(wtx.records) == 0 {
+		return nil
+	}
+
+	record := walRecord{LSN: r.lsn + 1, Op: walOpTx, Records: wtx.records}
+	if err := r.append(record); err != nil {
+		undo()
+		return err
+	}
+	r.lsn = record.LSN
+
+	return nil
+}
+
+// Compact writes the current state into the snapshot file and truncates the log.
+func (r *walRepository) Compact(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.inmem.snapshot()
+	b, err := json.Marshal(walSnapshot{LSN: r.lsn, Questions: state.Questions, Revisions: state.Revisions, Banks: state.Banks})
+	if err != nil {
+		return fmt.Errorf("could not encode snapshot: %w", err)
+	}
+
+	// A crash after the snapshot is written but before the log is truncated is harmless,
+	// replay skips the records already covered by the snapshot LSN.
+	if err := WriteFileAtomic(r.snapshotPath, b); err != nil {
+		return err
+	}
+
+	if err := r.f.Truncate(0); err != nil {
+		return fmt.Errorf("could not truncate %q: %w", r.path, err)
+	}
+	if err := r.f.Sync(); err != nil {
+		return fmt.Errorf("could not sync %q: %w", r.path, err)
+	}
+	r.size = 0
+
+	return nil
+}
+
+// Close closes the log file.
+func (r *walRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Close()
+}
+
+// mutate applies record to the in-memory copy and appends it to the log, the change of record
+// is undone when it could not be made durable.
+func (r *walRepository) mutate(record walRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	undo, err := r.inmem.applyTx(func(tx Repository) error {
+		return applyWALRecord(context.Background(), tx, record)
+	})
+	if err != nil {
+		return err
+	}
+
+	record.LSN = r.lsn + 1
+	if err := r.append(record); err != nil {
+		undo()
+		return err
+	}
+	r.lsn = record.LSN
+
+	return nil
+}
+
+func (r *walRepository) apply(record walRecord) error {
+	return applyWALRecord(context.Background(), r.inmem, record)
+}
+
+func applyWALRecord(ctx context.Context, repo Repository, record walRecord) error {
+	if record.Op != walOpTx {
+		ctx = WithBank(ctx, record.Bank)
+	}
+
+	switch record.Op {
+	case walOpBank:
+		if record.At == nil {
+			return fmt.Errorf("bank without creation time: %w", ErrWALCorrupted)
+		}
+		return repo.CreateBank(ctx, &Bank{Name: record.Bank, CreatedAt: *record.At})
+	case walOpCreate:
+		return repo.Create(ctx, record.Question)
+	case walOpUpdate:
+		if record.IfVersion != nil {
+			return repo.UpdateIfVersion(ctx, record.Question, *record.IfVersion)
+		}
+		return repo.Update(ctx, record.Question)
+	case walOpDelete:
+		return repo.Delete(ctx, record.ID)
+	case walOpTrash:
+		if record.At == nil {
+			return fmt.Errorf("trash without time: %w", ErrWALCorrupted)
+		}
+		return repo.Trash(ctx, record.ID, *record.At)
+	case walOpRestore:
+		return repo.Restore(ctx, record.ID)
+	case walOpPurge:
+		if record.At == nil {
+			return fmt.Errorf("purge without time: %w", ErrWALCorrupted)
+		}
+		_, err := repo.Purge(ctx, *record.At)
+		return err
+	case walOpRevision:
+		if record.Revision == nil {
+			return fmt.Errorf("revision without content: %w", ErrWALCorrupted)
+		}
+		return repo.AddRevision(ctx, record.Revision)
+	case walOpTx:
+		return repo.WithTx(ctx, func(tx Repository) error {
+			for _, record := range record.Records {
+				if err := applyWALRecord(ctx, tx, record); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		return fmt.Errorf("unknown operation %q: %w", record.Op, ErrWALCorrupted)
+	}
+}
+
+// walBank returns the bank of ctx as recorded by walRecord.
+func walBank(ctx context.Context) string {
+	if bank := BankFromContext(ctx); bank != DefaultBank {
+		return bank
+	}
+	return ""
+}
+
+// walTx records the changes made through the in-memory transaction it wraps.
+type walTx struct {
+	Repository
+	mu      sync.Mutex
+	records []walRecord
+}
+
+func (t *walTx) CreateBank(ctx context.Context, bank *Bank) error {
+	if err := t.Repository.CreateBank(ctx, bank); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpBank, Bank: bank.Name, At: &bank.CreatedAt})
+
+	return nil
+}
+
+func (t *walTx) Create(ctx context.Context, question *Question) error {
+	if err := t.Repository.Create(ctx, question); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpCreate, Bank: walBank(ctx), Question: question})
+
+	return nil
+}
+
+func (t *walTx) Update(ctx context.Context, question *Question) error {
+	if err := t.Repository.Update(ctx, question); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpUpdate, Bank: walBank(ctx), Question: question})
+
+	return nil
+}
+
+func (t *walTx) UpdateIfVersion(ctx context.Context, question *Question, version int) error {
+	if err := t.Repository.UpdateIfVersion(ctx, question, version); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpUpdate, Bank: walBank(ctx), Question: question, IfVersion: &version})
+
+	return nil
+}
+
+func (t *walTx) Delete(ctx context.Context, id int) error {
+	if err := t.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpDelete, Bank: walBank(ctx), ID: id})
+
+	return nil
+}
+
+func (t *walTx) Trash(ctx context.Context, id int, at time.Time) error {
+	if err := t.Repository.Trash(ctx, id, at); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpTrash, Bank: walBank(ctx), ID: id, At: &at})
+
+	return nil
+}
+
+func (t *walTx) Restore(ctx context.Context, id int) error {
+	if err := t.Repository.Restore(ctx, id); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpRestore, Bank: walBank(ctx), ID: id})
+
+	return nil
+}
+
+func (t *walTx) Purge(ctx context.Context, before time.Time) (int, error) {
+	n, err := t.Repository.Purge(ctx, before)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	t.record(walRecord{Op: walOpPurge, Bank: walBank(ctx), At: &before})
+
+	return n, nil
+}
+
+func (t *walTx) AddRevision(ctx context.Context, revision *Revision) error {
+	if err := t.Repository.AddRevision(ctx, revision); err != nil {
+		return err
+	}
+	t.record(walRecord{Op: walOpRevision, Bank: walBank(ctx), Revision: revision})
+
+	return nil
+}
+
+// WithTx keeps the records of a nested transaction only when it commits.
+func (t *walTx) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	var nested *walTx
+	err := t.Repository.WithTx(ctx, func(tx Repository) error {
+		nested = &walTx{Repository: tx}
+		return fn(nested)
+	})
+	if err != nil {
+		return err
+	}
+	if len(nested.records) > 0 {
+		t.record(walRecord{Op: walOpTx, Records: nested.records})
+	}
+
+	return nil
+}
+
+// record keeps a copy of record, the question is copied as the caller may reuse it.
+func (t *walTx) record(record walRecord) {
+	if record.Question != nil {
+		question := record.Question.clone()
+		record.Question = &question
+	}
+	if record.Revision != nil {
+		revision := record.Revision.clone()
+		record.Revision = &revision
+	}
+
+	t.mu.Lock()
+	t.records = append(t.records, record)
+	t.mu.Unlock()
+}
+
+func (r *walRepository) append(record walRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not encode record: %w", err)
+	}
+
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, walCRCTable))
+	copy(buf[walHeaderSize:], payload)
+
+	if _, err := r.f.Write(buf); err != nil {
+		// Drop whatever part of the record reached the file so the next append starts clean.
+		r.f.Truncate(r.size)
+		return fmt.Errorf("could not append to %q: %w", r.path, err)
+	}
+	if err := r.f.Sync(); err != nil {
+		r.f.Truncate(r.size)
+		return fmt.Errorf("could not sync %q: %w", r.path, err)
+	}
+	r.size += int64(len(buf))
+
+	return nil
+}
+
+func (r *walRepository) loadSnapshot() error {
+	b, err := os.ReadFile(r.snapshotPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %q: %w", r.snapshotPath, err)
+	}
+
+	var snapshot walSnapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return fmt.Errorf("could not decode %q: %w", r.snapshotPath, err)
+	}
+
+	upgradeQuestions(snapshot.Questions)
+	r.inmem.restore(inmemState{Questions: snapshot.Questions, Revisions: snapshot.Revisions, Banks: snapshot.Banks})
+	r.lsn = snapshot.LSN
+
+	return nil
+}
+
+// replay applies every complete record of the log in f. A record that is cut short or fails its
+// checksum is only tolerated at the end of the log, where it is the result of a crash in the middle
+// of an append, and it is truncated away.
+func (r *walRepository) replay(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat %q: %w", r.path, err)
+	}
+	size := fi.Size()
+
+	var (
+		br     = bufio.NewReader(f)
+		header = make([]byte, walHeaderSize)
+		offset int64
+	)
+
+	for offset < size {
+		record, n, err := readWALRecord(br, header)
+		if err != nil {
+			if !errors.Is(err, errWALTornRecord) || offset+n < size {
+				return fmt.Errorf("record at offset %d of %q: %w", offset, r.path, ErrWALCorrupted)
+			}
+			if err := f.Truncate(offset); err != nil {
+				return fmt.Errorf("could not truncate torn record of %q: %w", r.path, err)
+			}
+			if err := f.Sync(); err != nil {
+				return fmt.Errorf("could not sync %q: %w", r.path, err)
+			}
+			break
+		}
+
+		if record.LSN > r.lsn {
+			if err := r.apply(record); err != nil {
+				return fmt.Errorf("could not replay record %d of %q: %v: %w", record.LSN, r.path, err, ErrWALCorrupted)
+			}
+			r.lsn = record.LSN
+		}
+		offset += n
+	}
+	r.size = offset
+
+	return nil
+}
+
+var errWALTornRecord = errors.New("torn record")
+
+// readWALRecord reads a single record from br, n is the extent of the record in bytes as declared
+// by its header. errWALTornRecord is returned when the record is incomplete or does not match its checksum.
+func readWALRecord(br *bufio.Reader, header []byte) (record walRecord, n int64, err error) {
+	if _, err := io.ReadFull(br, header); err != nil {
+		return record, walHeaderSize, errWALTornRecord
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	n = walHeaderSize + int64(length)
+	if length > walMaxRecordSize {
+		return record, n, errWALTornRecord
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return record, n, errWALTornRecord
+	}
+	if crc32.Checksum(payload, walCRCTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return record, n, errWALTornRecord
+	}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return record, n, errWALTornRecord
+	}
+
+	return record, n, nil
+}
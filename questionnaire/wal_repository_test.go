@@ -0,0 +1,375 @@
+package questionnaire
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// openWAL opens the WAL repository in path and registers its closing to t.
+func openWAL(t *testing.T, path string) Repository {
+	t.Helper()
+
+	r, err := NewWALRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.(io.Closer).Close() })
+
+	return r
+}
+
+func TestWALRepositoryReplay(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+		question3 = Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
+
+		updatedQuestion1 = Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7", Version: 2}
+	)
+
+	tt := []struct {
+		Name              string
+		Do                func(ctx context.Context, r Repository) error
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:              "create question 1",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 2",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question2) },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "create question 1, failed duplicate",
+			Do:                func(ctx context.Context, r Repository) error { return r.Create(ctx, &question1) },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       ErrQuestionIsAlreadyExist,
+		},
+		{
+			Name:              "update question 1",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &updatedQuestion1) },
+			ExpectedQuestions: []Question{updatedQuestion1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "update question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Update(ctx, &question3) },
+			ExpectedQuestions: []Question{updatedQuestion1, question2},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+		{
+			Name:              "compact",
+			Do:                func(ctx context.Context, r Repository) error { return r.(Compactor).Compact(ctx) },
+			ExpectedQuestions: []Question{updatedQuestion1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "delete question 2 after compaction",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 2) },
+			ExpectedQuestions: []Question{updatedQuestion1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "delete question 3, failed not found",
+			Do:                func(ctx context.Context, r Repository) error { return r.Delete(ctx, 3) },
+			ExpectedQuestions: []Question{updatedQuestion1},
+			ExpectedErr:       ErrQuestionNotFound,
+		},
+	}
+
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.wal")
+	)
+
+	// Every case replays the log, the order in table test is important, can't be parallelized.
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			r := openWAL(t, path)
+			if err := tc.Do(ctx, r); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			r.(io.Closer).Close()
+
+			questions, err := openWAL(t, path).GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWALRepositoryCompact(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "questions.wal")
+		r    = openWAL(t, path)
+	)
+
+	for i := 1; i <= 10; i++ {
+		if err := r.Create(ctx, &Question{ID: i, Question: "1 + 1?", Answer: "2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.(Compactor).Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != 0 {
+		t.Fatalf("expected log to be truncated, got: %d bytes", fi.Size())
+	}
+
+	// Simulate a crash between writing the snapshot and truncating the log: the records already
+	// covered by the snapshot must not be applied twice.
+	r.(io.Closer).Close()
+	stalePath := filepath.Join(t.TempDir(), "stale.wal")
+	stale := openWAL(t, stalePath)
+	for i := 1; i <= 10; i++ {
+		if err := stale.Create(ctx, &Question{ID: i, Question: "1 + 1?", Answer: "2"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := os.ReadFile(stalePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := openWAL(t, path).GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 10 {
+		t.Fatalf("expected 10 questions, got: %d", len(questions))
+	}
+}
+
+func TestWALRepositoryRecovery(t *testing.T) {
+	var (
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+	)
+
+	tt := []struct {
+		Name              string
+		Damage            func(log []byte) []byte
+		ExpectedQuestions []Question
+		ExpectedErr       error
+	}{
+		{
+			Name:              "intact log",
+			Damage:            func(log []byte) []byte { return log },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "torn header of last record",
+			Damage:            func(log []byte) []byte { return append(log, 0x2a, 0x00) },
+			ExpectedQuestions: []Question{question1, question2},
+			ExpectedErr:       nil,
+		},
+		{
+			Name:              "torn payload of last record",
+			Damage:            func(log []byte) []byte { return log[:len(log)-5] },
+			ExpectedQuestions: []Question{question1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name: "checksum mismatch of last record",
+			Damage: func(log []byte) []byte {
+				log[len(log)-2] ^= 0xff
+				return log
+			},
+			ExpectedQuestions: []Question{question1},
+			ExpectedErr:       nil,
+		},
+		{
+			Name: "checksum mismatch of first record",
+			Damage: func(log []byte) []byte {
+				log[walHeaderSize+2] ^= 0xff
+				return log
+			},
+			ExpectedQuestions: nil,
+			ExpectedErr:       ErrWALCorrupted,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "questions.wal")
+
+			r := openWAL(t, path)
+			if err := r.Create(ctx, &question1); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Create(ctx, &question2); err != nil {
+				t.Fatal(err)
+			}
+			r.(io.Closer).Close()
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tc.Damage(b), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err = NewWALRepository(path)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatal(err)
+			}
+			if err != nil {
+				return
+			}
+			defer r.(io.Closer).Close()
+
+			questions, err := r.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.ExpectedQuestions, questions); diff != "" {
+				t.Fatal(diff)
+			}
+
+			// The torn tail is gone, new records must be readable after the recovered ones.
+			question3 := Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper", Version: 1}
+			if err := r.Create(ctx, &question3); err != nil {
+				t.Fatal(err)
+			}
+			r.(io.Closer).Close()
+
+			questions, err = openWAL(t, path).GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(append(tc.ExpectedQuestions, question3), questions); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestWALRepositoryFailedAppend(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		question1 = Question{ID: 1, Question: "How many characters are there in \"Quipper\"?", Answer: "7", Version: 1}
+		question2 = Question{ID: 2, Question: "Guess random number, 1, 2, 3 or 4?", Answer: "4", Version: 1}
+	)
+
+	r := openWAL(t, filepath.Join(t.TempDir(), "questions.wal"))
+	if err := r.Create(ctx, &question1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Create(ctx, &question2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Trash(ctx, 2, time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appending to a closed log fails, the change that was applied must be undone.
+	r.(*walRepository).f.Close()
+
+	tt := []struct {
+		Name string
+		Do   func(r Repository) error
+	}{
+		{
+			Name: "create",
+			Do: func(r Repository) error {
+				return r.Create(ctx, &Question{ID: 3, Question: "Quipper vs Ruangguru?", Answer: "Quipper"})
+			},
+		},
+		{
+			Name: "update",
+			Do: func(r Repository) error {
+				return r.Update(ctx, &Question{ID: 1, Question: "How many characterss in \"Quipper\"?", Answer: "7"})
+			},
+		},
+		{
+			Name: "purge",
+			Do: func(r Repository) error {
+				_, err := r.Purge(ctx, time.Now())
+				return err
+			},
+		},
+		{
+			Name: "transaction",
+			Do: func(r Repository) error {
+				return r.WithTx(ctx, func(tx Repository) error {
+					if err := tx.Delete(ctx, 1); err != nil {
+						return err
+					}
+					return tx.Restore(ctx, 2)
+				})
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Do(r); err == nil {
+				t.Fatal("expected error")
+			}
+
+			questions, err := r.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]Question{question1}, questions); diff != "" {
+				t.Fatal(diff)
+			}
+			if err := r.Restore(ctx, 2); err == nil {
+				t.Fatal("expected error")
+			}
+			if _, err := r.GetByID(ctx, 2); !errors.Is(err, ErrQuestionNotFound) {
+				t.Fatalf("expected question 2 in trash, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestWALRepositoryLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.wal")
+
+	r, err := NewWALRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewWALRepository(path); !errors.Is(err, ErrWALLocked) {
+		t.Fatalf("expected: %v, got: %v", ErrWALLocked, err)
+	}
+
+	// The lock is released on close.
+	r.(io.Closer).Close()
+	openWAL(t, path)
+}